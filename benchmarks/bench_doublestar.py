@@ -0,0 +1,75 @@
+"""
+Time glob matching of patterns with several ``**`` segments against deep paths.
+
+Not part of the test suite. Run it from the repository root:
+
+    python benchmarks/bench_doublestar.py [SRC_DIR]
+
+SRC_DIR defaults to this checkout's ``src``; point it at another checkout's ``src`` to
+compare before and after a change. Each case reports the time per match and the peak
+memory allocated while matching, as traced by tracemalloc.
+"""
+
+import sys
+import time
+import tracemalloc
+from pathlib import Path
+
+SRC_DIR = Path(sys.argv[1]) if len(sys.argv) > 1 else Path(__file__).parent.parent / "src"
+sys.path.insert(0, str(SRC_DIR))
+
+from syft_perm.core import match  # noqa: E402
+
+
+def _deep_path(depth: int, tail: str) -> str:
+    middle = "/".join(f"d{i}" for i in range(depth))
+    return f"src/{middle}/docs/{middle}/{tail}"
+
+
+NESTED = "src/**/docs/**/test/*.py"
+REPEATED = "/".join(["a", "b", "c"] * 64) + "/x.txt"
+NEAR_MISS = "/".join(["ca", "cb", "cc"] * 64) + "/x.txt"
+
+# (pattern, description of the path, path)
+CASES = [
+    (NESTED, "depth 20 hit", _deep_path(20, "test/x.py")),
+    (NESTED, "depth 20 miss", _deep_path(20, "tst/x.py")),
+    (NESTED, "depth 80 hit", _deep_path(80, "test/x.py")),
+    (NESTED, "depth 80 miss", _deep_path(80, "tst/x.py")),
+    ("**/a/**/b/**/c/**/d.txt", "193 segments, miss", REPEATED),
+    ("**/a/**/b/**/c/**/x.txt", "193 segments, near miss", NEAR_MISS),
+]
+
+
+def _ns_per_op(pattern: str, path: str, budget: float = 0.5) -> float:
+    """Nanoseconds per match, repeating until ``budget`` seconds have passed."""
+    repeat = 0
+    start = time.perf_counter_ns()
+    while True:
+        match(pattern, path)
+        repeat += 1
+        elapsed = time.perf_counter_ns() - start
+        if elapsed > budget * 1e9:
+            return elapsed / repeat
+
+
+def _peak_bytes(pattern: str, path: str) -> int:
+    """Peak memory traced while matching once."""
+    tracemalloc.start()
+    match(pattern, path)
+    _, peak = tracemalloc.get_traced_memory()
+    tracemalloc.stop()
+    return peak
+
+
+def main() -> None:
+    print(f"syft_perm from {SRC_DIR}")
+    for pattern, description, path in CASES:
+        match(pattern, path)
+        ns = _ns_per_op(pattern, path)
+        peak = _peak_bytes(pattern, path)
+        print(f"{pattern:<26} {description:<24} {ns:>14,.0f} ns/op {peak:>10,} B peak")
+
+
+if __name__ == "__main__":
+    main()
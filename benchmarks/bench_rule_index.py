@@ -0,0 +1,72 @@
+"""
+Time resolution against 500 rules with and without the literal-prefix rule index.
+
+Not part of the test suite. Run it from the repository root:
+
+    python benchmarks/bench_rule_index.py
+
+Without the index every rule is tried against every path, as before it existed.
+"""
+
+import random
+import sys
+import time
+from pathlib import Path
+from unittest.mock import patch
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import Resolver, clear_pattern_cache, parse_permission_file  # noqa: E402
+from syft_perm.core import matcher as matcher_module  # noqa: E402
+
+
+def _all_candidates(self, rel_path, relative_path):
+    """Treat every rule as a candidate, like scanning them all."""
+    return set(self.order)
+
+
+def _rules(rng: random.Random) -> str:
+    lines = ["rules:"]
+    for i in range(500):
+        directory = f"team{i % 50}/proj{i}"
+        pattern = rng.choice(
+            [
+                f"{directory}/**",
+                f"{directory}/*.csv",
+                f"{directory}/data/**/*.json",
+                f"{directory}/docs/*.{{md,txt}}",
+            ]
+        )
+        lines.append(f'- pattern: "{pattern}"\n  access:\n    read: [user{i}@example.com]')
+    lines.append('- pattern: "**/*.log"\n  access:\n    read: ["*"]')
+    return "\n".join(lines) + "\n"
+
+
+def _seconds(permission_files, paths) -> float:
+    # A fresh resolver and pattern cache so no run reuses another's compiled matchers
+    clear_pattern_cache()
+    resolver = Resolver("/nonexistent", permission_files=permission_files, stat_func=None)
+    start = time.perf_counter()
+    for path in paths:
+        resolver.resolve(path, "user3@example.com")
+    return time.perf_counter() - start
+
+
+def main() -> None:
+    rng = random.Random(0)
+    permission_files = {"": parse_permission_file(_rules(rng))}
+    names = ["a.csv", "data/x/y.json", "docs/r.md", "z.log"]
+    paths = [
+        f"team{rng.randrange(50)}/proj{rng.randrange(500)}/{rng.choice(names)}" for _ in range(200)
+    ]
+
+    indexed = _seconds(permission_files, paths)
+    with patch.object(matcher_module._RuleIndex, "candidates", _all_candidates):
+        naive = _seconds(permission_files, paths)
+    print(f"{len(paths)} paths, 501 rules")
+    print(f"every rule: {naive / len(paths) * 1e3:8.2f} ms/resolve")
+    print(f"rule index: {indexed / len(paths) * 1e3:8.2f} ms/resolve")
+
+
+if __name__ == "__main__":
+    main()
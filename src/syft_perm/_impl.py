@@ -25,11 +25,15 @@ from .core import (
     _acl_norm_path,
     _calculate_glob_specificity,
     _doublestar_match,
+    _effective_access_level,
     _glob_match,
     _is_owner,
     _sort_rules_by_specificity,
+    _split_negation,
+    _user_in,
     clear_permission_cache,
     get_cache_stats,
+    parse_access_level,
 )
 from .core.permissions import _permission_cache
 from .core.visualization import PermissionExplanation, ShareWidget
@@ -165,10 +169,11 @@ class SyftFile:
                     sorted_rules = _sort_rules_by_specificity(rules)
                     for rule in sorted_rules:
                         pattern = rule.get("pattern", "")
+                        negated, match_pattern = _split_negation(pattern)
                         # Check if pattern matches our file path relative to this directory
                         rel_path = str(self._path.relative_to(parent_dir))
-                        if _glob_match(pattern, rel_path):
-                            access = rule.get("access", {})
+                        if _glob_match(match_pattern, rel_path):
+                            access = {} if negated else rule.get("access", {})
                             # Check file limits if present
                             limits = rule.get("limits", {})
                             if limits:
@@ -207,12 +212,13 @@ class SyftFile:
 
                 for rule in sorted_rules:
                     pattern = rule.get("pattern", "")
+                    negated, match_pattern = _split_negation(pattern)
                     # Check if pattern matches our file path relative to this directory
                     rel_path = (
                         str(self._path.relative_to(parent_dir)) if self._path is not None else ""
                     )
-                    if _glob_match(pattern, rel_path):
-                        access = rule.get("access", {})
+                    if _glob_match(match_pattern, rel_path):
+                        access = {} if negated else rule.get("access", {})
                         # Check file limits if present
                         limits = rule.get("limits", {})
                         if limits:
@@ -876,34 +882,10 @@ class SyftFile:
         if _is_owner(str(self._path), user):
             return True
 
-        # Implement permission hierarchy following old syftbox logic: Admin > Write > Create > Read
-        # Get all permission sets
-        admin_users = all_perms.get("admin", [])
-        write_users = all_perms.get("write", [])
-        create_users = all_perms.get("create", [])
-        read_users = all_perms.get("read", [])
-
-        # Check public access for each level
-        everyone_admin = "*" in admin_users
-        everyone_write = "*" in write_users
-        everyone_create = "*" in create_users
-        everyone_read = "*" in read_users
-
-        # Check user-specific access following old syftbox hierarchy logic
-        is_admin = everyone_admin or user in admin_users
-        is_writer = is_admin or everyone_write or user in write_users
-        is_creator = is_writer or everyone_create or user in create_users
-        is_reader = is_creator or everyone_read or user in read_users
-
-        # Return based on requested permission level
-        if permission == "admin":
-            return is_admin
-        elif permission == "write":
-            return is_writer
-        elif permission == "create":
-            return is_creator
-        elif permission == "read":
-            return is_reader
+        # Permission hierarchy following old syftbox logic: Admin > Write > Create > Read.
+        # Users are compared exactly as listed, as old syftbox did
+        level = _effective_access_level(all_perms, user, strict=True)
+        return level >= parse_access_level(permission)
 
     def _get_all_permissions_with_sources(self) -> Dict[str, Any]:
         """Get all permissions using old syftbox nearest-node algorithm with source tracking."""
@@ -938,11 +920,12 @@ class SyftFile:
                         sorted_rules = _sort_rules_by_specificity(rules)
                         for rule in sorted_rules:
                             pattern = rule.get("pattern", "")
+                            negated, match_pattern = _split_negation(pattern)
                             # Check if pattern matches our file path relative to this directory
                             rel_path = str(self._path.relative_to(parent_dir))
-                            if _glob_match(pattern, rel_path):
+                            if _glob_match(match_pattern, rel_path):
                                 matched_pattern = pattern  # Also track in general matched pattern
-                                access = rule.get("access", {})
+                                access = {} if negated else rule.get("access", {})
 
                                 # Check file limits if present
                                 limits = rule.get("limits", {})
@@ -1000,14 +983,15 @@ class SyftFile:
                     found_matching_rule = False
                     for rule in sorted_rules:
                         pattern = rule.get("pattern", "")
+                        negated, match_pattern = _split_negation(pattern)
                         # Check if pattern matches our file path relative to this directory
                         rel_path = (
                             str(self._path.relative_to(parent_dir))
                             if self._path is not None
                             else ""
                         )
-                        if _glob_match(pattern, rel_path):
-                            access = rule.get("access", {})
+                        if _glob_match(match_pattern, rel_path):
+                            access = {} if negated else rule.get("access", {})
 
                             # Check file limits if present
                             limits = rule.get("limits", {})
@@ -1096,55 +1080,55 @@ class SyftFile:
         has_permission = False
 
         if permission == "admin":
-            if "*" in admin_users or user in admin_users:
+            if _user_in(admin_users, user, strict=True):
                 has_permission = True
                 if sources.get("admin"):
                     src = sources["admin"][0]
                     reasons.append(f"Explicitly granted admin in {src['path'].parent}")
         elif permission == "write":
-            if "*" in admin_users or user in admin_users:
+            if _user_in(admin_users, user, strict=True):
                 has_permission = True
                 if sources.get("admin"):
                     src = sources["admin"][0]
                     reasons.append(f"Included via admin permission in {src['path'].parent}")
-            elif "*" in write_users or user in write_users:
+            elif _user_in(write_users, user, strict=True):
                 has_permission = True
                 if sources.get("write"):
                     src = sources["write"][0]
                     reasons.append(f"Explicitly granted write in {src['path'].parent}")
         elif permission == "create":
-            if "*" in admin_users or user in admin_users:
+            if _user_in(admin_users, user, strict=True):
                 has_permission = True
                 if sources.get("admin"):
                     src = sources["admin"][0]
                     reasons.append(f"Included via admin permission in {src['path'].parent}")
-            elif "*" in write_users or user in write_users:
+            elif _user_in(write_users, user, strict=True):
                 has_permission = True
                 if sources.get("write"):
                     src = sources["write"][0]
                     reasons.append(f"Included via write permission in {src['path'].parent}")
-            elif "*" in create_users or user in create_users:
+            elif _user_in(create_users, user, strict=True):
                 has_permission = True
                 if sources.get("create"):
                     src = sources["create"][0]
                     reasons.append(f"Explicitly granted create in {src['path'].parent}")
         elif permission == "read":
-            if "*" in admin_users or user in admin_users:
+            if _user_in(admin_users, user, strict=True):
                 has_permission = True
                 if sources.get("admin"):
                     src = sources["admin"][0]
                     reasons.append(f"Included via admin permission in {src['path'].parent}")
-            elif "*" in write_users or user in write_users:
+            elif _user_in(write_users, user, strict=True):
                 has_permission = True
                 if sources.get("write"):
                     src = sources["write"][0]
                     reasons.append(f"Included via write permission in {src['path'].parent}")
-            elif "*" in create_users or user in create_users:
+            elif _user_in(create_users, user, strict=True):
                 has_permission = True
                 if sources.get("create"):
                     src = sources["create"][0]
                     reasons.append(f"Included via create permission in {src['path'].parent}")
-            elif "*" in read_users or user in read_users:
+            elif _user_in(read_users, user, strict=True):
                 has_permission = True
                 if sources.get("read"):
                     src = sources["read"][0]
@@ -1416,13 +1400,14 @@ class SyftFolder:
                             sorted_rules = _sort_rules_by_specificity(rules)
                             for rule in sorted_rules:
                                 pattern = rule.get("pattern", "")
+                                negated, match_pattern = _split_negation(pattern)
                                 # Check if pattern matches our folder path
                                 # relative to this directory
                                 rel_path = str(self._path.relative_to(parent_dir))
-                                if _glob_match(pattern, rel_path) or _glob_match(
-                                    pattern, rel_path + "/"
+                                if _glob_match(match_pattern, rel_path) or _glob_match(
+                                    match_pattern, rel_path + "/"
                                 ):
-                                    access = rule.get("access", {})
+                                    access = {} if negated else rule.get("access", {})
                                     # Check file limits if present
                                     limits = rule.get("limits", {})
                                     if limits:
@@ -1447,12 +1432,13 @@ class SyftFolder:
                         found_matching_rule = False
                         for rule in sorted_rules:
                             pattern = rule.get("pattern", "")
+                            negated, match_pattern = _split_negation(pattern)
                             # Check if pattern matches our folder path relative to this directory
                             rel_path = str(self._path.relative_to(parent_dir))
-                            if _glob_match(pattern, rel_path) or _glob_match(
-                                pattern, rel_path + "/"
+                            if _glob_match(match_pattern, rel_path) or _glob_match(
+                                match_pattern, rel_path + "/"
                             ):
-                                access = rule.get("access", {})
+                                access = {} if negated else rule.get("access", {})
                                 # Check file limits if present
                                 limits = rule.get("limits", {})
                                 if limits:
@@ -2114,34 +2100,10 @@ class SyftFolder:
         if _is_owner(str(self._path), user):
             return True
 
-        # Implement permission hierarchy following old syftbox logic: Admin > Write > Create > Read
-        # Get all permission sets
-        admin_users = all_perms.get("admin", [])
-        write_users = all_perms.get("write", [])
-        create_users = all_perms.get("create", [])
-        read_users = all_perms.get("read", [])
-
-        # Check public access for each level
-        everyone_admin = "*" in admin_users
-        everyone_write = "*" in write_users
-        everyone_create = "*" in create_users
-        everyone_read = "*" in read_users
-
-        # Check user-specific access following old syftbox hierarchy logic
-        is_admin = everyone_admin or user in admin_users
-        is_writer = is_admin or everyone_write or user in write_users
-        is_creator = is_writer or everyone_create or user in create_users
-        is_reader = is_creator or everyone_read or user in read_users
-
-        # Return based on requested permission level
-        if permission == "admin":
-            return is_admin
-        elif permission == "write":
-            return is_writer
-        elif permission == "create":
-            return is_creator
-        elif permission == "read":
-            return is_reader
+        # Permission hierarchy following old syftbox logic: Admin > Write > Create > Read.
+        # Users are compared exactly as listed, as old syftbox did
+        level = _effective_access_level(all_perms, user, strict=True)
+        return level >= parse_access_level(permission)
 
     def _check_permission_with_reasons(
         self, user: str, permission: Literal["read", "create", "write", "admin"]
@@ -2176,55 +2138,55 @@ class SyftFolder:
         has_permission = False
 
         if permission == "admin":
-            if "*" in admin_users or user in admin_users:
+            if _user_in(admin_users, user, strict=True):
                 has_permission = True
                 if sources.get("admin"):
                     src = sources["admin"][0]
                     reasons.append(f"Explicitly granted admin in {src['path'].parent}")
         elif permission == "write":
-            if "*" in admin_users or user in admin_users:
+            if _user_in(admin_users, user, strict=True):
                 has_permission = True
                 if sources.get("admin"):
                     src = sources["admin"][0]
                     reasons.append(f"Included via admin permission in {src['path'].parent}")
-            elif "*" in write_users or user in write_users:
+            elif _user_in(write_users, user, strict=True):
                 has_permission = True
                 if sources.get("write"):
                     src = sources["write"][0]
                     reasons.append(f"Explicitly granted write in {src['path'].parent}")
         elif permission == "create":
-            if "*" in admin_users or user in admin_users:
+            if _user_in(admin_users, user, strict=True):
                 has_permission = True
                 if sources.get("admin"):
                     src = sources["admin"][0]
                     reasons.append(f"Included via admin permission in {src['path'].parent}")
-            elif "*" in write_users or user in write_users:
+            elif _user_in(write_users, user, strict=True):
                 has_permission = True
                 if sources.get("write"):
                     src = sources["write"][0]
                     reasons.append(f"Included via write permission in {src['path'].parent}")
-            elif "*" in create_users or user in create_users:
+            elif _user_in(create_users, user, strict=True):
                 has_permission = True
                 if sources.get("create"):
                     src = sources["create"][0]
                     reasons.append(f"Explicitly granted create in {src['path'].parent}")
         elif permission == "read":
-            if "*" in admin_users or user in admin_users:
+            if _user_in(admin_users, user, strict=True):
                 has_permission = True
                 if sources.get("admin"):
                     src = sources["admin"][0]
                     reasons.append(f"Included via admin permission in {src['path'].parent}")
-            elif "*" in write_users or user in write_users:
+            elif _user_in(write_users, user, strict=True):
                 has_permission = True
                 if sources.get("write"):
                     src = sources["write"][0]
                     reasons.append(f"Included via write permission in {src['path'].parent}")
-            elif "*" in create_users or user in create_users:
+            elif _user_in(create_users, user, strict=True):
                 has_permission = True
                 if sources.get("create"):
                     src = sources["create"][0]
                     reasons.append(f"Included via create permission in {src['path'].parent}")
-            elif "*" in read_users or user in read_users:
+            elif _user_in(read_users, user, strict=True):
                 has_permission = True
                 if sources.get("read"):
                     src = sources["read"][0]
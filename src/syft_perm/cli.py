@@ -0,0 +1,125 @@
+"""Command-line access checks against a datasite's permission files."""
+
+import argparse
+import sys
+from pathlib import Path
+from typing import List, Optional, TextIO
+
+from .core import AccessLevel, Resolver, SyftPermError, load_permission_file, parse_access_level
+
+# Exit codes: the question was answered yes, answered no, or couldn't be answered
+EXIT_OK = 0
+EXIT_DENIED = 1
+EXIT_ERROR = 2
+
+
+def _resolver(args: argparse.Namespace) -> Resolver:
+    root = Path(args.root)
+    if not root.is_dir():
+        raise FileNotFoundError(f"datasite root {root} is not a directory")
+    return Resolver(root, owner=args.owner)
+
+
+def _check(args: argparse.Namespace, out: TextIO) -> int:
+    level, trace = _resolver(args).resolve_with_trace(args.path, args.user)
+    print(level, file=out)
+    if args.trace:
+        for match in trace:
+            print(match, file=out)
+    return EXIT_OK if level >= args.level else EXIT_DENIED
+
+
+def _validate(args: argparse.Namespace, out: TextIO) -> int:
+    status = EXIT_OK
+    for path in args.files:
+        try:
+            perm_file = load_permission_file(path)
+        except ValueError as e:
+            print(f"{path}: {e}", file=out)
+            status = EXIT_DENIED
+            continue
+        for conflict in perm_file.validate():
+            print(f"{path}: warning: {conflict}", file=out)
+        print(f"{path}: ok ({len(perm_file.rules)} rules)", file=out)
+    return status
+
+
+def _list(args: argparse.Namespace, out: TextIO) -> int:
+    for path, level in _resolver(args).walk(args.user, prune_no_access=True):
+        if level >= args.level:
+            print(f"{level}\t{path}", file=out)
+    return EXIT_OK
+
+
+def _access_level(value: str) -> AccessLevel:
+    try:
+        return parse_access_level(value)
+    except ValueError as e:
+        raise argparse.ArgumentTypeError(str(e)) from None
+
+
+def build_parser() -> argparse.ArgumentParser:
+    """Build the argument parser of the ``syftperm`` command."""
+    parser = argparse.ArgumentParser(
+        prog="syftperm",
+        description="Inspect SyftBox permissions from the shell.",
+        epilog=(
+            f"exit status: {EXIT_OK} if allowed or valid, {EXIT_DENIED} if access is denied"
+            f" or a file is invalid, {EXIT_ERROR} on errors"
+        ),
+    )
+    commands = parser.add_subparsers(dest="command", required=True)
+
+    def add_datasite_options(command: argparse.ArgumentParser) -> None:
+        command.add_argument("--root", default=".", help="datasite root (default: .)")
+        command.add_argument("--user", required=True, help="user to resolve for")
+        command.add_argument("--owner", help="datasite owner that {owner} entries stand for")
+        command.add_argument(
+            "--level",
+            type=_access_level,
+            default=AccessLevel.READ,
+            help="least access level that counts as allowed (default: read)",
+        )
+
+    check = commands.add_parser("check", help="print a user's access level on a path")
+    add_datasite_options(check)
+    check.add_argument("--trace", action="store_true", help="also print the decision trace")
+    check.add_argument("path", help="path relative to the datasite root")
+    check.set_defaults(handler=_check)
+
+    validate = commands.add_parser("validate", help="lint syft.pub.yaml files")
+    validate.add_argument("files", nargs="+", help="permission files to check")
+    validate.set_defaults(handler=_validate)
+
+    list_files = commands.add_parser("list", help="list the files a user can access")
+    add_datasite_options(list_files)
+    list_files.set_defaults(handler=_list)
+    return parser
+
+
+def main(
+    argv: Optional[List[str]] = None, out: Optional[TextIO] = None, err: Optional[TextIO] = None
+) -> int:
+    """
+    Run the ``syftperm`` command.
+
+    Usage errors are reported by argparse, which exits with status 2 (EXIT_ERROR).
+
+    Args:
+        argv: Arguments without the program name; defaults to ``sys.argv[1:]``
+        out: Stream results are printed to; defaults to stdout
+        err: Stream errors are printed to; defaults to stderr
+
+    Returns:
+        int: EXIT_OK, EXIT_DENIED or EXIT_ERROR
+    """
+    args = build_parser().parse_args(argv)
+    try:
+        return args.handler(args, out or sys.stdout)
+    except (SyftPermError, OSError, ValueError) as e:
+        print(f"syftperm: error: {e}", file=err or sys.stderr)
+        return EXIT_ERROR
+
+
+if __name__ == "__main__":
+    sys.exit(main())
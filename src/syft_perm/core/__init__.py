@@ -1,37 +1,215 @@
 """Core components for syft-perm."""
 
+from .acl_cache import WILDCARD_USER, AclCache, export_acl_cache
+from .builder import PermissionFileBuilder
+from .datasite import CombineStrategy, Datasite, federated_resolve, load_datasite
+from .diff import AccessChange, diff_access
+from .errors import (
+    InvalidPatternError,
+    PathEscapesRootError,
+    PatternSyntaxError,
+    PermissionFileBuildError,
+    PermissionFileNotFoundError,
+    SyftPermError,
+    UnknownAccessLevelError,
+)
+from .filesystem import (
+    FileSystem,
+    MemoryFileSystem,
+    OSFileSystem,
+    TarFileSystem,
+    ZipFileSystem,
+    find_entry,
+)
+from .matcher import (
+    PatternMatcher,
+    clear_pattern_cache,
+    compile_pattern,
+    get_pattern_cache_stats,
+    match_any,
+    warm_pattern_cache,
+)
+from .metrics import Metrics
 from .path_matching import (
+    DEFAULT_MAX_WILDCARDS,
+    MatchCapabilities,
+    MatchOptions,
     _acl_norm_path,
     _calculate_glob_specificity,
     _doublestar_match,
     _glob_match,
     _sort_rules_by_specificity,
+    _split_negation,
+    capabilities,
+    escape_pattern,
+    explain_pattern,
+    is_recursive,
+    match,
+    match_fold,
+    match_prefix,
+    normalize_pattern,
+    pattern_specificity,
 )
 from .permissions import (
+    OWNER_PLACEHOLDER,
+    USER_PLACEHOLDER,
+    AccessLevel,
+    EmailUserMatcher,
     PermissionCache,
     PermissionReason,
     PermissionResult,
+    UserMatcher,
+    Verb,
+    _effective_access_level,
     _is_owner,
+    _user_in,
+    _user_matches,
+    canonical_user,
     clear_permission_cache,
     get_cache_stats,
+    level_for_verbs,
+    parse_access_level,
+    parse_verb,
+    user_path_segment,
+    verbs_for_level,
+)
+from .resolver import (
+    Cancellation,
+    DenialReason,
+    DotSegments,
+    MatchKind,
+    ResolutionCancelled,
+    ResolutionStrategy,
+    ResolutionTimeout,
+    Resolver,
+    RuleMatch,
+    RuleStat,
+    StatFunc,
+    Trace,
+    TraceReason,
+)
+from .rules import (
+    IGNORE_FILE_NAME,
+    PERMISSION_FILE_NAME,
+    DuplicateUser,
+    EffectiveRule,
+    EffectiveRuleset,
+    InvalidUser,
+    PermissionFile,
+    Rule,
+    RuleConflict,
+    SourcePosition,
+    UnreachableRule,
+    load_permission_file,
+    merge_rule_chain,
+    parse_ignore_file,
+    parse_permission_file,
 )
+from .store import PermissionStore
 from .visualization import (
     PermissionExplanation,
     ShareWidget,
 )
 
 __all__ = [
+    "AccessLevel",
+    "OWNER_PLACEHOLDER",
+    "USER_PLACEHOLDER",
+    "parse_access_level",
+    "Verb",
+    "parse_verb",
+    "verbs_for_level",
+    "level_for_verbs",
+    "canonical_user",
+    "user_path_segment",
+    "UserMatcher",
+    "EmailUserMatcher",
+    "PermissionFile",
+    "SyftPermError",
+    "InvalidPatternError",
+    "PatternSyntaxError",
+    "UnknownAccessLevelError",
+    "PermissionFileNotFoundError",
+    "PermissionFileBuilder",
+    "PermissionFileBuildError",
+    "Rule",
+    "InvalidUser",
+    "DuplicateUser",
+    "RuleConflict",
+    "UnreachableRule",
+    "SourcePosition",
+    "PERMISSION_FILE_NAME",
+    "load_permission_file",
+    "merge_rule_chain",
+    "EffectiveRule",
+    "EffectiveRuleset",
+    "parse_permission_file",
+    "IGNORE_FILE_NAME",
+    "parse_ignore_file",
+    "Datasite",
+    "load_datasite",
+    "CombineStrategy",
+    "federated_resolve",
+    "Resolver",
+    "AccessChange",
+    "diff_access",
+    "AclCache",
+    "WILDCARD_USER",
+    "export_acl_cache",
+    "PermissionStore",
+    "FileSystem",
+    "OSFileSystem",
+    "MemoryFileSystem",
+    "ZipFileSystem",
+    "TarFileSystem",
+    "find_entry",
+    "PathEscapesRootError",
+    "Cancellation",
+    "ResolutionCancelled",
+    "ResolutionStrategy",
+    "DotSegments",
+    "Metrics",
+    "ResolutionTimeout",
+    "StatFunc",
+    "RuleMatch",
+    "RuleStat",
+    "Trace",
+    "TraceReason",
+    "DenialReason",
+    "MatchKind",
     "PermissionReason",
     "PermissionResult",
     "PermissionCache",
     "get_cache_stats",
     "clear_permission_cache",
     "_is_owner",
+    "_effective_access_level",
+    "_user_in",
+    "_user_matches",
     "_acl_norm_path",
     "_doublestar_match",
     "_glob_match",
     "_calculate_glob_specificity",
     "_sort_rules_by_specificity",
+    "_split_negation",
+    "MatchCapabilities",
+    "MatchOptions",
+    "DEFAULT_MAX_WILDCARDS",
+    "PatternMatcher",
+    "compile_pattern",
+    "match_any",
+    "warm_pattern_cache",
+    "get_pattern_cache_stats",
+    "clear_pattern_cache",
+    "match",
+    "capabilities",
+    "escape_pattern",
+    "explain_pattern",
+    "is_recursive",
+    "match_fold",
+    "match_prefix",
+    "normalize_pattern",
+    "pattern_specificity",
     "PermissionExplanation",
     "ShareWidget",
 ]
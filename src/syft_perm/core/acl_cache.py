@@ -0,0 +1,79 @@
+"""Flatten a datasite's permissions into the precomputed map the sync client reads."""
+
+import posixpath
+from pathlib import Path
+from typing import Dict, List, Optional, Union
+
+from .path_matching import MatchOptions
+from .permissions import OWNER_PLACEHOLDER, AccessLevel, canonical_user
+from .resolver import Cancellation, Resolver
+
+# Reserved user key holding what "*" grants, i.e. the access of anyone not named
+WILDCARD_USER = "*"
+
+# Datasite-relative file path -> user -> access level
+AclCache = Dict[str, Dict[str, AccessLevel]]
+
+
+def export_acl_cache(
+    root: Union[str, Path],
+    owner: Optional[str] = None,
+    match_options: Optional[MatchOptions] = None,
+    default_access: AccessLevel = AccessLevel.NONE,
+    cancel: Optional[Cancellation] = None,
+) -> AclCache:
+    """
+    Resolve every file of a datasite for every user its rules name.
+
+    Files are listed with Resolver.walk, so hidden entries and the permission files
+    themselves are left out. The users are every entry of every rule that applies to
+    some file, with ``{owner}`` standing for ``owner``. Two kinds of key are reserved:
+    ``*`` holds what a user no rule names gets, and each ``*@domain`` entry is kept as
+    its own key holding what an unnamed user at that domain gets. Only levels above
+    NONE are included, so a missing user means the ``*`` level, or none at all.
+
+    Args:
+        root: Datasite root directory
+        owner: Datasite owner that ``{owner}`` entries stand for
+        match_options: Options passed to the glob matcher
+        default_access: Level for paths no rule matches
+        cancel: Optional Cancellation checked at every directory
+
+    Returns:
+        dict: Users and their levels keyed by datasite-relative path, in walk order
+
+    Raises:
+        ResolutionCancelled: If ``cancel`` aborts the export
+    """
+    resolver = Resolver(
+        root, match_options=match_options, default_access=default_access, owner=owner
+    )
+    cache: AclCache = {}
+    for path, level in resolver.walk(WILDCARD_USER, cancel=cancel):
+        cache[path] = {WILDCARD_USER: level} if level > AccessLevel.NONE else {}
+
+    paths = list(cache)
+    for user in _named_users(resolver, paths, cancel):
+        for path, level in resolver.resolve_batch(paths, user, cancel).items():
+            if level > AccessLevel.NONE:
+                cache[path][user] = level
+    return cache
+
+
+def _named_users(
+    resolver: Resolver, paths: List[str], cancel: Optional[Cancellation] = None
+) -> List[str]:
+    """Every user entry, other than ``*``, granted or revoked by the rules that can apply."""
+    users = set()
+    # Files in one directory share their permission chain, so one path per directory does
+    for path in {posixpath.dirname(path): path for path in paths}.values():
+        for effective in resolver.ruleset_for(path, cancel):
+            rule = effective.rule
+            for _, entries in rule.verb_lists() + list(rule.revoke.items()):
+                for entry in entries:
+                    if entry == OWNER_PLACEHOLDER:
+                        if resolver.owner is not None:
+                            users.add(canonical_user(resolver.owner))
+                    elif entry != WILDCARD_USER:
+                        users.add(entry)
+    return sorted(users)
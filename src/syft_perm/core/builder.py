@@ -0,0 +1,171 @@
+"""Fluent construction of permission files from code."""
+
+from datetime import datetime
+from typing import Any, Dict, List, Optional, Tuple, Union
+
+from .errors import PermissionFileBuildError, UnknownAccessLevelError
+from .path_matching import _split_negation, _validate_pattern
+from .permissions import AccessLevel, Verb, parse_access_level, parse_verb
+from .rules import PERMISSION_FILE_NAME, PermissionFile, _parse_rule
+
+
+class PermissionFileBuilder:
+    """
+    Build a PermissionFile one rule at a time, checking each step as it is made.
+
+    ``add_rule`` starts a rule, and the calls after it up to the next ``add_rule``
+    configure that rule. Mistakes don't raise straight away: they are collected and
+    all reported together by ``build``, so a generated config shows every problem at
+    once. For example::
+
+        PermissionFileBuilder().add_rule("*.csv").grant("write", "alice@example.com")
+            .grant(AccessLevel.READ, "*").terminal().build()
+    """
+
+    def __init__(self):
+        self._rules: List[Dict[str, Any]] = []
+        self._terminal_file = False
+        self._errors: List[str] = []
+
+    def add_rule(self, pattern: str) -> "PermissionFileBuilder":
+        """Start a new rule matching ``pattern`` (``!`` prefixed for an exclusion)."""
+        if not isinstance(pattern, str):
+            self._errors.append(f"rule {len(self._rules)}: pattern must be a string")
+            pattern = str(pattern)
+        else:
+            try:
+                _validate_pattern(_split_negation(pattern)[1])
+            except ValueError as e:
+                self._errors.append(f"rule {len(self._rules)}: invalid pattern {pattern!r}: {e}")
+        self._rules.append({"pattern": pattern, "access": {}})
+        return self
+
+    def grant(self, level: Union[AccessLevel, str], *users: str) -> "PermissionFileBuilder":
+        """Give users an access level under the current rule."""
+        return self._add_users("grant", "access", level, users)
+
+    def revoke(self, level: Union[AccessLevel, str], *users: str) -> "PermissionFileBuilder":
+        """Take an access level, and those above it, away from users under the current rule."""
+        return self._add_users("revoke", "revoke", level, users)
+
+    def allow(self, verb: Union[Verb, str], *users: str) -> "PermissionFileBuilder":
+        """Give users single verbs, on top of any access level, under the current rule."""
+        rule = self._current("allow")
+        if rule is None:
+            return self
+        try:
+            verb = verb if isinstance(verb, Verb) else parse_verb(verb)
+        except UnknownAccessLevelError as e:
+            self._rule_error(str(e))
+            return self
+        if not verb:
+            self._rule_error("cannot allow no verbs")
+            return self
+        if not users or not all(isinstance(user, str) and user for user in users):
+            self._rule_error(f"users for '{verb}' must be non-empty strings")
+            return self
+        verbs = rule.setdefault("verbs", {})
+        for member in Verb:
+            if member in verb:
+                verbs.setdefault(str(member), []).extend(users)
+        return self
+
+    def _add_users(
+        self, method: str, key: str, level: Union[AccessLevel, str], users: Tuple[str, ...]
+    ) -> "PermissionFileBuilder":
+        """List users under a level of the current rule's ``access`` or ``revoke``."""
+        rule = self._current(method)
+        if rule is None:
+            return self
+        try:
+            level = level if isinstance(level, AccessLevel) else parse_access_level(level)
+        except UnknownAccessLevelError as e:
+            self._rule_error(str(e))
+            return self
+        if level == AccessLevel.NONE:
+            self._rule_error(f"cannot {method} '{level}'")
+            return self
+        if not users or not all(isinstance(user, str) and user for user in users):
+            self._rule_error(f"users for '{level}' must be non-empty strings")
+            return self
+        rule.setdefault(key, {}).setdefault(str(level), []).extend(users)
+        return self
+
+    def terminal(self) -> "PermissionFileBuilder":
+        """Make the current rule terminal for the paths it matches."""
+        rule = self._current("terminal")
+        if rule is not None:
+            rule["terminal"] = True
+        return self
+
+    def priority(self, priority: int) -> "PermissionFileBuilder":
+        """Set the current rule's priority."""
+        rule = self._current("priority")
+        if rule is not None:
+            rule["priority"] = priority
+        return self
+
+    def depth(
+        self, min_depth: Optional[int] = None, max_depth: Optional[int] = None
+    ) -> "PermissionFileBuilder":
+        """Limit the current rule to paths with this many segments below its directory."""
+        rule = self._current("depth")
+        if rule is not None:
+            rule["min_depth"] = min_depth
+            rule["max_depth"] = max_depth
+        return self
+
+    def active(
+        self, not_before: Optional[datetime] = None, not_after: Optional[datetime] = None
+    ) -> "PermissionFileBuilder":
+        """Limit the current rule to the time from ``not_before`` until ``not_after``."""
+        rule = self._current("active")
+        if rule is not None:
+            rule["not_before"] = not_before
+            rule["not_after"] = not_after
+        return self
+
+    def limits(self, **limits: Any) -> "PermissionFileBuilder":
+        """Add file limits (max_file_size, allowed_extensions, ...) to the current rule."""
+        rule = self._current("limits")
+        if rule is not None:
+            rule.setdefault("limits", {}).update(limits)
+        return self
+
+    def terminal_file(self) -> "PermissionFileBuilder":
+        """Make the whole file terminal, so permission files above it are ignored."""
+        self._terminal_file = True
+        return self
+
+    def build(self) -> PermissionFile:
+        """
+        Validate everything added so far and create the permission file.
+
+        Returns:
+            PermissionFile: The rules in the order they were added
+
+        Raises:
+            PermissionFileBuildError: Listing every problem found, if there were any
+        """
+        errors = list(self._errors)
+        rules = []
+        for index, raw in enumerate(self._rules):
+            try:
+                rules.append(_parse_rule(raw, PERMISSION_FILE_NAME, index))
+            except ValueError as e:
+                errors.append(str(e))
+        if errors:
+            raise PermissionFileBuildError(errors)
+        return PermissionFile(rules=rules, terminal=self._terminal_file)
+
+    def _current(self, method: str) -> Optional[Dict[str, Any]]:
+        """The rule being configured, recording an error if there is none yet."""
+        if not self._rules:
+            self._errors.append(f"{method}() called before add_rule()")
+            return None
+        return self._rules[-1]
+
+    def _rule_error(self, problem: str) -> None:
+        """Record a problem with the rule being configured."""
+        index = len(self._rules) - 1
+        self._errors.append(f"rule {index} ({self._rules[index]['pattern']!r}): {problem}")
@@ -0,0 +1,262 @@
+"""Load every permission file of a datasite tree in one pass."""
+
+import logging
+import posixpath
+import threading
+from dataclasses import dataclass, field
+from enum import Enum
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Sequence, Tuple, Union
+
+from .filesystem import FileSystem, OSFileSystem
+from .path_matching import DEFAULT_MAX_WILDCARDS, _acl_norm_path
+from .permissions import AccessLevel
+from .resolver import Resolver
+from .rules import PERMISSION_FILE_NAME, PermissionFile, parse_permission_file
+
+logger = logging.getLogger(__name__)
+
+
+class CombineStrategy(Enum):
+    """How the levels a user has on one path in several datasites combine."""
+
+    MOST_PERMISSIVE = "most_permissive"
+    MOST_RESTRICTIVE = "most_restrictive"
+
+
+@dataclass
+class Datasite:
+    """
+    Every permission file of a datasite, indexed by the directory holding it.
+
+    Attributes:
+        root: Datasite root directory
+        files: Permission files keyed by datasite-relative directory ("" for the root)
+        errors: Errors of the malformed files skipped while loading, keyed the same
+            way. Each skipped file is in ``files`` as a rule-less terminal stand-in.
+        filesystem: The filesystem the files were read from, if not the local disk
+        unvisited: Directories not searched because they are deeper than the
+            ``max_depth`` loaded with, in walk order
+        strict_users: Whether user entries were kept exactly as written
+        max_wildcards: Most wildcards a pattern could contain; None for no limit
+        variables: Values the ``${name}`` references were expanded with
+    """
+
+    root: Path
+    files: Dict[str, PermissionFile] = field(default_factory=dict)
+    errors: Dict[str, ValueError] = field(default_factory=dict)
+    filesystem: Optional[FileSystem] = None
+    unvisited: List[str] = field(default_factory=list)
+    strict_users: bool = False
+    max_wildcards: Optional[int] = DEFAULT_MAX_WILDCARDS
+    variables: Optional[Mapping[str, str]] = None
+    _reload_lock: threading.Lock = field(
+        default_factory=threading.Lock, init=False, repr=False, compare=False
+    )
+
+    @property
+    def directories(self) -> List[str]:
+        """Directories holding a permission file, sorted."""
+        return sorted(self.files)
+
+    def chain(self, path: str) -> List[Tuple[str, PermissionFile]]:
+        """
+        The permission files from the root down to a path's directory.
+
+        Takes one lookup per directory on the way, whatever the size of the tree.
+
+        Args:
+            path: Datasite-relative path of a file or directory
+
+        Returns:
+            list: (directory, permission file) pairs, root first
+        """
+        segments = _acl_norm_path(path).split("/")[:-1]
+        chain = []
+        for depth in range(len(segments) + 1):
+            directory = "/".join(segments[:depth])
+            perm_file = self.files.get(directory)
+            if perm_file is not None:
+                chain.append((directory, perm_file))
+        return chain
+
+    def resolver(self, **options: Any) -> Resolver:
+        """
+        A resolver over the loaded files, which never reads syft.pub.yaml again.
+
+        Args:
+            **options: Any other Resolver arguments, e.g. ``owner`` or ``strategy``
+
+        Returns:
+            Resolver: Resolver rooted at the datasite
+        """
+        return Resolver(
+            self.root, permission_files=self.files, filesystem=self.filesystem, **options
+        )
+
+    def reload_file(self, directory: str) -> None:
+        """
+        Re-read the permission file of one directory and swap it into the index.
+
+        The file is parsed and validated with the options the datasite was loaded with
+        before anything changes, so a malformed file raises and leaves the index as it
+        was. A directory whose file was removed drops out of the index. ``files`` is
+        replaced by a new dict in one assignment rather than changed in place: code
+        reading it concurrently sees either the old file or the new one, and resolvers
+        made earlier keep the files they were made with. Reloads are serialized with
+        each other.
+
+        Args:
+            directory: Datasite-relative directory ("" for the root)
+
+        Raises:
+            OSError: If the file cannot be read
+            ValueError: If the file is malformed; the loaded file is kept
+        """
+        directory = _acl_norm_path(directory)
+        source = self.filesystem if self.filesystem is not None else OSFileSystem(self.root)
+        rel_path = posixpath.join(directory, PERMISSION_FILE_NAME)
+        with self._reload_lock:
+            files = dict(self.files)
+            if source.is_file(rel_path):
+                files[directory] = parse_permission_file(
+                    source.read_text(rel_path),
+                    self.root / rel_path,
+                    self.strict_users,
+                    self.max_wildcards,
+                    self.variables,
+                )
+            else:
+                files.pop(directory, None)
+            errors = dict(self.errors)
+            errors.pop(directory, None)
+            self.errors = errors
+            self.files = files
+
+    def __len__(self) -> int:
+        return len(self.files)
+
+
+def load_datasite(
+    root: Union[str, Path],
+    filesystem: Optional[FileSystem] = None,
+    strict_users: bool = False,
+    max_wildcards: Optional[int] = DEFAULT_MAX_WILDCARDS,
+    skip_invalid_files: bool = False,
+    variables: Optional[Mapping[str, str]] = None,
+    max_depth: Optional[int] = None,
+) -> Datasite:
+    """
+    Find, parse and validate every syft.pub.yaml under a datasite root.
+
+    Hidden directories are not searched, as in Resolver.walk. With
+    ``skip_invalid_files`` set, a malformed file is logged, recorded in
+    ``Datasite.errors`` and replaced by a rule-less terminal file, so a broken file
+    can only take access away.
+
+    With ``max_depth`` set, directories below that level are not searched and are
+    listed in ``Datasite.unvisited``. Permission files in them are not loaded, so a
+    resolver over the datasite treats paths there as if those files didn't exist.
+
+    Args:
+        root: Datasite root directory
+        filesystem: Read the tree from this filesystem, rooted at the datasite, instead
+            of from ``root`` on disk
+        strict_users: Keep user entries exactly as written
+        max_wildcards: Most wildcards a pattern may contain; None for no limit
+        skip_invalid_files: Collect malformed files instead of raising
+        variables: Values for ``${name}`` references in patterns and user lists, as in
+            parse_permission_file
+        max_depth: Deepest directory level searched, the root being 0, or None for no
+            limit
+
+    Returns:
+        Datasite: The loaded files
+
+    Raises:
+        ValueError: If a permission file is malformed and ``skip_invalid_files`` isn't
+            set; the message starts with the file's path
+    """
+    root = Path(root)
+    source = filesystem if filesystem is not None else OSFileSystem(root)
+    datasite = Datasite(
+        root,
+        filesystem=filesystem,
+        strict_users=strict_users,
+        max_wildcards=max_wildcards,
+        variables=variables,
+    )
+    for rel_dir, dirnames, filenames in source.walk(""):
+        dirnames[:] = sorted(name for name in dirnames if not name.startswith("."))
+        depth = rel_dir.count("/") + 1 if rel_dir else 0
+        if max_depth is not None and depth >= max_depth:
+            datasite.unvisited.extend(posixpath.join(rel_dir, name) for name in dirnames)
+            dirnames[:] = []
+        if PERMISSION_FILE_NAME not in filenames:
+            continue
+        rel_path = posixpath.join(rel_dir, PERMISSION_FILE_NAME)
+        try:
+            datasite.files[rel_dir] = parse_permission_file(
+                source.read_text(rel_path),
+                root / rel_path,
+                strict_users,
+                max_wildcards,
+                variables,
+            )
+        except ValueError as e:
+            if not skip_invalid_files:
+                raise
+            logger.warning("skipping invalid permission file: %s", e)
+            datasite.errors[rel_dir] = e
+            datasite.files[rel_dir] = PermissionFile(rules=[], terminal=True, path=root / rel_path)
+    return datasite
+
+
+def federated_resolve(
+    datasites: Sequence[Datasite],
+    path: str,
+    user: str,
+    combine: CombineStrategy = CombineStrategy.MOST_PERMISSIVE,
+    **options: Any,
+) -> AccessLevel:
+    """
+    Resolve a path in each of several datasites and combine the levels into one.
+
+    Serves merged views where the same datasite-relative path can come from several
+    sources with their own rules. Each datasite resolves the path against its own
+    permission files; MOST_PERMISSIVE then gives the highest of their levels and
+    MOST_RESTRICTIVE the lowest.
+
+    Only the datasites in which the path exists, as a file or a directory, take part.
+    A source without the path neither grants nor denies anything, so under
+    MOST_RESTRICTIVE it can't veto access the sources holding the path give. When the
+    path exists in none of them, as for a file about to be created, every datasite
+    takes part.
+
+    Args:
+        datasites: Sources of the view; their order doesn't matter
+        path: Path relative to each datasite's root
+        user: User ID to resolve for
+        combine: How the levels combine; MOST_PERMISSIVE unless set
+        **options: Any other Resolver arguments, passed to every datasite's resolver
+
+    Returns:
+        AccessLevel: The combined level, NONE without any datasites
+
+    Raises:
+        ValueError: If the path is rejected, as by Resolver.resolve
+    """
+    rel_path = _acl_norm_path(path)
+    holding = [datasite for datasite in datasites if _has_path(datasite, rel_path)]
+    levels = [
+        datasite.resolver(**options).resolve(rel_path, user) for datasite in holding or datasites
+    ]
+    if not levels:
+        return AccessLevel.NONE
+    return max(levels) if combine is CombineStrategy.MOST_PERMISSIVE else min(levels)
+
+
+def _has_path(datasite: Datasite, rel_path: str) -> bool:
+    """Whether a file or directory exists at a path of a datasite."""
+    source = datasite.filesystem if datasite.filesystem is not None else OSFileSystem(datasite.root)
+    return source.is_file(rel_path) or source.is_dir(rel_path)
@@ -0,0 +1,89 @@
+"""Preview how editing permission files would change who can access what."""
+
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Dict, Iterable, List, Mapping, Union
+
+from .permissions import AccessLevel
+from .resolver import Resolver
+from .rules import PermissionFile
+
+# A single root permission file, or files keyed by datasite-relative directory
+PermissionTree = Union[PermissionFile, Mapping[str, PermissionFile]]
+
+
+@dataclass(frozen=True)
+class AccessChange:
+    """
+    A user's access to a path before and after a permission edit.
+
+    Attributes:
+        path: Datasite-relative path
+        user: User ID
+        before: Level under the old permission files
+        after: Level under the new permission files
+    """
+
+    path: str
+    user: str
+    before: AccessLevel
+    after: AccessLevel
+
+    @property
+    def gained(self) -> bool:
+        """Whether the edit raises the user's access."""
+        return self.after > self.before
+
+    @property
+    def lost(self) -> bool:
+        """Whether the edit lowers the user's access."""
+        return self.after < self.before
+
+    def __str__(self) -> str:
+        return f"{self.path} {self.user}: {self.before} -> {self.after}"
+
+
+def _as_tree(files: PermissionTree) -> Dict[str, PermissionFile]:
+    """Treat a lone permission file as the datasite root's."""
+    if isinstance(files, PermissionFile):
+        return {"": files}
+    return dict(files)
+
+
+def diff_access(
+    old: PermissionTree,
+    new: PermissionTree,
+    paths: Iterable[Union[str, Path]],
+    users: Iterable[str],
+) -> List[AccessChange]:
+    """
+    Compare access levels under two sets of permission files.
+
+    Both sides are resolved in memory with the usual nearest-node rules, without
+    reading the filesystem, so file size, directory and symlink limits don't apply.
+
+    Args:
+        old: Permission files before the edit (one root file or a directory mapping)
+        new: Permission files after the edit, in the same form
+        paths: Sample datasite-relative paths to compare
+        users: Users to compare for every path
+
+    Returns:
+        list: An AccessChange for each (path, user) pair whose level differs, in the
+            order the paths and users were given
+    """
+    before = Resolver(".", stat_func=None, permission_files=_as_tree(old))
+    after = Resolver(".", stat_func=None, permission_files=_as_tree(new))
+    paths = list(paths)
+
+    changes = []
+    for user in dict.fromkeys(users):
+        old_levels = before.resolve_batch(paths, user)
+        new_levels = after.resolve_batch(paths, user)
+        for path, level in old_levels.items():
+            if new_levels[path] != level:
+                changes.append(AccessChange(path, user, level, new_levels[path]))
+
+    # Report path by path so a reader sees everything that changes for one file together
+    order = {str(path): position for position, path in enumerate(paths)}
+    return sorted(changes, key=lambda change: order[change.path])
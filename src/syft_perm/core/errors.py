@@ -0,0 +1,135 @@
+"""Exception types raised by the permission engine, each carrying the context it failed on."""
+
+import errno
+from pathlib import Path
+from typing import Dict, List, Optional, Sequence, Tuple, Union
+
+
+class SyftPermError(Exception):
+    """Base class of every error the permission engine raises on purpose."""
+
+
+class InvalidPatternError(SyftPermError, ValueError):
+    """
+    A glob pattern is malformed.
+
+    Attributes:
+        pattern: The offending pattern
+        reason: What is wrong with it
+    """
+
+    def __init__(self, pattern: str, reason: str):
+        self.pattern = pattern
+        self.reason = reason
+        super().__init__(f"invalid pattern {pattern!r}: {reason}")
+
+
+class PatternSyntaxError(InvalidPatternError):
+    """
+    One or more rules in a permission file have malformed glob patterns.
+
+    ``pattern`` and ``reason`` describe the first bad rule; ``errors`` lists them all.
+
+    Attributes:
+        source: File the rules were loaded from
+        errors: (rule index, pattern, problem) for every bad rule, in file order
+        lines: Line number of each rule by index, when the file was parsed from yaml
+    """
+
+    def __init__(
+        self,
+        source: str,
+        errors: List[Tuple[int, str, str]],
+        lines: Optional[Dict[int, int]] = None,
+    ):
+        self.source = source
+        self.errors = errors
+        self.lines = lines or {}
+        self.pattern, self.reason = errors[0][1], errors[0][2]
+        details = "; ".join(
+            f"rule {index} ({pattern!r}){_at_line(lines, index)}: {problem}"
+            for index, pattern, problem in errors
+        )
+        ValueError.__init__(self, f"{source}: invalid patterns: {details}")
+
+
+def _at_line(lines: Optional[Dict[int, int]], index: int) -> str:
+    """Format the line of a rule for error messages, if known."""
+    if not lines or index not in lines:
+        return ""
+    return f" at line {lines[index]}"
+
+
+class UnknownAccessLevelError(SyftPermError, ValueError):
+    """
+    An access level name isn't one of the known levels.
+
+    Attributes:
+        value: The name as written
+        known: Names of the valid levels
+        context: Where it was found, e.g. the file and rule, if known
+    """
+
+    def __init__(self, value: object, known: Sequence[str], context: Optional[str] = None):
+        self.value = value
+        self.known = list(known)
+        self.context = context
+        message = f"Unknown access level {value!r} (expected one of: {', '.join(self.known)})"
+        super().__init__(f"{context}: {message}" if context else message)
+
+
+class PermissionFileNotFoundError(SyftPermError, FileNotFoundError):
+    """
+    A permission file that was asked for by path doesn't exist.
+
+    Attributes:
+        path: Path of the missing file (also available as ``filename``)
+    """
+
+    def __init__(self, path: Union[str, Path]):
+        self.path = Path(path)
+        super().__init__(errno.ENOENT, "permission file not found", str(path))
+
+
+class PathEscapesRootError(SyftPermError, ValueError):
+    """
+    A path lies outside the datasite root, or could, through symlinks or ``..``.
+
+    Attributes:
+        path: The path as given
+        root: The datasite root
+        real_path: Where the path really leads when symlinks took it outside, else None
+        reason: Why the path was refused without checking where it leads, else None
+    """
+
+    def __init__(
+        self,
+        path: Union[str, Path],
+        root: Union[str, Path],
+        real_path: Optional[str] = None,
+        reason: Optional[str] = None,
+    ):
+        self.path = path
+        self.root = root
+        self.real_path = real_path
+        self.reason = reason
+        if reason is not None:
+            message = f"{path}: {reason} (datasite root {root})"
+        elif real_path is None:
+            message = f"{path} is not inside datasite root {root}"
+        else:
+            message = f"{path} resolves to {real_path}, outside datasite root {root}"
+        super().__init__(message)
+
+
+class PermissionFileBuildError(SyftPermError, ValueError):
+    """
+    A PermissionFileBuilder was given invalid rules.
+
+    Attributes:
+        errors: Every problem found while building, in the order it was found
+    """
+
+    def __init__(self, errors: List[str]):
+        self.errors = errors
+        super().__init__("invalid permission file: " + "; ".join(errors))
@@ -0,0 +1,298 @@
+"""Read-only filesystems the resolver can load datasites from instead of the local disk."""
+
+import os
+import posixpath
+import stat
+import tarfile
+import zipfile
+from pathlib import Path
+from typing import BinaryIO, Callable, Dict, Iterator, List, Mapping, Optional, Set, Tuple, Union
+
+from .path_matching import MatchOptions, _acl_norm_path, _fold_case
+
+# (directory, subdirectory names, file names), like os.walk but relative to the root
+WalkEntry = Tuple[str, List[str], List[str]]
+
+
+class FileSystem:
+    """
+    A read-only directory tree addressed by relative posix paths, "" being the root.
+
+    Subclass it to serve datasites from somewhere other than the local disk and pass
+    it to Resolver or PermissionStore. Only these methods are needed.
+    """
+
+    def read_text(self, path: str) -> str:
+        """
+        Read a file as UTF-8 text.
+
+        Raises:
+            OSError: If the file doesn't exist or can't be read
+        """
+        raise NotImplementedError
+
+    def is_file(self, path: str) -> bool:
+        """Whether a regular file exists at the path."""
+        raise NotImplementedError
+
+    def is_dir(self, path: str) -> bool:
+        """Whether a directory exists at the path."""
+        raise NotImplementedError
+
+    def stat(self, path: str) -> os.stat_result:
+        """
+        Stat a path without following a final symlink, like ``os.lstat``.
+
+        Raises:
+            OSError: If the path doesn't exist
+        """
+        raise NotImplementedError
+
+    def walk(self, top: str = "") -> Iterator[WalkEntry]:
+        """
+        Walk a directory top-down, like ``os.walk``.
+
+        Directory paths are relative to the filesystem root. Removing names from the
+        yielded subdirectory list skips those subdirectories. A missing ``top`` yields
+        nothing.
+        """
+        raise NotImplementedError
+
+    def sub(self, directory: str) -> "FileSystem":
+        """Get a view of this filesystem rooted at one of its directories."""
+        return SubFileSystem(self, directory)
+
+
+class OSFileSystem(FileSystem):
+    """
+    The local disk below a root directory.
+
+    Args:
+        root: Directory that relative paths start from
+        stat_func: Function used to stat absolute paths, ``os.lstat`` by default
+    """
+
+    def __init__(
+        self, root: Union[str, Path], stat_func: Callable[[Path], os.stat_result] = os.lstat
+    ):
+        self.root = Path(root)
+        self.stat_func = stat_func
+
+    def read_text(self, path: str) -> str:
+        return (self.root / path).read_text()
+
+    def is_file(self, path: str) -> bool:
+        return (self.root / path).is_file()
+
+    def is_dir(self, path: str) -> bool:
+        return (self.root / path).is_dir()
+
+    def stat(self, path: str) -> os.stat_result:
+        return self.stat_func(self.root / path)
+
+    def walk(self, top: str = "") -> Iterator[WalkEntry]:
+        for dirpath, dirnames, filenames in os.walk(self.root / top):
+            yield _acl_norm_path(os.path.relpath(dirpath, self.root)), dirnames, filenames
+
+
+class SubFileSystem(FileSystem):
+    """
+    A directory of another filesystem, seen as a filesystem of its own.
+
+    Args:
+        parent: Filesystem containing the directory
+        directory: Directory of ``parent`` to use as the root
+    """
+
+    def __init__(self, parent: FileSystem, directory: str):
+        self.parent = parent
+        self.directory = _acl_norm_path(directory)
+
+    def _full(self, path: str) -> str:
+        return _acl_norm_path(posixpath.join(self.directory, path))
+
+    def read_text(self, path: str) -> str:
+        return self.parent.read_text(self._full(path))
+
+    def is_file(self, path: str) -> bool:
+        return self.parent.is_file(self._full(path))
+
+    def is_dir(self, path: str) -> bool:
+        return self.parent.is_dir(self._full(path))
+
+    def stat(self, path: str) -> os.stat_result:
+        return self.parent.stat(self._full(path))
+
+    def walk(self, top: str = "") -> Iterator[WalkEntry]:
+        prefix = len(self.directory) + 1 if self.directory else 0
+        for dirpath, dirnames, filenames in self.parent.walk(self._full(top)):
+            yield dirpath[prefix:], dirnames, filenames
+
+
+class _IndexedFileSystem(FileSystem):
+    """A filesystem built from a flat list of file paths, such as a dict or an archive."""
+
+    def __init__(self, sizes: Mapping[str, int], directories: Set[str] = frozenset()):
+        self._sizes: Dict[str, int] = {}
+        self._children: Dict[str, Tuple[Set[str], Set[str]]] = {"": (set(), set())}
+        for directory in directories:
+            self._add_directory(_acl_norm_path(directory))
+        for path, size in sizes.items():
+            path = _acl_norm_path(path)
+            self._sizes[path] = size
+            parent, name = posixpath.split(path)
+            self._add_directory(parent)
+            self._children[parent][1].add(name)
+
+    def _add_directory(self, directory: str) -> None:
+        if directory not in self._children:
+            self._children[directory] = (set(), set())
+            parent, name = posixpath.split(directory)
+            self._add_directory(parent)
+            self._children[parent][0].add(name)
+
+    def _read_bytes(self, path: str) -> bytes:
+        raise NotImplementedError
+
+    def read_text(self, path: str) -> str:
+        path = _acl_norm_path(path)
+        if path not in self._sizes:
+            raise FileNotFoundError(path)
+        return self._read_bytes(path).decode("utf-8")
+
+    def is_file(self, path: str) -> bool:
+        return _acl_norm_path(path) in self._sizes
+
+    def is_dir(self, path: str) -> bool:
+        return _acl_norm_path(path) in self._children
+
+    def stat(self, path: str) -> os.stat_result:
+        path = _acl_norm_path(path)
+        if path in self._sizes:
+            return os.stat_result((stat.S_IFREG | 0o644, 0, 0, 1, 0, 0, self._sizes[path], 0, 0, 0))
+        if path in self._children:
+            return os.stat_result((stat.S_IFDIR | 0o755, 0, 0, 1, 0, 0, 0, 0, 0, 0))
+        raise FileNotFoundError(path)
+
+    def walk(self, top: str = "") -> Iterator[WalkEntry]:
+        top = _acl_norm_path(top)
+        if top not in self._children:
+            return
+        pending = [top]
+        while pending:
+            directory = pending.pop()
+            subdirectories, files = self._children[directory]
+            dirnames = sorted(subdirectories)
+            yield directory, dirnames, sorted(files)
+            pending.extend(posixpath.join(directory, name) for name in reversed(dirnames))
+
+
+class MemoryFileSystem(_IndexedFileSystem):
+    """
+    Files held in memory, keyed by relative path; directories are implied by the paths.
+
+    Args:
+        files: File contents keyed by relative posix path
+    """
+
+    def __init__(self, files: Mapping[str, Union[str, bytes]]):
+        self._contents = {
+            _acl_norm_path(path): content.encode("utf-8") if isinstance(content, str) else content
+            for path, content in files.items()
+        }
+        super().__init__({path: len(content) for path, content in self._contents.items()})
+
+    def _read_bytes(self, path: str) -> bytes:
+        return self._contents[path]
+
+
+class ZipFileSystem(_IndexedFileSystem):
+    """
+    The contents of a zip archive, read on demand.
+
+    Args:
+        archive: Open zip file, or the path of one to open
+    """
+
+    def __init__(self, archive: Union[zipfile.ZipFile, str, Path]):
+        self.archive = archive if isinstance(archive, zipfile.ZipFile) else zipfile.ZipFile(archive)
+        infos = self.archive.infolist()
+        super().__init__(
+            {info.filename: info.file_size for info in infos if not info.is_dir()},
+            {info.filename for info in infos if info.is_dir()},
+        )
+        self._names = {_acl_norm_path(info.filename): info for info in infos}
+
+    def _read_bytes(self, path: str) -> bytes:
+        return self.archive.read(self._names[path])
+
+
+class TarFileSystem(_IndexedFileSystem):
+    """
+    The contents of a tar archive, compressed or not, read on demand.
+
+    Nothing is extracted to disk. Regular files and directories are listed; links and
+    other special members are left out. Archives usually hold the datasite in a top
+    directory, which ``sub`` can root the filesystem at.
+
+    Args:
+        archive: Open tar file, the path of one to open, or a seekable binary file
+            object such as an ``io.BytesIO`` holding a downloaded ``.tar.gz``.
+            Compression is detected from the content.
+    """
+
+    def __init__(self, archive: Union[tarfile.TarFile, str, Path, BinaryIO]):
+        if isinstance(archive, tarfile.TarFile):
+            self.archive = archive
+        elif isinstance(archive, (str, Path)):
+            self.archive = tarfile.open(archive)
+        else:
+            self.archive = tarfile.open(fileobj=archive)
+        members = self.archive.getmembers()
+        super().__init__(
+            {member.name: member.size for member in members if member.isfile()},
+            {member.name for member in members if member.isdir()},
+        )
+        self._members = {_acl_norm_path(member.name): member for member in members}
+
+    def _read_bytes(self, path: str) -> bytes:
+        return self.archive.extractfile(self._members[path]).read()
+
+
+def find_entry(
+    filesystem: FileSystem, path: str, options: Optional[MatchOptions] = None
+) -> Optional[str]:
+    """
+    Locate the stored entry a requested path names, spelled as the filesystem has it.
+
+    With case-insensitive ``options`` each segment is looked up in its directory's
+    listing case-folded, so ``data/file.csv`` finds an archive's ``Data/File.CSV`` the
+    way a case-insensitive disk would. A name spelled exactly as requested is preferred;
+    among names differing only in case, the first in sorted order is taken.
+
+    Args:
+        filesystem: Filesystem to look in
+        path: Relative posix path as requested
+        options: Matching options; only ``case_insensitive`` is used
+
+    Returns:
+        str: The stored path of the file or directory, or None if there is none
+    """
+    path = _acl_norm_path(path)
+    if filesystem.is_file(path) or filesystem.is_dir(path):
+        return path
+    if options is None or not options.case_insensitive:
+        return None
+    stored = ""
+    for segment in path.split("/"):
+        listing = next(filesystem.walk(stored), None)
+        if listing is None:
+            return None
+        names = listing[1] + listing[2]
+        if segment not in names:
+            folded = _fold_case(segment)
+            segment = next((name for name in sorted(names) if _fold_case(name) == folded), None)
+            if segment is None:
+                return None
+        stored = posixpath.join(stored, segment)
+    return stored
@@ -0,0 +1,343 @@
+"""Compiled glob patterns and a shared cache so rules aren't re-parsed on every match."""
+
+import threading
+from collections import OrderedDict
+from typing import Any, Callable, Dict, Iterable, List, Optional, Set, Tuple
+
+from .errors import InvalidPatternError
+from .metrics import Metrics
+from .path_matching import (
+    MatchOptions,
+    _acl_norm_path,
+    _acl_norm_pattern,
+    _expand_braces,
+    _fold_case,
+    _fold_pattern,
+    _has_hidden,
+    _is_literal,
+    _match_doublestar,
+    _match_simple_glob,
+    _names_hidden_segments,
+    _normalize_separators,
+    _validate_pattern,
+    normalize_pattern,
+)
+from .rules import PermissionFile, Rule
+
+
+class PatternMatcher:
+    """
+    A glob pattern parsed once and reusable for any number of paths.
+
+    Compiling normalizes the pattern, applies case folding and expands brace
+    alternatives up front, so matching only walks the path. Instances are immutable
+    and safe to share between threads.
+
+    Args:
+        pattern: Glob pattern (without a leading ``!`` exclusion marker)
+        options: Matching options; defaults to case-sensitive doublestar matching
+
+    Raises:
+        InvalidPatternError: If the pattern syntax is invalid
+    """
+
+    def __init__(self, pattern: str, options: Optional[MatchOptions] = None):
+        try:
+            _validate_pattern(pattern)
+        except ValueError as e:
+            raise InvalidPatternError(pattern, str(e)) from None
+        self.pattern = pattern
+        self.options = options
+        self._case_insensitive = options is not None and options.case_insensitive
+        self._match_dotfiles = options is None or options.match_dotfiles
+
+        source = _fold_pattern(pattern) if self._case_insensitive else pattern
+        # (normalized alternative, is_literal) pairs, in expansion order
+        self._alternatives: Tuple[Tuple[str, bool], ...] = tuple(
+            (normalized, _is_literal(normalized))
+            for normalized in dict.fromkeys(
+                _acl_norm_pattern(expanded) for expanded in _expand_braces(source)
+            )
+        )
+
+    def match_path(self, path: str) -> bool:
+        """
+        Check whether a path matches the compiled pattern.
+
+        Args:
+            path: Path relative to the permission file's directory
+
+        Returns:
+            bool: True if path matches pattern
+        """
+        path = _normalize_separators(path, self.options)
+        if self._case_insensitive:
+            path = _fold_case(path)
+        path = _acl_norm_path(path)
+        check_hidden = not self._match_dotfiles and _has_hidden(path)
+        for alternative, is_literal in self._alternatives:
+            if is_literal:
+                if alternative == path:
+                    return True
+                continue
+            if "**" in alternative:
+                matched = _match_doublestar(alternative, path)
+            else:
+                matched = _match_simple_glob(alternative, path)
+            if matched and (not check_hidden or _names_hidden_segments(alternative, path)):
+                return True
+        return False
+
+    def __repr__(self) -> str:
+        return f"PatternMatcher({self.pattern!r})"
+
+
+class PatternCache:
+    """
+    Thread-safe LRU cache of compiled patterns keyed by pattern string and options.
+
+    Patterns are keyed by their normalize_pattern form, so spellings of one pattern
+    such as ``data//x`` and ``./data/x`` share a compiled matcher.
+    """
+
+    def __init__(self, max_size: int = 4096):
+        self.cache: "OrderedDict[Tuple[str, Optional[MatchOptions]], PatternMatcher]" = (
+            OrderedDict()
+        )
+        self.max_size = max_size
+        self._lock = threading.Lock()
+
+    def get(
+        self,
+        pattern: str,
+        options: Optional[MatchOptions] = None,
+        on_compile: Optional[Callable[[str], None]] = None,
+    ) -> PatternMatcher:
+        """Get the compiled matcher for a pattern, compiling it on first use."""
+        key = (normalize_pattern(pattern), options)
+        with self._lock:
+            matcher = self.cache.get(key)
+            if matcher is not None:
+                self.cache.move_to_end(key)
+                return matcher
+
+        # Compile outside the lock; a racing thread compiling the same pattern is harmless
+        matcher = PatternMatcher(pattern, options)
+        if on_compile is not None:
+            on_compile(pattern)
+        with self._lock:
+            if key not in self.cache and len(self.cache) >= self.max_size:
+                self.cache.popitem(last=False)
+            self.cache[key] = matcher
+            self.cache.move_to_end(key)
+        return matcher
+
+    def clear(self) -> None:
+        """Clear all compiled patterns."""
+        with self._lock:
+            self.cache.clear()
+
+
+# Global cache instance
+_pattern_cache = PatternCache()
+
+# How many permission files' rule indexes are kept before evicting the oldest
+_RULE_INDEX_CACHE_SIZE = 256
+
+
+class _RuleIndex:
+    """
+    The order a permission file's rules are tried in, with the rules grouped by the
+    literal directories their patterns start with.
+
+    A pattern like ``data/projectA/*.csv`` can only match paths under
+    ``data/projectA/``, so looking up a path's own directory prefixes gives every rule
+    that could match it without testing the others. Patterns starting with a wildcard
+    (``**/*.csv``, ``*/x``) form the group of the empty prefix and are candidates for
+    every path, as are rules with ``extensions``, whose stripped names can't be looked
+    up. Root-anchored patterns are grouped apart, since they are matched against the
+    datasite-relative path instead.
+
+    Only patterns, priorities and declaration order go into an index, so files that
+    differ in nothing else share one; rules are referred to by declaration index.
+    """
+
+    def __init__(self, perm_file: PermissionFile, options: Optional[MatchOptions] = None):
+        self.options = options
+        self.order: Tuple[int, ...] = tuple(index for index, _ in perm_file.ordered_rules())
+        self._relative: Dict[str, Set[int]] = {}
+        self._anchored: Dict[str, Set[int]] = {}
+        for index, rule in enumerate(perm_file.rules):
+            groups = self._anchored if rule.is_root_anchored else self._relative
+            prefix = "" if rule.extensions is not None else self._literal_prefix(rule)
+            groups.setdefault(prefix, set()).add(index)
+
+    def ordered_rules(self, perm_file: PermissionFile) -> List[Tuple[int, Rule]]:
+        """The file's rules with their declaration index, like PermissionFile.ordered_rules."""
+        return [(index, perm_file.rules[index]) for index in self.order]
+
+    def candidates(self, rel_path: str, relative_path: str) -> Set[int]:
+        """
+        Indices of the rules whose literal prefix a path starts with.
+
+        Args:
+            rel_path: Datasite-relative path, for root-anchored rules
+            relative_path: The path relative to the file's directory, for the others
+        """
+        found: Set[int] = set()
+        for groups, path in ((self._relative, relative_path), (self._anchored, rel_path)):
+            if not groups:
+                continue
+            path = _normalize_separators(path, self.options)
+            segments = _acl_norm_path(self._fold(path)).split("/")
+            prefix = ""
+            found.update(groups.get(prefix, ()))
+            for segment in segments:
+                prefix += segment + "/"
+                found.update(groups.get(prefix, ()))
+        return found
+
+    def _literal_prefix(self, rule: Rule) -> str:
+        """The leading directories of a rule's pattern without any wildcard, escape or brace."""
+        pattern = _acl_norm_pattern(self._fold(normalize_pattern(rule.match_pattern)))
+        segments = pattern.split("/")[:-1]
+        prefix = ""
+        for segment in segments:
+            if not _is_literal(segment) or "{" in segment:
+                break
+            prefix += segment + "/"
+        return prefix
+
+    def _fold(self, value: str) -> str:
+        """Fold a path or pattern's case if the options match case-insensitively."""
+        if self.options is not None and self.options.case_insensitive:
+            return _fold_case(value)
+        return value
+
+
+class _RuleIndexCache:
+    """Thread-safe LRU of rule indexes keyed by the patterns and priorities they index."""
+
+    def __init__(self, max_size: int = _RULE_INDEX_CACHE_SIZE):
+        self.cache: "OrderedDict[Tuple[Any, ...], _RuleIndex]" = OrderedDict()
+        self.max_size = max_size
+        self._lock = threading.Lock()
+
+    def get(self, perm_file: PermissionFile, options: Optional[MatchOptions] = None) -> _RuleIndex:
+        """Get the index of a file's rules, building it the first time they are seen."""
+        key = (
+            options,
+            tuple(
+                (rule.pattern, rule.priority, rule.extensions is None) for rule in perm_file.rules
+            ),
+        )
+        with self._lock:
+            index = self.cache.get(key)
+            if index is not None:
+                self.cache.move_to_end(key)
+                return index
+        index = _RuleIndex(perm_file, options)
+        with self._lock:
+            if key not in self.cache and len(self.cache) >= self.max_size:
+                self.cache.popitem(last=False)
+            self.cache[key] = index
+        return index
+
+    def clear(self) -> None:
+        """Clear all rule indexes."""
+        with self._lock:
+            self.cache.clear()
+
+
+_rule_index_cache = _RuleIndexCache()
+
+
+def compile_pattern(
+    pattern: str, options: Optional[MatchOptions] = None, metrics: Optional[Metrics] = None
+) -> PatternMatcher:
+    """
+    Get a compiled matcher for a pattern from the shared cache.
+
+    Args:
+        pattern: Glob pattern (without a leading ``!`` exclusion marker)
+        options: Matching options
+        metrics: Told when the pattern has to be compiled rather than found in the cache
+
+    Returns:
+        PatternMatcher: Cached compiled pattern
+
+    Raises:
+        InvalidPatternError: If the pattern syntax is invalid
+    """
+    on_compile = metrics.on_pattern_compile if metrics is not None else None
+    return _pattern_cache.get(pattern, options, on_compile)
+
+
+def match_any(
+    patterns: Iterable[str], path: str, options: Optional[MatchOptions] = None
+) -> Optional[int]:
+    """
+    Find the first of several patterns that matches a path.
+
+    Patterns are tried in the order given, so pass them in precedence order, and
+    trying stops at the first match. Every pattern is compiled first, so a malformed
+    one is reported even if an earlier pattern would have matched.
+
+    Args:
+        patterns: Glob patterns (without a leading ``!`` exclusion marker)
+        path: Path to match
+        options: Matching options
+
+    Returns:
+        int: Index of the first matching pattern, or None if none matches
+
+    Raises:
+        InvalidPatternError: If any pattern syntax is invalid
+    """
+    matchers = [compile_pattern(pattern, options) for pattern in patterns]
+    for index, matcher in enumerate(matchers):
+        if matcher.match_path(path):
+            return index
+    return None
+
+
+def warm_pattern_cache(
+    perm_files: Iterable[PermissionFile], options: Optional[MatchOptions] = None
+) -> int:
+    """
+    Compile every rule pattern of the given permission files ahead of time.
+
+    Patterns with a ``{user}`` placeholder are skipped, since they are only compiled
+    once the requesting user is filled in.
+
+    Args:
+        perm_files: Loaded permission files whose rules should be pre-compiled
+        options: Matching options the patterns will be used with
+
+    Returns:
+        int: Number of rule patterns compiled or already cached
+    """
+    count = 0
+    for perm_file in perm_files:
+        for rule in perm_file.rules:
+            if rule.has_user_placeholder:
+                continue
+            compile_pattern(rule.match_pattern, options)
+            count += 1
+    return count
+
+
+def _index_rules(perm_file: PermissionFile, options: Optional[MatchOptions] = None) -> _RuleIndex:
+    """Get the shared index of a permission file's rules for matching with ``options``."""
+    return _rule_index_cache.get(perm_file, options)
+
+
+def get_pattern_cache_stats() -> Dict[str, Any]:
+    """Get pattern cache statistics for testing and debugging."""
+    return {"size": len(_pattern_cache.cache), "max_size": _pattern_cache.max_size}
+
+
+def clear_pattern_cache() -> None:
+    """Clear the compiled pattern cache, and the rule indexes built on it, for testing."""
+    _pattern_cache.clear()
+    _rule_index_cache.clear()
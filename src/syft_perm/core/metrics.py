@@ -0,0 +1,46 @@
+"""Hooks for reporting resolution counters and timings to a monitoring system."""
+
+
+class Metrics:
+    """
+    Receives events from resolvers and permission stores.
+
+    Every hook does nothing here; subclass and override the ones you want to record,
+    e.g. to update Prometheus counters. Resolvers and stores without a Metrics don't
+    time anything, so leaving it unset costs nothing. Hooks are called on the thread
+    doing the work and should return quickly.
+    """
+
+    def on_resolve(self, duration: float, depth: int) -> None:
+        """
+        A single path was resolved.
+
+        Args:
+            duration: Seconds the resolution took
+            depth: Directories consulted, from the datasite root down to the path's own
+        """
+
+    def on_batch(self, duration: float, paths: int) -> None:
+        """
+        A batch of paths was resolved together by resolve_batch or resolve_pairs.
+
+        Args:
+            duration: Seconds the whole batch took
+            paths: Number of distinct paths resolved
+        """
+
+    def on_cache(self, hit: bool) -> None:
+        """
+        PermissionStore.resolve_cached looked up a level.
+
+        Args:
+            hit: Whether the level was already cached for the user
+        """
+
+    def on_pattern_compile(self, pattern: str) -> None:
+        """
+        A rule pattern wasn't in the shared pattern cache and was compiled.
+
+        Args:
+            pattern: The pattern compiled
+        """
@@ -1,6 +1,53 @@
 """Path matching and glob pattern utilities extracted from syft_perm implementation."""
 
+import os
+import re
+from dataclasses import dataclass
+from functools import lru_cache
 from pathlib import PurePath
+from typing import Dict, List, Optional, Tuple
+
+
+@dataclass(frozen=True)
+class MatchOptions:
+    """
+    Options controlling how glob patterns are matched against paths.
+
+    The defaults reproduce the case-sensitive doublestar behavior of old syftbox,
+    so callers only need to pass options when they want something different.
+
+    Attributes:
+        case_insensitive: Compare pattern and path case-folded. Intended for datasites
+            living on case-insensitive filesystems (macOS, Windows).
+        normalize_separators: Convert backslashes in paths to ``/`` before matching.
+            On by default only where ``\\`` is the OS separator (Windows); elsewhere a
+            backslash is a legal filename character and is left alone. Patterns are
+            never converted, since ``\\`` escapes glob metacharacters there.
+        match_dotfiles: Let wildcards match names starting with ``.``. On by default,
+            as in doublestar: ``*`` matches ``.env`` and ``**`` descends into
+            ``.config``. When off, a path segment starting with ``.`` is only matched
+            by a pattern segment that starts with a literal ``.``, so ``**/*`` no
+            longer matches ``dir/.env`` while ``**/.env`` and ``.*`` still do.
+    """
+
+    case_insensitive: bool = False
+    normalize_separators: bool = os.sep == "\\"
+    match_dotfiles: bool = True
+
+
+_DEFAULT_OPTIONS = MatchOptions()
+
+# Most wildcards a pattern loaded from a permission file may contain
+DEFAULT_MAX_WILDCARDS = 10
+
+_GLOB_METACHARACTERS = frozenset("*?[\\")
+
+
+def _normalize_separators(path: str, options: Optional[MatchOptions] = None) -> str:
+    """Convert backslash separators in a path to ``/`` when the options ask for it."""
+    if (options or _DEFAULT_OPTIONS).normalize_separators:
+        return path.replace("\\", "/")
+    return path
 
 
 def _acl_norm_path(path: str) -> str:
@@ -27,6 +74,34 @@ def _acl_norm_path(path: str) -> str:
     return normalized
 
 
+def _acl_norm_pattern(pattern: str) -> str:
+    """
+    Normalize a glob pattern like _acl_norm_path, only ever splitting it at ``/``.
+
+    A backslash in a pattern escapes the next character, so unlike in a path it is never
+    read as a Windows separator.
+    """
+    return "/".join(segment for segment in pattern.split("/") if segment not in ("", "."))
+
+
+def _is_literal(pattern: str) -> bool:
+    """Whether a brace-free pattern only matches the path spelled exactly like it."""
+    return _GLOB_METACHARACTERS.isdisjoint(pattern)
+
+
+def _names_one_path(pattern: str) -> bool:
+    """Whether a pattern has no unescaped wildcard, class or brace, so it names one path."""
+    escaped = False
+    for char in pattern:
+        if escaped:
+            escaped = False
+        elif char == "\\":
+            escaped = True
+        elif char in "*?[{":
+            return False
+    return True
+
+
 def _doublestar_match(pattern: str, path: str) -> bool:
     """
     Match a path against a glob pattern using doublestar algorithm.
@@ -40,11 +115,11 @@ def _doublestar_match(pattern: str, path: str) -> bool:
         bool: True if path matches pattern
     """
     # Normalize inputs
-    pattern = _acl_norm_path(pattern)
+    pattern = _acl_norm_pattern(pattern)
     path = _acl_norm_path(path)
 
     # Quick exact match
-    if pattern == path:
+    if pattern == path and _is_literal(pattern):
         return True
 
     # Handle ** patterns
@@ -63,6 +138,25 @@ def _match_doublestar(pattern: str, path: str) -> bool:
     old syftbox.
     Key behavior: ** matches zero or more path segments (directories).
     """
+    return _match_doublestar_memo(pattern, path, {})
+
+
+def _match_doublestar_memo(pattern: str, path: str, seen: Dict[Tuple[str, str], bool]) -> bool:
+    """
+    Match like _match_doublestar, remembering results for the (pattern, path) pairs
+    already tried within one top-level match.
+
+    With several ``**`` the same suffix is retried against the same remainder from many
+    split points; without memoization that is exponential in the number of ``**``.
+    """
+    key = (pattern, path)
+    if key not in seen:
+        seen[key] = _match_doublestar_once(pattern, path, seen)
+    return seen[key]
+
+
+def _match_doublestar_once(pattern: str, path: str, seen: Dict[Tuple[str, str], bool]) -> bool:
+    """One step of _match_doublestar; recursive calls go through the memo."""
     # Handle the simplest cases first
     if pattern == "**":
         return True
@@ -70,6 +164,8 @@ def _match_doublestar(pattern: str, path: str) -> bool:
         return not path
     if not path:
         return pattern == "**" or pattern == ""
+    if not _literal_runs_possible(pattern, path):
+        return False
 
     # Find the first ** in the pattern
     double_star_idx = pattern.find("**")
@@ -86,24 +182,28 @@ def _match_doublestar(pattern: str, path: str) -> bool:
         # For doublestar patterns, prefix should match at the beginning of the
         # path or we need to try matching the entire pattern at later positions
         # (for leading **)
-        if path == prefix:
+        if path == prefix and _is_literal(prefix):
             # Exact match
             remaining = ""
-        elif path.startswith(prefix + "/"):
+        elif path.startswith(prefix + "/") and _is_literal(prefix):
             # Path starts with prefix followed by separator
             remaining = path[len(prefix) + 1 :]
         elif _match_simple_glob(prefix, path):
             # Glob pattern matches entire path
             remaining = ""
         else:
-            # Prefix doesn't match at start, for leading ** try at later positions
-            if pattern.startswith("**/"):
-                path_segments = path.split("/")
-                for i in range(1, len(path_segments) + 1):
-                    remaining_path = "/".join(path_segments[i:])
-                    if _match_doublestar(pattern, remaining_path):
-                        return True
-            return False
+            # Glob prefix like "data*" may still match the leading segments of the path
+            glob_remaining = _glob_prefix_remainder(prefix, path)
+            if glob_remaining is None:
+                # Prefix doesn't match at start, for leading ** try at later positions
+                if pattern.startswith("**/"):
+                    path_segments = path.split("/")
+                    for i in range(1, len(path_segments) + 1):
+                        remaining_path = "/".join(path_segments[i:])
+                        if _match_doublestar_memo(pattern, remaining_path, seen):
+                            return True
+                return False
+            remaining = glob_remaining
     else:
         # No prefix, ** can match from the beginning
         remaining = path
@@ -124,20 +224,80 @@ def _match_doublestar(pattern: str, path: str) -> bool:
         # No remaining path, but we have a suffix to match
         return suffix == ""
 
-    # Split remaining path into segments and try matching suffix at each position
-    remaining_segments = remaining.split("/")
+    # Try matching suffix starting from each segment position, the whole remainder first
+    start = 0
+    while True:
+        if _match_doublestar_memo(suffix, remaining[start:], seen):
+            return True
+        start = remaining.find("/", start) + 1
+        if not start:
+            return False
 
-    # Try exact match first
-    if _match_doublestar(suffix, remaining):
+
+# Patterns whose literal runs can't be read off by splitting on * and ?
+_UNSPLITTABLE_CHARACTERS = frozenset("\\[{")
+_RUN_SEPARATOR = re.compile(r"[*?]")
+
+
+@lru_cache(maxsize=1024)
+def _literal_runs(pattern: str) -> Optional[Tuple[str, Tuple[str, ...], str]]:
+    """The (first, middle, last) literal runs of a ``**`` pattern, or None if not split."""
+    if not _UNSPLITTABLE_CHARACTERS.isdisjoint(pattern):
+        return None
+    pieces = pattern.split("**")
+    if len(pieces) < 2:
+        return None
+
+    # Slashes next to ** may be absorbed when it matches zero segments
+    pieces = [pieces[0].rstrip("/"), *(p.strip("/") for p in pieces[1:-1]), pieces[-1].lstrip("/")]
+    runs = [run for piece in pieces for run in _RUN_SEPARATOR.split(piece) if run]
+    first = runs.pop(0) if runs and pattern.startswith(runs[0]) else ""
+    last = runs.pop() if runs and pattern.endswith(runs[-1]) else ""
+    return first, tuple(runs), last
+
+
+def _literal_runs_possible(pattern: str, path: str) -> bool:
+    """
+    Quickly rule out paths that can't match a ``**`` pattern.
+
+    Splitting the pattern on ``**``, and each piece on ``*`` and ``?``, leaves literal
+    runs that any matching path must contain in order: the first at the very start
+    and the last at the very end, unless the pattern begins or ends with a wildcard.
+    This is only a necessary condition, so a True result still needs the full match.
+    Patterns with escapes, character classes or braces are not checked.
+    """
+    runs = _literal_runs(pattern)
+    if runs is None:
         return True
+    first, middle, last = runs
+    if not path.startswith(first):
+        return False
+    position = len(first)
+    for run in middle:
+        position = path.find(run, position)
+        if position == -1:
+            return False
+        position += len(run)
+    return path.endswith(last) and len(path) - len(last) >= position
 
-    # Try matching suffix starting from each segment position
-    for i in range(len(remaining_segments)):
-        candidate = "/".join(remaining_segments[i:])
-        if _match_doublestar(suffix, candidate):
-            return True
 
-    return False
+def _glob_prefix_remainder(prefix: str, path: str) -> Optional[str]:
+    """
+    Match a ``**``-free glob prefix against the leading segments of a path.
+
+    Since ``*`` never crosses ``/``, a prefix of n segments can only match the first n
+    segments of the path.
+
+    Returns:
+        The rest of the path after the matched segments, or None if they don't match
+    """
+    prefix_count = prefix.count("/") + 1
+    path_segments = path.split("/")
+    if len(path_segments) <= prefix_count:
+        return None
+    if not _match_simple_glob(prefix, "/".join(path_segments[:prefix_count])):
+        return None
+    return "/".join(path_segments[prefix_count:])
 
 
 def _match_suffix_recursive(suffix: str, path: str) -> bool:
@@ -163,7 +323,13 @@ def _match_suffix_recursive(suffix: str, path: str) -> bool:
 
 
 def _match_simple_glob(pattern: str, path: str) -> bool:
-    """Match simple glob patterns with *, ?, [] but no **. Case-sensitive matching."""
+    """
+    Match simple glob patterns with *, ?, [] but no **. Case-sensitive matching.
+
+    A backslash escapes the following character so it is matched literally. As in
+    doublestar, ``?`` and character classes match exactly one character, never ``/``.
+    Paths are compared by code point, so ``?`` also matches one multibyte character.
+    """
     if not pattern and not path:
         return True
     if not pattern:
@@ -171,8 +337,8 @@ def _match_simple_glob(pattern: str, path: str) -> bool:
     if not path:
         return pattern == "*" or all(c == "*" for c in pattern)
 
-    # Handle exact match (case-sensitive)
-    if pattern == path:
+    # Handle exact match (case-sensitive); a class or escape never matches itself
+    if pattern == path and _is_literal(pattern):
         return True
 
     # Convert glob pattern to regex-like matching (case-sensitive)
@@ -190,20 +356,29 @@ def _match_simple_glob(pattern: str, path: str) -> bool:
                 pattern_idx += 1
                 continue
             elif pattern[pattern_idx] == "?":
-                # ? matches any single char (case-sensitive)
-                pattern_idx += 1
-                path_idx += 1
-                continue
+                # ? matches any single char except the separator (case-sensitive)
+                if path[path_idx] != "/":
+                    pattern_idx += 1
+                    path_idx += 1
+                    continue
             elif pattern[pattern_idx] == "[":
-                # Character class matching like [0-9], [abc], etc.
-                if _match_char_class(pattern, pattern_idx, path[path_idx]):
-                    # Find end of character class
-                    bracket_end = pattern.find("]", pattern_idx + 1)
-                    if bracket_end != -1:
-                        pattern_idx = bracket_end + 1
-                        path_idx += 1
-                        continue
+                # Character class matching like [0-9], [abc], etc.; never matches /
+                bracket_end = _class_end(pattern, pattern_idx)
+                if (
+                    bracket_end != -1
+                    and path[path_idx] != "/"
+                    and _match_char_class(pattern, pattern_idx, path[path_idx])
+                ):
+                    pattern_idx = bracket_end + 1
+                    path_idx += 1
+                    continue
                 # If no matching bracket or no match, fall through to backtrack
+            elif pattern[pattern_idx] == "\\" and pattern_idx + 1 < len(pattern):
+                # Escaped metacharacter matches itself literally
+                if pattern[pattern_idx + 1] == path[path_idx]:
+                    pattern_idx += 2
+                    path_idx += 1
+                    continue
             elif pattern[pattern_idx] == path[path_idx]:
                 # Exact char match (case-sensitive)
                 pattern_idx += 1
@@ -228,46 +403,69 @@ def _match_simple_glob(pattern: str, path: str) -> bool:
     return pattern_idx == len(pattern)
 
 
+def _class_end(pattern: str, start_idx: int) -> int:
+    """
+    Find the ``]`` closing the character class opened at start_idx.
+
+    As in doublestar, a ``]`` right after ``[`` (or ``[!``/``[^``) is a member rather
+    than the end, and a backslash escapes the next character, so ``[]a]`` and
+    ``[\\]a]`` both hold ``]`` and ``a``.
+
+    Returns:
+        int: Index of the closing bracket, or -1 if the class is unterminated
+    """
+    i = start_idx + 1
+    if i < len(pattern) and pattern[i] in "!^":
+        i += 1
+    if i < len(pattern) and pattern[i] == "]":
+        i += 1
+    while i < len(pattern):
+        c = pattern[i]
+        if c == "\\":
+            i += 2
+            continue
+        if c == "]":
+            return i
+        i += 1
+    return -1
+
+
 def _match_char_class(pattern: str, start_idx: int, char: str) -> bool:
-    """Match a character against a character class like [0-9], [abc], [!xyz]."""
+    """
+    Match a character against a character class like [0-9], [abc], [!xyz].
+
+    Ranges compare code points and are inclusive. A leading ``!`` or ``^`` negates the
+    class, ``-`` first or last is a literal member, and a backslash makes the next
+    character a literal member (or range bound), including ``]``, ``-`` and ``\\``.
+    """
     if start_idx >= len(pattern) or pattern[start_idx] != "[":
         return False
-
-    # Find the end of the character class
-    end_idx = pattern.find("]", start_idx + 1)
+    end_idx = _class_end(pattern, start_idx)
     if end_idx == -1:
         return False
 
-    char_class = pattern[start_idx + 1 : end_idx]
-    if not char_class:
-        return False
+    i = start_idx + 1
+    negate = pattern[i] in "!^"
+    if negate:
+        i += 1
 
-    # Handle negation [!...] or [^...]
-    negate = False
-    if char_class[0] in "!^":
-        negate = True
-        char_class = char_class[1:]
+    def member(idx: int) -> Tuple[str, int]:
+        """The literal character at idx, unescaped, and the index after it."""
+        if pattern[idx] == "\\" and idx + 1 < end_idx:
+            return pattern[idx + 1], idx + 2
+        return pattern[idx], idx + 1
 
-    # Check for range patterns like 0-9, a-z
-    i = 0
     matched = False
-    while i < len(char_class):
-        if i + 2 < len(char_class) and char_class[i + 1] == "-":
+    while i < end_idx and not matched:
+        low, i = member(i)
+        if i + 1 < end_idx and pattern[i] == "-":
             # Range pattern like 0-9
-            start_char = char_class[i]
-            end_char = char_class[i + 2]
-            if start_char <= char <= end_char:
-                matched = True
-                break
-            i += 3
+            high, i = member(i + 1)
+            matched = low <= char <= high
         else:
-            # Single character
-            if char_class[i] == char:
-                matched = True
-                break
-            i += 1
+            matched = char == low
 
-    return matched if not negate else not matched
+    return matched != negate
 
 
 def _glob_match(pattern: str, path: str) -> bool:
@@ -282,7 +480,486 @@ def _glob_match(pattern: str, path: str) -> bool:
     Returns:
         bool: True if path matches pattern
     """
-    return _doublestar_match(pattern, path)
+    return match(pattern, path)
+
+
+def _find_closing_brace(pattern: str, open_idx: int) -> int:
+    """
+    Find the index of the ``}`` closing the ``{`` at open_idx.
+
+    Nested braces, backslash escapes and ``[...]`` character classes are skipped.
+
+    Returns:
+        int: Index of the closing brace, or -1 if the brace is unbalanced
+    """
+    depth = 0
+    i = open_idx
+    while i < len(pattern):
+        c = pattern[i]
+        if c == "\\":
+            i += 2
+            continue
+        if c == "[":
+            class_end = _class_end(pattern, i)
+            if class_end != -1:
+                i = class_end + 1
+                continue
+        if c == "{":
+            depth += 1
+        elif c == "}":
+            depth -= 1
+            if depth == 0:
+                return i
+        i += 1
+    return -1
+
+
+def _split_alternatives(body: str) -> List[str]:
+    """Split the inside of a brace group on top-level, unescaped commas outside classes."""
+    alternatives = []
+    depth = 0
+    start = 0
+    i = 0
+    while i < len(body):
+        c = body[i]
+        if c == "\\":
+            i += 2
+            continue
+        if c == "[":
+            class_end = _class_end(body, i)
+            if class_end != -1:
+                i = class_end + 1
+                continue
+        if c == "{":
+            depth += 1
+        elif c == "}":
+            depth -= 1
+        elif c == "," and depth == 0:
+            alternatives.append(body[start:i])
+            start = i + 1
+        i += 1
+    alternatives.append(body[start:])
+    return alternatives
+
+
+@lru_cache(maxsize=1024)
+def _expand_braces(pattern: str) -> Tuple[str, ...]:
+    r"""
+    Expand ``{a,b,c}`` alternatives in a glob pattern into plain patterns.
+
+    Nested groups (``{a,b{1,2}}``) and empty alternatives (``file{,.bak}``) are
+    supported. Unbalanced braces are kept literally and ``\{`` / ``\}`` escape a
+    brace so it is never treated as a group.
+
+    Args:
+        pattern: Glob pattern possibly containing brace groups
+
+    Returns:
+        tuple: Expanded patterns in declaration order, without duplicates
+    """
+    i = 0
+    while i < len(pattern):
+        c = pattern[i]
+        if c == "\\":
+            i += 2
+            continue
+        if c == "[":
+            class_end = _class_end(pattern, i)
+            if class_end != -1:
+                i = class_end + 1
+                continue
+        if c == "{":
+            close_idx = _find_closing_brace(pattern, i)
+            if close_idx != -1:
+                prefix = pattern[:i]
+                suffix = pattern[close_idx + 1 :]
+                expanded: List[str] = []
+                for alternative in _split_alternatives(pattern[i + 1 : close_idx]):
+                    for candidate in _expand_braces(prefix + alternative + suffix):
+                        if candidate not in expanded:
+                            expanded.append(candidate)
+                return tuple(expanded)
+        i += 1
+    return (pattern,)
+
+
+def _fold_case(value: str) -> str:
+    """
+    Case-fold a path one segment at a time.
+
+    Uses plain ``str.lower`` so no locale-specific rules (e.g. Turkish dotless i) are
+    applied. Patterns are folded with ``_fold_pattern``, which treats classes apart.
+    """
+    return "/".join(segment.lower() for segment in value.split("/"))
+
+
+def _fold_pattern(pattern: str) -> str:
+    """
+    Case-fold a glob pattern to match paths folded with ``_fold_case``.
+
+    Literal characters are lower-cased. Character classes keep their members and gain
+    the lower-case form of each, so ``[A-Z]`` still matches every letter and ``[!A]``
+    rejects ``a`` as well as ``A``.
+    """
+    folded = []
+    i = 0
+    while i < len(pattern):
+        c = pattern[i]
+        if c == "\\" and i + 1 < len(pattern):
+            folded.append(c + pattern[i + 1].lower())
+            i += 2
+            continue
+        end = _class_end(pattern, i) if c == "[" else -1
+        if end == -1:
+            folded.append(c.lower())
+            i += 1
+            continue
+        folded.append(_fold_class(pattern, i, end))
+        i = end + 1
+    return "".join(folded)
+
+
+def _fold_class(pattern: str, start_idx: int, end_idx: int) -> str:
+    """The character class from start_idx to end_idx with lower-case members added."""
+    i = start_idx + 1
+    if pattern[i] in "!^":
+        i += 1
+
+    def member(idx: int) -> Tuple[str, int]:
+        """The literal character at idx, unescaped, and the index after it."""
+        if pattern[idx] == "\\" and idx + 1 < end_idx:
+            return pattern[idx + 1], idx + 2
+        return pattern[idx], idx + 1
+
+    members = set()
+    j = i
+    while j < end_idx:
+        low, j = member(j)
+        high = low
+        if j + 1 < end_idx and pattern[j] == "-":
+            high, j = member(j + 1)
+        members.update(chr(code) for code in range(ord(low), ord(high) + 1))
+    added = sorted({char.lower() for char in members if len(char.lower()) == 1} - members)
+    if not added:
+        return pattern[start_idx : end_idx + 1]
+    body = pattern[i:end_idx]
+    # The added members go first, so a leading "]" or "-" has to be escaped
+    if body.startswith(("]", "-")):
+        body = "\\" + body
+    return pattern[start_idx:i] + "".join("\\" + char for char in added) + body + "]"
+
+
+def match(pattern: str, path: str, options: Optional[MatchOptions] = None) -> bool:
+    """
+    Match a path against a glob pattern, honoring optional match options.
+
+    ``*`` and ``?`` stay within one path segment, so ``data/*`` matches the direct
+    children of ``data`` only. ``**`` spans any number of segments: ``data/**`` matches
+    every descendant of ``data`` (but not ``data`` itself).
+
+    Args:
+        pattern: Glob pattern (supports *, ?, ** for recursive and {a,b} alternatives)
+        path: Path to match against pattern
+        options: Matching options; defaults to case-sensitive doublestar matching
+
+    Returns:
+        bool: True if path matches pattern
+    """
+    path = _normalize_separators(path, options)
+    if options is not None and options.case_insensitive:
+        pattern = _fold_pattern(pattern)
+        path = _fold_case(path)
+    check_hidden = options is not None and not options.match_dotfiles and _has_hidden(path)
+    return any(
+        _doublestar_match(expanded, path)
+        and (not check_hidden or _names_hidden_segments(expanded, path))
+        for expanded in _expand_braces(pattern)
+    )
+
+
+def _has_hidden(path: str) -> bool:
+    """Whether any segment of a path starts with a dot."""
+    return any(segment.startswith(".") for segment in _acl_norm_path(path).split("/"))
+
+
+def _names_hidden_segments(pattern: str, path: str) -> bool:
+    """
+    Check that a pattern can match a path without a wildcard covering a leading dot.
+
+    Every path segment starting with ``.`` must line up with a pattern segment that
+    starts with a literal (possibly escaped) ``.``; ``**`` never spans such a segment.
+    Only meaningful for a pattern already known to match the path.
+    """
+    pattern_segments = _acl_norm_pattern(pattern).split("/")
+    path_segments = _acl_norm_path(path).split("/")
+    seen: Dict[Tuple[int, int], bool] = {}
+
+    def aligned(i: int, j: int) -> bool:
+        key = (i, j)
+        if key in seen:
+            return seen[key]
+        if i == len(pattern_segments):
+            result = j == len(path_segments)
+        elif pattern_segments[i] == "**":
+            result = aligned(i + 1, j) or (
+                j < len(path_segments)
+                and not path_segments[j].startswith(".")
+                and aligned(i, j + 1)
+            )
+        elif j == len(path_segments):
+            result = False
+        else:
+            segment = pattern_segments[i]
+            explicit = segment.startswith(".") or segment.startswith("\\.")
+            result = (
+                (explicit or not path_segments[j].startswith("."))
+                and _match_simple_glob(segment, path_segments[j])
+                and aligned(i + 1, j + 1)
+            )
+        seen[key] = result
+        return result
+
+    return aligned(0, 0)
+
+
+def is_recursive(pattern: str) -> bool:
+    """
+    Check whether a pattern can match paths at any depth.
+
+    Args:
+        pattern: Glob pattern, optionally with a leading ``!``
+
+    Returns:
+        bool: True if some alternative of the pattern has a ``**`` segment, False if it
+            only matches paths with a fixed number of segments (like ``data/*``)
+    """
+    _, pattern = _split_negation(pattern)
+    return any("**" in alternative for alternative in _expand_braces(pattern))
+
+
+def _could_match_below(
+    pattern: str, directory: str, options: Optional[MatchOptions] = None
+) -> bool:
+    """
+    Conservatively check whether a pattern might match some path inside a directory.
+
+    Used to skip directories whose contents no rule can reach. It may report True for
+    a pattern that never actually matches there, but never False for one that does.
+
+    Args:
+        pattern: Glob pattern without a ``!`` marker, relative to its permission file
+        directory: Directory relative to the same permission file ("" for its own)
+        options: Matching options
+    """
+    if options is not None and options.case_insensitive:
+        pattern = _fold_pattern(pattern)
+        directory = _fold_case(directory)
+    dir_segments = directory.split("/") if directory else []
+    for alternative in _expand_braces(pattern):
+        segments = _acl_norm_pattern(alternative).split("/")
+        for i, dir_segment in enumerate(dir_segments):
+            if i >= len(segments):
+                break
+            if "**" in segments[i]:
+                return True
+            if not _match_simple_glob(segments[i], dir_segment):
+                break
+        else:
+            if len(segments) > len(dir_segments):
+                return True
+    return False
+
+
+def _unclosed_brace(pattern: str) -> int:
+    """Index of the first top-level ``{`` with no closing ``}``, or -1 if there is none."""
+    i = 0
+    while i < len(pattern):
+        c = pattern[i]
+        if c == "\\":
+            i += 2
+            continue
+        if c == "[":
+            class_end = _class_end(pattern, i)
+            if class_end != -1:
+                i = class_end + 1
+                continue
+        if c == "{":
+            close_idx = _find_closing_brace(pattern, i)
+            if close_idx == -1:
+                return i
+            i = close_idx
+        i += 1
+    return -1
+
+
+def _complete_segment(partial: str) -> str:
+    """
+    A glob matching every segment that some completion of a partial segment matches.
+
+    Any completion can end in ``*``. A trailing lone backslash or an unclosed ``[``
+    stands for exactly one character still to be written, so it becomes ``?`` instead.
+    """
+    i = 0
+    while i < len(partial):
+        c = partial[i]
+        if c == "\\":
+            if i + 1 == len(partial):
+                return partial[:i] + "?*"
+            i += 2
+            continue
+        if c == "[":
+            class_end = _class_end(partial, i)
+            if class_end == -1:
+                return partial[:i] + "?*"
+            i = class_end + 1
+            continue
+        i += 1
+    return partial + "*"
+
+
+def match_prefix(pattern_prefix: str, path: str, options: Optional[MatchOptions] = None) -> bool:
+    """
+    Check whether a path could still match once a partly typed pattern is finished.
+
+    Meant for live previews in editors: ``data/re`` could-matches
+    ``data/reports/x.csv``, since ``data/re*/**`` matches it. Segments before the last
+    ``/`` of the prefix are complete and match path segments as in ``match`` (a ``**``
+    segment spans any number of them); the text after it may still grow, so it only has
+    to match the start of the next path segment, and the completion may go on to match
+    anything deeper. A prefix ending in ``/`` needs the path to go at least one segment
+    further, and one ending in ``**`` reaches everything below where it stands.
+
+    An unfinished brace group could still gain any alternative, so the prefix is
+    treated as ending just before it; an unfinished character class or escape stands
+    for one more character. Reaching a path is never a guarantee that the finished
+    pattern will match it.
+
+    Args:
+        pattern_prefix: Start of a glob pattern. A leading ``!`` or ``/`` is ignored, so
+            pass a path relative to where the finished pattern would be matched.
+        path: Path to check, relative to the same directory
+        options: Matching options; case folding, separator normalization and dotfile
+            matching apply as in ``match``
+
+    Returns:
+        bool: True if some completion of the prefix could match the path
+    """
+    _, pattern_prefix = _split_negation(pattern_prefix)
+    if pattern_prefix.startswith("/"):
+        pattern_prefix = pattern_prefix[1:]
+    path = _normalize_separators(path, options)
+    if options is not None and options.case_insensitive:
+        pattern_prefix = _fold_pattern(pattern_prefix)
+        path = _fold_case(path)
+    brace_idx = _unclosed_brace(pattern_prefix)
+    if brace_idx != -1:
+        pattern_prefix = pattern_prefix[:brace_idx]
+    path = _acl_norm_path(path)
+    if not path:
+        return False
+    path_segments = path.split("/")
+    dotfiles = options is None or options.match_dotfiles
+    return any(
+        _prefix_reaches(alternative, path_segments, dotfiles)
+        for alternative in _expand_braces(pattern_prefix)
+    )
+
+
+def _prefix_reaches(pattern_prefix: str, path_segments: List[str], dotfiles: bool) -> bool:
+    """``match_prefix`` for one brace-free prefix and a split, normalized path."""
+    segments = pattern_prefix.split("/")
+    if segments[-1] == "**":
+        # A trailing ``**`` already spans segments, including literal dotfile names after it
+        segments.append("")
+    complete, partial = segments[:-1], _complete_segment(segments[-1])
+    seen: Dict[Tuple[int, int], bool] = {}
+
+    def segment_matches(segment: str, path_segment: str) -> bool:
+        if not dotfiles and path_segment.startswith("."):
+            if not (segment.startswith(".") or segment.startswith("\\.")):
+                return False
+        return _match_simple_glob(segment, path_segment)
+
+    def reaches(i: int, j: int) -> bool:
+        key = (i, j)
+        if key in seen:
+            return seen[key]
+        if i == len(complete):
+            # The partial segment may still become a literal dotfile name
+            result = j < len(path_segments) and (
+                segments[-1] == "" or segment_matches(partial, path_segments[j])
+            )
+        elif complete[i] == "**":
+            result = reaches(i + 1, j) or (
+                j < len(path_segments)
+                and (dotfiles or not path_segments[j].startswith("."))
+                and reaches(i, j + 1)
+            )
+        elif complete[i] in ("", "."):
+            result = reaches(i + 1, j)
+        else:
+            result = (
+                j < len(path_segments)
+                and segment_matches(complete[i], path_segments[j])
+                and reaches(i + 1, j + 1)
+            )
+        seen[key] = result
+        return result
+
+    return reaches(0, 0)
+
+
+def match_fold(pattern: str, path: str) -> bool:
+    """
+    Match a path against a glob pattern ignoring case.
+
+    Shorthand for ``match(pattern, path, MatchOptions(case_insensitive=True))``.
+    """
+    return match(pattern, path, MatchOptions(case_insensitive=True))
+
+
+def _validate_pattern(pattern: str, max_wildcards: Optional[int] = None) -> None:
+    """
+    Check a glob pattern for syntax the matcher can't interpret.
+
+    Args:
+        pattern: Glob pattern to check
+        max_wildcards: Reject patterns with more wildcards than this; None for no limit
+
+    Raises:
+        ValueError: If the pattern is empty, has an unterminated character class, ends
+            in a dangling escape, has a ``..`` segment or has too many wildcards
+    """
+    if not pattern:
+        raise ValueError("pattern must not be empty")
+    for expanded in _expand_braces(pattern):
+        if ".." in expanded.split("/"):
+            raise ValueError("'..' segments could reach outside the rule's directory")
+    wildcards = 0
+    i = 0
+    while i < len(pattern):
+        char = pattern[i]
+        if char == "\\":
+            if i + 1 == len(pattern):
+                raise ValueError("ends with a dangling escape")
+            i += 2
+            continue
+        if char == "[":
+            end = _class_end(pattern, i)
+            if end == -1:
+                raise ValueError(f"unterminated character class at offset {i}")
+            i = end + 1
+            continue
+        if char == "?":
+            wildcards += 1
+        elif char == "*":
+            # A run of stars such as ** is a single wildcard
+            wildcards += 1
+            while i + 1 < len(pattern) and pattern[i + 1] == "*":
+                i += 1
+        i += 1
+    if max_wildcards is not None and wildcards > max_wildcards:
+        raise ValueError(f"has {wildcards} wildcards, more than the limit of {max_wildcards}")
 
 
 def _calculate_glob_specificity(pattern: str) -> int:
@@ -319,6 +996,200 @@ def _calculate_glob_specificity(pattern: str) -> int:
     return score
 
 
+# Characters with a special meaning anywhere in a glob pattern
+_ESCAPED_CHARACTERS = frozenset("*?[]{}\\")
+
+
+def escape_pattern(literal: str) -> str:
+    r"""
+    Escape a literal path so it can be used as a pattern matching only that path.
+
+    Glob metacharacters (``*?[]{}`` and ``\``) are backslash-escaped, and so is a
+    leading ``!`` so the rule isn't read as an exclusion. ``/`` is kept as the
+    separator.
+
+    Args:
+        literal: Path to match exactly, relative to the permission file's directory
+
+    Returns:
+        str: Pattern matching ``literal`` and nothing else
+    """
+    escaped = "".join("\\" + c if c in _ESCAPED_CHARACTERS else c for c in literal)
+    if escaped.startswith("!"):
+        escaped = "\\" + escaped
+    return escaped
+
+
+def normalize_pattern(pattern: str) -> str:
+    """
+    Canonicalize how a pattern's separators are written, keeping what it matches.
+
+    Runs of ``/`` and ``.`` segments are dropped, so ``data//x``, ``./data/x`` and
+    ``data/./x`` all become ``data/x``. Wildcards, any ``!`` exclusion marker, a leading
+    ``/`` root anchor and a trailing ``/`` (which limits a rule to directories) are kept,
+    each collapsed to a single ``/``; in particular ``**`` is never turned into ``*``.
+    Use it to compare patterns or key caches on them.
+
+    Args:
+        pattern: Rule pattern, possibly prefixed with ``!``
+
+    Returns:
+        str: The canonical form of the pattern
+    """
+    negated, body = _split_negation(pattern)
+    normalized = "/".join(segment for segment in body.split("/") if segment not in ("", "."))
+    if body.endswith("/") and normalized:
+        normalized += "/"
+    if body.startswith("/"):
+        normalized = "/" + normalized
+    return "!" + normalized if negated else normalized
+
+
+def explain_pattern(pattern: str) -> str:
+    """
+    Describe in words what a rule pattern matches, to show next to it in a UI.
+
+    The description tells ``*``, which stays within one folder, apart from ``**``,
+    which reaches any depth, and says what the last segment requires of the name.
+    Segments it has no words for, like character classes or brace alternatives, are
+    quoted as written. Relative patterns are described from the folder holding the
+    permission file ("this folder").
+
+    Args:
+        pattern: Rule pattern, optionally with a leading ``!``
+
+    Returns:
+        str: e.g. "any .csv file directly inside data/ (not in subfolders)" for
+            ``data/*.csv``, or "any file at any depth under src/" for ``src/**``
+    """
+    negated, body = _split_negation(normalize_pattern(pattern))
+    anchored = body.startswith("/")
+    kind = "folder" if body.endswith("/") else "file"
+    segments = [segment for segment in body.split("/") if segment]
+    name = segments.pop() if segments else "**"
+    # Directories between ``**`` segments; the name is matched below the last group
+    groups: List[List[str]] = [[]]
+    for segment in segments:
+        if segment != "**":
+            groups[-1].append(segment)
+        elif groups[-1] or len(groups) == 1:
+            groups.append([])
+    if name == "**":
+        what = f"any {kind}"
+        if groups[-1] or len(groups) == 1:
+            groups.append([])
+    else:
+        what = _explain_name(name, kind)
+
+    # A name with wildcards could be read as reaching into subfolders too
+    direct = "" if _names_one_path(name) else " (not in subfolders)"
+    places = []
+    if groups[-1]:
+        folder = _explain_folders(groups[-1], len(groups) > 1, anchored)
+        places.append(f"directly inside {folder}{direct}")
+    elif len(groups) == 1:
+        base = "the datasite root" if anchored else "this folder"
+        places.append(f"directly inside {base}{direct}")
+    for index in range(len(groups) - 2, -1, -1):
+        if groups[index]:
+            folder = _explain_folders(groups[index], index > 0, anchored)
+            places.append(f"at any depth under {folder}")
+        else:
+            places.append("at any depth under the datasite root" if anchored else "at any depth")
+    description = f"{what} {', '.join(places)}"
+    return f"excludes {description}" if negated else description
+
+
+def _explain_name(segment: str, kind: str) -> str:
+    """Describe the names one pattern segment matches."""
+    if segment == "*":
+        return f"any {kind}"
+    if _names_one_path(segment):
+        return f"the {kind} {_unescape(segment)}"
+    rest, start = segment[1:], segment[:-1]
+    if segment.startswith("*") and _names_one_path(rest):
+        if rest.startswith(".") and kind == "file":
+            return f"any {_unescape(rest)} file"
+        return f"any {kind} whose name ends with {_unescape(rest)}"
+    if segment.endswith("*") and not segment.endswith("\\*") and _names_one_path(start):
+        return f"any {kind} whose name starts with {_unescape(start)}"
+    return f"any {kind} matching {segment}"
+
+
+def _explain_folders(segments: List[str], nested: bool, anchored: bool) -> str:
+    """Describe a run of folder segments, either from the start or found at any depth."""
+    path = "/".join(segments) + "/"
+    if anchored and not nested:
+        path = "/" + path
+    if not all(_names_one_path(segment) for segment in segments):
+        return f"a folder matching {path}"
+    path = _unescape(path)
+    return f"a {path} folder" if nested else path
+
+
+def _unescape(pattern: str) -> str:
+    """Drop the backslashes escaping characters in a literal pattern."""
+    return re.sub(r"\\(.)", r"\1", pattern)
+
+
+def _split_negation(pattern: str) -> Tuple[bool, str]:
+    """
+    Split a leading ``!`` exclusion marker off a rule pattern.
+
+    Args:
+        pattern: Rule pattern, possibly prefixed with ``!``
+
+    Returns:
+        tuple: (is_exclusion, pattern without the marker)
+    """
+    if pattern.startswith("!"):
+        return True, pattern[1:]
+    return False, pattern
+
+
+def pattern_specificity(pattern: str) -> int:
+    """
+    Score how specific a rule pattern is; higher scores win.
+
+    The score follows old syftbox: ``2 * len(pattern) + 10 * (number of "/")``, minus
+    20 for a ``*`` at the start, 10 for every other ``*`` and 2 for each of
+    ``? ! [ ] {``. The catch-alls ``**`` and ``**/*`` are pinned to -100 and -99. A
+    leading ``!`` exclusion marker is ignored, so an exclusion scores like the pattern
+    it excludes, and the pattern is scored in its normalize_pattern form, so
+    ``./data//x`` is no more specific than ``data/x``.
+
+    Args:
+        pattern: Rule pattern, optionally with a leading ``!``
+
+    Returns:
+        int: Specificity score
+    """
+    _, pattern = _split_negation(normalize_pattern(pattern))
+    return _calculate_glob_specificity(pattern)
+
+
+def _rule_precedence_key(pattern: str, index: int, priority: int = 0) -> Tuple[int, int, bool, int]:
+    """
+    Sort key putting rules in the order they are tried, for ascending sorts.
+
+    Precedence is fully deterministic:
+
+    1. Higher ``priority`` first. Rules default to 0, so setting it lets one rule win
+       over any other in its file regardless of how specific either pattern is.
+    2. On equal priority, higher ``pattern_specificity`` first.
+    3. On equal specificity, exclusions (``!pattern``) before inclusions, so an
+       exclusion always wins over an include that is no more specific than itself.
+    4. Remaining ties go to the rule declared first in the file.
+
+    Args:
+        pattern: Rule pattern
+        index: Position of the rule in its file
+        priority: The rule's priority
+    """
+    negated, _ = _split_negation(pattern)
+    return -priority, -pattern_specificity(pattern), not negated, index
+
+
 def _sort_rules_by_specificity(rules: list) -> list:
     """
     Sort rules by specificity (most specific first) matching old syftbox algorithm.
@@ -327,17 +1198,67 @@ def _sort_rules_by_specificity(rules: list) -> list:
         rules: List of rule dictionaries
 
     Returns:
-        list: Rules sorted by specificity (descending)
+        list: Rules in the order they are tried, see ``_rule_precedence_key``
     """
-    # Create list of (rule, specificity) tuples
-    rules_with_scores = []
-    for rule in rules:
-        pattern = rule.get("pattern", "")
-        score = _calculate_glob_specificity(pattern)
-        rules_with_scores.append((rule, score))
+    indexed = sorted(
+        enumerate(rules),
+        key=lambda item: _rule_precedence_key(
+            item[1].get("pattern", ""), item[0], _raw_priority(item[1])
+        ),
+    )
+    return [rule for _, rule in indexed]
+
 
-    # Sort by specificity (descending - higher scores first)
-    rules_with_scores.sort(key=lambda x: x[1], reverse=True)
+def _raw_priority(rule: dict) -> int:
+    """Read the priority of an unvalidated rule mapping, treating bad values as 0."""
+    priority = rule.get("priority", 0)
+    if isinstance(priority, bool) or not isinstance(priority, int):
+        return 0
+    return priority
 
-    # Return just the rules
-    return [rule for rule, score in rules_with_scores]
+
+@dataclass(frozen=True)
+class MatchCapabilities:
+    """
+    Which extended glob features this build of the matcher supports.
+
+    Attributes:
+        braces: ``{a,b}`` alternatives
+        negation: ``!pattern`` exclusion rules
+        case_fold: Case-insensitive matching through ``MatchOptions.case_insensitive``
+        dotfile_toggle: Keeping wildcards off dotfiles through ``MatchOptions.match_dotfiles``
+        character_classes: ``[abc]`` and ``[!abc]`` classes
+        escapes: A ``\\`` making the next metacharacter literal
+        root_anchors: A leading ``/`` anchoring a rule pattern to the datasite root
+    """
+
+    braces: bool
+    negation: bool
+    case_fold: bool
+    dotfile_toggle: bool
+    character_classes: bool
+    escapes: bool
+    root_anchors: bool
+
+
+@lru_cache(maxsize=None)
+def capabilities() -> MatchCapabilities:
+    """
+    Describe the extended matching features available, for feature detection.
+
+    Each feature is probed against the matcher itself on the first call rather than
+    read from a list kept by hand, so the answer follows what matching really does.
+
+    Returns:
+        MatchCapabilities: The supported features
+    """
+    no_dotfiles = MatchOptions(match_dotfiles=False)
+    return MatchCapabilities(
+        braces=match("{a,b}.txt", "b.txt"),
+        negation=_split_negation("!a.txt") == (True, "a.txt"),
+        case_fold=match("A.txt", "a.txt", MatchOptions(case_insensitive=True)),
+        dotfile_toggle=match("*", ".env") and not match("*", ".env", no_dotfiles),
+        character_classes=match("[ab].txt", "b.txt") and not match("[!ab].txt", "b.txt"),
+        escapes=match("a\\*.txt", "a*.txt") and not match("a\\*.txt", "ab.txt"),
+        root_anchors=normalize_pattern("/a/b") == "/a/b",
+    )
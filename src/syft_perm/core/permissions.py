@@ -1,11 +1,14 @@
 """Permission-related components for SyftPerm."""
 
+import threading
 from collections import OrderedDict
 from dataclasses import dataclass, field
-from enum import Enum
+from enum import Enum, Flag, IntEnum
 from pathlib import Path
 from typing import Any, Dict, List, Optional
+from urllib.parse import quote
 
+from .errors import UnknownAccessLevelError
 from .path_matching import _acl_norm_path
 
 
@@ -23,6 +26,267 @@ class PermissionReason(Enum):
     FILE_LIMIT = "Blocked by {limit_type} limit"
 
 
+class AccessLevel(IntEnum):
+    """
+    Access levels granted by permission rules, ordered from least to most privileged.
+
+    Each level includes every level below it (admin > write > create > read), so
+    "does this user have at least write" is a plain comparison:
+    ``level >= AccessLevel.WRITE``.
+    """
+
+    NONE = 0
+    READ = 1
+    CREATE = 2
+    WRITE = 3
+    ADMIN = 4
+
+    def __str__(self) -> str:
+        """Return the lowercase name used in syft.pub.yaml files."""
+        return self.name.lower()
+
+
+def parse_access_level(value: str) -> AccessLevel:
+    """
+    Parse an access level name as written in syft.pub.yaml.
+
+    Args:
+        value: Level name such as "read" or "admin" (case-insensitive)
+
+    Returns:
+        AccessLevel: The matching access level
+
+    Raises:
+        UnknownAccessLevelError: If the name is not a known access level
+    """
+    if isinstance(value, str):
+        name = value.strip().upper()
+        if name in AccessLevel.__members__:
+            return AccessLevel[name]
+    raise UnknownAccessLevelError(value, [str(level) for level in AccessLevel])
+
+
+class Verb(Flag):
+    """
+    Individual operations a rule can grant, for access the level ladder can't express.
+
+    A set of verbs is a combination like ``Verb.READ | Verb.CREATE`` ("can read and
+    add files but not overwrite or delete them"), and ``Verb(0)`` is no access. Each
+    access level stands for a fixed bundle (see verbs_for_level).
+    """
+
+    READ = 1
+    CREATE = 2
+    UPDATE = 4
+    DELETE = 8
+    ADMIN = 16
+
+    def __str__(self) -> str:
+        """Return the lowercase names used in syft.pub.yaml files, joined with ``+``."""
+        return "+".join(verb.name.lower() for verb in Verb if verb in self)
+
+
+_LEVEL_VERBS = {
+    AccessLevel.NONE: Verb(0),
+    AccessLevel.READ: Verb.READ,
+    AccessLevel.CREATE: Verb.READ | Verb.CREATE,
+    AccessLevel.WRITE: Verb.READ | Verb.CREATE | Verb.UPDATE | Verb.DELETE,
+    AccessLevel.ADMIN: Verb.READ | Verb.CREATE | Verb.UPDATE | Verb.DELETE | Verb.ADMIN,
+}
+
+
+def verbs_for_level(level: AccessLevel) -> Verb:
+    """
+    Get the verbs an access level grants.
+
+    Read is just read and create adds create; write adds update and delete, and
+    admin adds admin on top of everything. Each bundle includes the ones below it.
+    """
+    return _LEVEL_VERBS[level]
+
+
+def level_for_verbs(verbs: Verb) -> AccessLevel:
+    """
+    Get the highest access level whose whole bundle a set of verbs covers.
+
+    ``Verb.READ | Verb.CREATE`` is CREATE, and ``Verb.CREATE`` alone is NONE since it
+    lacks read. For sets granted through levels alone this round-trips with
+    verbs_for_level.
+    """
+    for level in sorted(_LEVEL_VERBS, reverse=True):
+        if _LEVEL_VERBS[level] & verbs == _LEVEL_VERBS[level]:
+            return level
+    return AccessLevel.NONE
+
+
+def parse_verb(value: str) -> Verb:
+    """
+    Parse a verb name as written under a rule's ``verbs`` in syft.pub.yaml.
+
+    Args:
+        value: Verb name such as "create" or "delete" (case-insensitive)
+
+    Returns:
+        Verb: The matching single verb
+
+    Raises:
+        UnknownAccessLevelError: If the name is not a known verb
+    """
+    if isinstance(value, str):
+        name = value.strip().upper()
+        if name in Verb.__members__:
+            return Verb[name]
+    raise UnknownAccessLevelError(value, [str(verb) for verb in Verb])
+
+
+# Allow-list entry standing for the owner of the datasite being resolved
+OWNER_PLACEHOLDER = "{owner}"
+
+# Pattern token standing for the requesting user's own directory name
+USER_PLACEHOLDER = "{user}"
+
+
+def canonical_user(user: str) -> str:
+    """
+    Get the form a user ID is compared in: emails lowercased, anything else unchanged.
+
+    ``Alice@Org.com`` and ``alice@org.com`` are the same account with every provider
+    syftbox users sign in with, so emails are matched case-insensitively by default.
+    """
+    return user.lower() if "@" in user else user
+
+
+def user_path_segment(user: str, strict: bool = False) -> Optional[str]:
+    """
+    Get the directory name a ``{user}`` token in a rule pattern stands for.
+
+    The user ID is taken in the form it is compared in (see canonical_user; as given
+    when ``strict`` is set) and percent-encoded, leaving letters, digits and ``@+-._~``
+    as they are. An email is thus its own folder name, ``alice@example.com`` like a
+    datasite directory, while ``/``, ``%`` and glob characters are encoded so the result
+    is always one literal path segment and no two users share a segment. ``*``, which
+    stands for anyone rather than a user, and IDs that would encode to ``.``, ``..`` or
+    nothing have no segment.
+
+    Args:
+        user: Requesting user ID
+        strict: Keep the ID's case instead of lowercasing emails
+
+    Returns:
+        str: Path segment for the user, or None if ``{user}`` can't match for them
+    """
+    if user == "*":
+        return None
+    segment = quote(user if strict else canonical_user(user), safe="@+")
+    return segment if segment not in ("", ".", "..") else None
+
+
+def _user_matches(entry: str, user: str, owner: Optional[str] = None, strict: bool = False) -> bool:
+    """
+    Check whether one allow-list entry covers a user.
+
+    ``*`` matches everyone and ``*@domain`` matches any user whose email is at that
+    domain (compared case-insensitively). ``{owner}`` matches the datasite owner when
+    one is known. Any other entry must be the same email ignoring case, or exactly
+    equal to the user when ``strict`` is set.
+    """
+    if entry == "*":
+        return True
+    if entry.startswith("*@"):
+        return user.lower().endswith(entry[1:].lower())
+    if entry == OWNER_PLACEHOLDER:
+        if owner is None:
+            return False
+        entry = owner
+    if strict:
+        return entry == user
+    return canonical_user(entry) == canonical_user(user)
+
+
+class UserMatcher:
+    """
+    Decides whether one allow- or revoke-list entry covers a requesting user.
+
+    Resolvers use EmailUserMatcher unless given another. Subclass this to identify
+    users some other way, e.g. by opaque IDs or by looking up group membership, and
+    pass it to Resolver or PermissionStore. It is asked about every entry a user is
+    compared with, ``*`` included; ``{owner}`` entries are replaced by the datasite
+    owner first, or skipped when there is none. It may be called from several threads
+    at once.
+    """
+
+    def matches(self, entry: str, user: str) -> bool:
+        """
+        Whether an entry covers a user.
+
+        Args:
+            entry: One allow- or revoke-list entry, as loaded
+            user: User ID being resolved for
+
+        Returns:
+            bool: True if whatever the entry grants or revokes applies to the user
+        """
+        raise NotImplementedError
+
+
+class EmailUserMatcher(UserMatcher):
+    """
+    The built-in matching of users identified by email.
+
+    ``*`` matches everyone, ``*@domain`` anyone at that domain, and any other entry
+    the same email ignoring case, or exactly the same ID when ``strict`` is set.
+    """
+
+    def __init__(self, strict: bool = False):
+        self.strict = strict
+
+    def matches(self, entry: str, user: str) -> bool:
+        return _user_matches(entry, user, strict=self.strict)
+
+
+def _user_in(
+    users: List[str],
+    user: str,
+    owner: Optional[str] = None,
+    strict: bool = False,
+    matcher: Optional[UserMatcher] = None,
+) -> bool:
+    """Check whether any entry in an allow list covers a user, through ``matcher`` if set."""
+    if matcher is None:
+        return any(_user_matches(entry, user, owner, strict) for entry in users)
+    for entry in users:
+        if entry == OWNER_PLACEHOLDER:
+            if owner is None:
+                continue
+            entry = owner
+        if matcher.matches(entry, user):
+            return True
+    return False
+
+
+def _effective_access_level(
+    permissions: Dict[str, List[str]], user: str, strict: bool = False
+) -> AccessLevel:
+    """
+    Get the highest access level a user holds in a permissions dictionary.
+
+    Args:
+        permissions: Mapping of level names to user lists (as read from yaml)
+        user: User ID to look up
+        strict: Require entries to equal the user exactly instead of ignoring the
+            case of emails
+
+    Returns:
+        AccessLevel: Highest level with an entry covering the user, or NONE
+    """
+    for level in sorted(AccessLevel, reverse=True):
+        if level == AccessLevel.NONE:
+            break
+        if _user_in(permissions.get(str(level), []), user, strict=strict):
+            return level
+    return AccessLevel.NONE
+
+
 @dataclass
 class PermissionResult:
     """Result of a permission check with reasons."""
@@ -35,39 +299,49 @@ class PermissionResult:
 
 # Cache implementation for permission lookups
 class PermissionCache:
-    """Simple LRU cache for permission lookups to match old ACL performance."""
+    """
+    Simple LRU cache for permission lookups to match old ACL performance.
+
+    Thread-safe: every operation holds a lock, so permission checks running on many
+    threads can share the global instance without locking of their own.
+    """
 
     def __init__(self, max_size: int = 10000):
         self.cache: OrderedDict[str, Dict[str, List[str]]] = OrderedDict()
         self.max_size = max_size
+        self._lock = threading.Lock()
 
     def get(self, path: str) -> Optional[Dict[str, List[str]]]:
         """Get permissions from cache if available."""
-        if path in self.cache:
-            # Move to end (LRU)
-            self.cache.move_to_end(path)
-            return self.cache[path]
-        return None
+        with self._lock:
+            permissions = self.cache.get(path)
+            if permissions is not None:
+                # Move to end (LRU)
+                self.cache.move_to_end(path)
+            return permissions
 
     def set(self, path: str, permissions: Dict[str, List[str]]) -> None:
         """Set permissions in cache."""
-        if path in self.cache:
-            self.cache.move_to_end(path)
-        else:
-            if len(self.cache) >= self.max_size:
-                # Remove oldest entry
-                self.cache.popitem(last=False)
-        self.cache[path] = permissions
+        with self._lock:
+            if path in self.cache:
+                self.cache.move_to_end(path)
+            else:
+                if len(self.cache) >= self.max_size:
+                    # Remove oldest entry
+                    self.cache.popitem(last=False)
+            self.cache[path] = permissions
 
     def invalidate(self, path_prefix: str) -> None:
         """Invalidate all cache entries starting with path_prefix."""
-        keys_to_remove = [k for k in self.cache if k.startswith(path_prefix)]
-        for key in keys_to_remove:
-            del self.cache[key]
+        with self._lock:
+            keys_to_remove = [k for k in self.cache if k.startswith(path_prefix)]
+            for key in keys_to_remove:
+                del self.cache[key]
 
     def clear(self) -> None:
         """Clear all cache entries."""
-        self.cache.clear()
+        with self._lock:
+            self.cache.clear()
 
 
 # Global cache instance
@@ -76,11 +350,12 @@ _permission_cache = PermissionCache()
 
 def get_cache_stats() -> Dict[str, Any]:
     """Get cache statistics for testing and debugging."""
-    return {
-        "size": len(_permission_cache.cache),
-        "max_size": _permission_cache.max_size,
-        "keys": list(_permission_cache.cache.keys()),
-    }
+    with _permission_cache._lock:
+        return {
+            "size": len(_permission_cache.cache),
+            "max_size": _permission_cache.max_size,
+            "keys": list(_permission_cache.cache.keys()),
+        }
 
 
 def clear_permission_cache() -> None:
@@ -0,0 +1,1603 @@
+"""Resolve effective access for paths inside a datasite from its syft.pub.yaml files."""
+
+import json
+import logging
+import os
+import posixpath
+import stat
+import threading
+import time
+from dataclasses import dataclass, field
+from datetime import datetime, timezone
+from enum import Enum
+from pathlib import Path, PurePosixPath
+from typing import (
+    Any,
+    Callable,
+    Dict,
+    Iterable,
+    Iterator,
+    List,
+    Mapping,
+    Optional,
+    Set,
+    Tuple,
+    Union,
+)
+
+from .errors import PathEscapesRootError
+from .filesystem import FileSystem, WalkEntry, find_entry
+from .matcher import _index_rules, compile_pattern
+from .metrics import Metrics
+from .path_matching import (
+    DEFAULT_MAX_WILDCARDS,
+    MatchOptions,
+    _acl_norm_path,
+    _could_match_below,
+    _names_one_path,
+    _normalize_separators,
+    _split_negation,
+)
+from .permissions import (
+    OWNER_PLACEHOLDER,
+    USER_PLACEHOLDER,
+    AccessLevel,
+    EmailUserMatcher,
+    UserMatcher,
+    Verb,
+    canonical_user,
+    level_for_verbs,
+    user_path_segment,
+    verbs_for_level,
+)
+from .rules import (
+    IGNORE_FILE_NAME,
+    PERMISSION_FILE_NAME,
+    EffectiveRule,
+    EffectiveRuleset,
+    PermissionFile,
+    Rule,
+    load_permission_file,
+    merge_rule_chain,
+    parse_ignore_file,
+    parse_permission_file,
+)
+
+logger = logging.getLogger(__name__)
+
+
+# Stats an absolute path like os.lstat; injectable so tests can fake file sizes
+StatFunc = Callable[[Path], os.stat_result]
+
+
+class ResolutionCancelled(Exception):
+    """A resolution was stopped through its Cancellation before it finished."""
+
+
+class ResolutionTimeout(ResolutionCancelled, TimeoutError):
+    """A resolution ran past the timeout of its Cancellation."""
+
+
+class Cancellation:
+    """
+    Lets a caller abort a resolution that is taking too long.
+
+    Resolver methods accepting a Cancellation check it each time they move to another
+    directory, e.g. while loading the permission chain or walking the datasite, and
+    raise once it has been cancelled or its timeout has passed. A check between
+    directories means a single slow filesystem call is not interrupted.
+
+    Args:
+        timeout: Seconds from now after which the resolution is aborted, or None
+        event: Event that aborts the resolution when set; a new one is created if
+            not given, and ``cancel()`` sets it
+    """
+
+    def __init__(self, timeout: Optional[float] = None, event: Optional[threading.Event] = None):
+        self.event = event if event is not None else threading.Event()
+        self.expires_at = None if timeout is None else time.monotonic() + timeout
+
+    def cancel(self) -> None:
+        """Abort every resolution using this Cancellation at its next check."""
+        self.event.set()
+
+    def check(self) -> None:
+        """
+        Raise if the resolution should stop.
+
+        Raises:
+            ResolutionCancelled: If ``cancel()`` was called or the event is set
+            ResolutionTimeout: If the timeout has passed
+        """
+        if self.event.is_set():
+            raise ResolutionCancelled("resolution cancelled")
+        if self.expires_at is not None and time.monotonic() >= self.expires_at:
+            raise ResolutionTimeout("resolution timed out")
+
+
+class TraceReason(Enum):
+    """Why a rule was or wasn't applied during resolution."""
+
+    APPLIED = "applied"
+    USER_NOT_LISTED = "user not in allow list"
+    EXCLUDED = "path excluded by rule"
+    PATTERN_MISMATCH = "pattern did not match"
+    SHADOWED_BY_SPECIFIC = "a more specific rule matched first"
+    SHADOWED_BY_NEARER = "a nearer permission file decided"
+    OVERRIDDEN_BY_TERMINAL = "overridden by terminal"
+    LIMIT_EXCEEDED = "blocked by file limits"
+    OUTSIDE_DEPTH = "path outside the rule's depth range"
+    REVOKED = "access revoked by rule"
+    INACTIVE = "rule outside its validity window"
+    NOT_A_DIRECTORY = "rule only matches directories"
+    IGNORED = "path matched by an ignore file"
+
+
+class DenialReason(Enum):
+    """Why a path resolved to no access, for telling the user what to do about it."""
+
+    # No rule decided the path, so it got default_access
+    NO_MATCHING_RULE = "no rule covers this path"
+    # The rule deciding the path doesn't grant the user a level
+    USER_NOT_LISTED = "user not in the allow list of the rule covering this path"
+    EXCLUDED = "path excluded by rule"
+    REVOKED = "access revoked by rule"
+    IGNORED = "path matched by an ignore file"
+
+
+class MatchKind(Enum):
+    """How broadly a rule's pattern reaches, for flagging sweeping grants in audits."""
+
+    # A literal pattern, naming exactly the path it matched
+    EXACT = "exact"
+    # A pattern with *, **, ?, a [...] class or {...} alternatives
+    WILDCARD = "wildcard"
+
+
+class ResolutionStrategy(Enum):
+    """How the rules matching a path combine into one access level."""
+
+    # The first matching rule, nearest file first and by precedence within a file, decides
+    MOST_SPECIFIC = "most_specific"
+    # Every matching rule counts and the highest level any of them grants wins
+    MOST_PERMISSIVE = "most_permissive"
+
+
+@dataclass(frozen=True)
+class RuleMatch:
+    """
+    One rule considered while resolving a path.
+
+    Attributes:
+        directory: Datasite-relative directory of the permission file ("" for the root)
+        rule_index: Index of the rule in its file's declaration order
+        pattern: The rule pattern as written
+        matched: Whether the pattern matched the path
+        applied: Whether this rule decided the result, or revoked the user's access
+        reason: Why the rule was or wasn't applied
+        level: Access level this rule grants the user when applied; for REVOKED, the
+            most the user is left with
+    """
+
+    directory: str
+    rule_index: int
+    pattern: str
+    matched: bool
+    applied: bool
+    reason: TraceReason
+    level: AccessLevel = AccessLevel.NONE
+
+    @property
+    def match_kind(self) -> MatchKind:
+        """
+        Whether the pattern names one exact path or is a wildcard.
+
+        Escaped characters like ``\\*`` are literal, and ``!``, ``/`` anchors and
+        trailing slashes don't count.
+        """
+        literal = _names_one_path(_split_negation(self.pattern)[1])
+        return MatchKind.EXACT if literal else MatchKind.WILDCARD
+
+    def to_dict(self) -> Dict[str, Any]:
+        """Serialize to plain values suitable for logging or json."""
+        return {
+            "directory": self.directory,
+            "rule_index": self.rule_index,
+            "pattern": self.pattern,
+            "matched": self.matched,
+            "applied": self.applied,
+            "reason": self.reason.value,
+            "level": str(self.level),
+            "match_kind": self.match_kind.value,
+        }
+
+    def __str__(self) -> str:
+        """One line naming the rule and why it was or wasn't applied, applied ones starred."""
+        source = posixpath.join(self.directory, PERMISSION_FILE_NAME)
+        line = f"{source} rule {self.rule_index} ({self.pattern!r}): {self.reason.value}"
+        if self.applied:
+            return f"* {line} -> {self.level}"
+        return f"  {line}"
+
+
+class Trace(list):
+    """
+    The RuleMatch entries of one resolution, in the order they were considered.
+
+    A plain list in every other respect. The trace only depends on the permission
+    files and the path, never on when or how often it is made, so its json can be
+    committed as a golden file.
+    """
+
+    def to_json(self, indent: Optional[int] = None) -> str:
+        """
+        Serialize to canonical json: a list of ``RuleMatch.to_dict`` entries in trace
+        order, with keys sorted.
+
+        Args:
+            indent: Spaces per nesting level, or None for compact output without spaces
+
+        Returns:
+            str: The same text for the same trace, byte for byte
+        """
+        separators = (",", ":") if indent is None else (",", ": ")
+        entries = [match.to_dict() for match in self]
+        return json.dumps(entries, indent=indent, separators=separators, sort_keys=True)
+
+    def __str__(self) -> str:
+        """The trace as lines of ``RuleMatch.__str__``."""
+        return "\n".join(str(match) for match in self)
+
+
+@dataclass
+class RuleStat:
+    """
+    How many files of a datasite one rule matches, as counted by rule_coverage.
+
+    Attributes:
+        directory: Datasite-relative directory of the permission file ("" for the root)
+        rule_index: Index of the rule in its file's declaration order
+        rule: The rule itself; its ``position`` gives the line it was read from
+        source: Path of the permission file holding the rule, if known
+        total_matches: Files the rule matches, whether or not another rule decides them
+        first_matches: Files the rule decides, being the first rule tried that matches
+    """
+
+    directory: str
+    rule_index: int
+    rule: Rule
+    source: Optional[Path]
+    total_matches: int = 0
+    first_matches: int = 0
+
+
+class DotSegments(Enum):
+    """What to do with ``..`` segments in the paths a resolver is asked about."""
+
+    # Raise PathEscapesRootError for any path with a .. segment
+    REJECT = "reject"
+    # Clean the path lexically and only raise if the result leaves the datasite root
+    CLEAN = "clean"
+
+
+@dataclass
+class _MatchMemo:
+    """
+    The user-independent checks made while resolving one path, kept for reuse.
+
+    Which rules match a path, whether they fit its file limits and which file is
+    terminal for it don't depend on the user, so evaluating the path for several users
+    can share one memo and only redo the allow-list lookups. Rules with a ``{user}``
+    pattern are the exception: their matches are kept per user. The memo also holds the
+    instant rule validity windows are checked against, so all users see the same one,
+    whether the path is a directory once that is known, and whether an ignore file
+    covers it.
+    """
+
+    now: Optional[datetime] = None
+    is_dir: Optional[bool] = None
+    kind_known: bool = False
+    ignored: Optional[bool] = None
+    terminal_dir: Optional[str] = None
+    terminal_known: bool = False
+    matched: Dict[Tuple[str, int, Optional[str]], bool] = field(default_factory=dict)
+    within_limits: Dict[Tuple[str, int], bool] = field(default_factory=dict)
+
+
+def _utc_now() -> datetime:
+    """The default Resolver clock."""
+    return datetime.now(timezone.utc)
+
+
+def _decisive_match(
+    trace: List[RuleMatch], level: AccessLevel, default_access: AccessLevel
+) -> Optional[RuleMatch]:
+    """The entry of a resolution trace whose rule decided the resolved level, if any."""
+    applied = [m for m in trace if m.applied]
+    exclusion = next((m for m in applied if m.reason is TraceReason.EXCLUDED), None)
+    if exclusion is not None:
+        return exclusion
+    grants = [m for m in applied if m.reason is not TraceReason.REVOKED]
+    granted = max((m.level for m in grants), default=default_access)
+    if level < granted:
+        # Capped by a revoke: the one that leaves the least decided
+        revokes = (m for m in applied if m.reason is TraceReason.REVOKED and m.level == level)
+        return next(revokes, None)
+    return next((m for m in grants if m.level == level), None)
+
+
+class Resolver:
+    """
+    Resolve access levels for paths in a datasite using the nearest-node algorithm.
+
+    Starting at the directory containing the path and walking up to the datasite root,
+    the nearest syft.pub.yaml with a matching rule decides access. Rule patterns are
+    relative to the directory holding their permission file, so ``*.csv`` in
+    ``data/projectA/syft.pub.yaml`` matches ``data/projectA/x.csv`` but not
+    ``data/x.csv`` or ``data/projectA/sub/x.csv``. A pattern with a leading ``/`` is
+    anchored to the datasite root instead and matched against the whole path, so
+    ``/data/*/x.csv`` in that file matches ``data/projectA/x.csv``. Either way a file
+    only governs paths beneath its own directory: an anchored pattern naming a sibling
+    tree never matches, since a permission file can't grant access outside the
+    directory it sits in. Within a file rules are tried by priority, then from most to
+    least specific, and the first match wins. A terminal file stops inheritance: only
+    its own rules apply to everything beneath it. A rule marked terminal does the same
+    for just the paths it matches, and the terminal file closest to the datasite root
+    takes precedence.
+
+    When no rule matches a path at all, ``default_access`` is returned. A terminal file
+    without a matching rule still blocks its parents, so paths beneath it fall back to
+    the default rather than to inherited rules. Exclusions and rules that match but
+    don't list the user are explicit decisions and always resolve to NONE.
+
+    That first-match behavior is the default ``MOST_SPECIFIC`` strategy. With
+    ``MOST_PERMISSIVE`` every matching rule of every consulted file counts and the user
+    gets the highest level any of them grants. Terminals still decide which files are
+    consulted, and a matching exclusion still denies the path outright.
+
+    Revokes are applied last. Every matching rule of a consulted file that revokes a
+    level from the user caps the result below that level, whether or not the rule
+    decided the path. Files shadowed by a nearer decision or overridden by a terminal
+    aren't consulted, so their revokes don't apply. A rule that only revokes never
+    decides a path, so the decision falls through to the rules that grant.
+
+    Paths are matched lexically by default. With ``resolve_real_path`` set, symlinks
+    are resolved first and rules are matched against where the path really lives, so a
+    link can't borrow the permissions of the directory it sits in.
+
+    With ``ignore_files`` set, every directory may hold a .syftignore file whose
+    patterns, in the syntax and relative to the directory of rules, take paths out of
+    the datasite. An ignored path, and everything beneath an ignored directory, has no
+    access at all, whatever the rules or ``default_access`` say, and walk skips it.
+    Ignore files are read from ``filesystem`` or ``root`` even when
+    ``permission_files`` is given.
+
+    Rules with a ``not_before``/``not_after`` window are skipped entirely, terminal or
+    not, when ``clock`` says the current time is outside it.
+
+    A pattern ending in ``/`` only matches directories. Pass ``is_dir`` to resolve to
+    say what the path is; otherwise it is statted with ``stat_func`` (so a symlink is
+    never a directory) the first time such a rule is tried. When neither tells, as for
+    a path that doesn't exist yet or with ``stat_func`` set to None, the trailing ``/``
+    is ignored. Patterns without one match files and directories alike.
+
+    Queried paths with ``..`` segments are rejected unless ``dot_segments`` is CLEAN,
+    in which case ``data/../notes.txt`` resolves as ``notes.txt`` and only paths
+    climbing out of the datasite are rejected. Patterns can never contain ``..``.
+
+    A resolver is never changed by resolving, and neither are the permission files it
+    reads, so one resolver or one set of ``permission_files`` can serve many threads
+    at once without any locking by the caller. Compiled patterns come from a shared,
+    locked cache, as do per-file indexes grouping rules by the literal directories
+    their patterns start with, so only rules whose prefix a path lies under are tried.
+
+    Args:
+        root: Datasite root directory
+        match_options: Options passed to the glob matcher
+        default_access: Level for paths no rule matches (NONE unless set)
+        resolve_real_path: Resolve symlinks before matching and reject paths that end
+            up outside the datasite root
+        stat_func: Used to check a rule's size, directory and symlink limits. It gets the
+            absolute path and should behave like ``os.lstat``. Pass None to resolve
+            without touching the filesystem, in which case those limits are ignored;
+            ``allowed_extensions`` only looks at the path and is always enforced.
+        permission_files: Resolve against these files, keyed by datasite-relative
+            directory ("" for the root), instead of reading syft.pub.yaml from disk
+        owner: Datasite owner granted whatever ``{owner}`` entries in user lists grant.
+            Without one, ``{owner}`` matches nobody.
+        filesystem: Read permission files, directory listings and stats from this
+            filesystem, rooted at the datasite, instead of from ``root`` on disk.
+            ``stat_func`` is then unused unless None, which still disables the limits.
+            With case-insensitive ``match_options`` files are found in it whatever
+            case they are stored in, as on a case-insensitive disk (see find_entry).
+        strict_users: Match user IDs exactly as written. By default emails are
+            compared ignoring case, both in the rules and in the requesting user.
+        strategy: How matching rules combine; MOST_SPECIFIC unless set
+        max_wildcards: Reject permission files with patterns containing more wildcards
+            than this when loading them; None for no limit
+        dot_segments: How ``..`` segments in queried paths are handled; REJECT unless set
+        metrics: Told about every resolution and pattern compile; nothing is timed
+            without one
+        clock: Returns the timezone-aware current time that rule validity windows are
+            checked against, once per resolved path; the system clock in UTC unless set
+        variables: Values for ``${name}`` references in the permission files read from
+            disk or ``filesystem``, as in parse_permission_file; not applied to
+            ``permission_files``
+        user_matcher: Decides which allow- and revoke-list entries cover the requesting
+            user. EmailUserMatcher, honoring ``strict_users``, unless set.
+        ignore_files: Honor .syftignore files; off unless set
+
+    Raises:
+        ValueError: If ``filesystem`` is combined with ``resolve_real_path``
+    """
+
+    def __init__(
+        self,
+        root: Union[str, Path],
+        match_options: Optional[MatchOptions] = None,
+        default_access: AccessLevel = AccessLevel.NONE,
+        resolve_real_path: bool = False,
+        stat_func: Optional[StatFunc] = os.lstat,
+        permission_files: Optional[Mapping[str, PermissionFile]] = None,
+        owner: Optional[str] = None,
+        filesystem: Optional[FileSystem] = None,
+        strict_users: bool = False,
+        strategy: ResolutionStrategy = ResolutionStrategy.MOST_SPECIFIC,
+        max_wildcards: Optional[int] = DEFAULT_MAX_WILDCARDS,
+        dot_segments: DotSegments = DotSegments.REJECT,
+        metrics: Optional[Metrics] = None,
+        clock: Callable[[], datetime] = _utc_now,
+        variables: Optional[Mapping[str, str]] = None,
+        user_matcher: Optional[UserMatcher] = None,
+        ignore_files: bool = False,
+    ):
+        if filesystem is not None and resolve_real_path:
+            raise ValueError("resolve_real_path needs the local filesystem")
+        self.root = Path(root)
+        self.match_options = match_options
+        self.default_access = default_access
+        self.resolve_real_path = resolve_real_path
+        self.stat_func = stat_func
+        self.permission_files = (
+            None
+            if permission_files is None
+            else {_acl_norm_path(d): f for d, f in permission_files.items()}
+        )
+        self.owner = owner
+        self.filesystem = filesystem
+        self.strict_users = strict_users
+        self.strategy = strategy
+        self.max_wildcards = max_wildcards
+        self.dot_segments = dot_segments
+        self.metrics = metrics
+        self.clock = clock
+        self.variables = variables
+        self.user_matcher = (
+            user_matcher if user_matcher is not None else EmailUserMatcher(strict_users)
+        )
+        self.ignore_files = ignore_files
+
+    def resolve(
+        self,
+        path: Union[str, Path],
+        user: str,
+        cancel: Optional[Cancellation] = None,
+        is_dir: Optional[bool] = None,
+    ) -> AccessLevel:
+        """
+        Resolve the access level a user has on a path.
+
+        Args:
+            path: Path relative to the datasite root, or an absolute path inside it
+            user: User ID to resolve for
+            cancel: Optional Cancellation checked at every directory
+            is_dir: Whether the path is a directory, if the caller already knows (e.g.
+                from ``os.DirEntry.is_dir``); None to stat it when a rule needs to know
+
+        Returns:
+            AccessLevel: Effective access level, or default_access if no rule matches
+
+        Raises:
+            ResolutionCancelled: If ``cancel`` aborts the resolution
+            PathEscapesRootError: If the path is outside the datasite root
+            UnknownAccessLevelError: If a permission file on the way uses an unknown level
+            PatternSyntaxError: If a permission file on the way has a malformed pattern
+        """
+        level, _ = self.resolve_with_trace(path, user, cancel, is_dir)
+        return level
+
+    def check_access(
+        self,
+        path: Union[str, Path],
+        user: str,
+        required: AccessLevel,
+        cancel: Optional[Cancellation] = None,
+    ) -> bool:
+        """
+        Check whether a user holds at least an access level on a path.
+
+        Levels are hierarchical, so admin implies write, write implies create and create
+        implies read.
+
+        Args:
+            path: Path relative to the datasite root, or an absolute path inside it
+            user: User ID to check
+            required: Minimum access level needed
+            cancel: Optional Cancellation checked at every directory
+
+        Returns:
+            bool: True if the resolved level is at least ``required``
+
+        Raises:
+            ResolutionCancelled: If ``cancel`` aborts the resolution
+        """
+        return self.resolve(path, user, cancel) >= required
+
+    def resolve_verbs(
+        self,
+        path: Union[str, Path],
+        user: str,
+        cancel: Optional[Cancellation] = None,
+        is_dir: Optional[bool] = None,
+    ) -> Verb:
+        """
+        Resolve the individual verbs a user holds on a path.
+
+        Rules are applied as by resolve, each granting the bundle of the user's level
+        plus any verbs it lists for them, and revokes cap the set at the bundle of the
+        level left. resolve returns the highest level whose whole bundle the set holds.
+
+        Args:
+            path: Path relative to the datasite root, or an absolute path inside it
+            user: User ID to resolve for
+            cancel: Optional Cancellation checked at every directory
+            is_dir: Whether the path is a directory, if known (see resolve)
+
+        Returns:
+            Verb: The verbs granted, or the bundle of default_access if no rule matches
+
+        Raises:
+            ResolutionCancelled: If ``cancel`` aborts the resolution
+        """
+        memo = _MatchMemo(is_dir=is_dir, kind_known=is_dir is not None)
+        start = time.perf_counter()
+        rel_path = self._relative(path)
+        verbs, _ = self._evaluate_verbs(rel_path, self._chain(rel_path, cancel=cancel), user, memo)
+        if self.metrics is not None:
+            self.metrics.on_resolve(time.perf_counter() - start, rel_path.count("/") + 1)
+        return verbs
+
+    def can_read(self, path: Union[str, Path], user: str) -> bool:
+        """Check whether a user can read a path (see resolve_verbs)."""
+        return Verb.READ in self.resolve_verbs(path, user)
+
+    def can_create(self, path: Union[str, Path], user: str) -> bool:
+        """Check whether a user can create files at a path (see resolve_verbs)."""
+        return Verb.CREATE in self.resolve_verbs(path, user)
+
+    def can_write(self, path: Union[str, Path], user: str) -> bool:
+        """Check whether a user can overwrite a path (see resolve_verbs)."""
+        return Verb.UPDATE in self.resolve_verbs(path, user)
+
+    def can_delete(self, path: Union[str, Path], user: str) -> bool:
+        """Check whether a user can delete a path (see resolve_verbs)."""
+        return Verb.DELETE in self.resolve_verbs(path, user)
+
+    def can_admin(self, path: Union[str, Path], user: str) -> bool:
+        """Check whether a user can administer a path (see resolve_verbs)."""
+        return Verb.ADMIN in self.resolve_verbs(path, user)
+
+    def users_with_access(
+        self,
+        path: Union[str, Path],
+        minimum: AccessLevel = AccessLevel.READ,
+        cancel: Optional[Cancellation] = None,
+    ) -> Tuple[List[str], bool]:
+        """
+        List the users who hold at least an access level on a path.
+
+        The rules that decide a path are the same for every user, so this finds them,
+        honoring terminals, exclusions and the strategy, and reads their allow lists.
+        ``*@domain`` entries are returned as written because their members can't be
+        enumerated, and ``{owner}`` is replaced by the resolver's owner. Emails are
+        listed lowercased unless ``strict_users`` is set. Users a matching rule revokes
+        the level from, through any entry covering them, are left out, and nobody holds
+        it through ``*`` once it is revoked from anyone; revoking it from ``*`` leaves
+        nobody with it.
+        Rules with a ``{user}`` pattern match one user at a time and aren't consulted.
+
+        Args:
+            path: Path relative to the datasite root, or an absolute path inside it
+            minimum: Lowest access level to include
+            cancel: Optional Cancellation checked at every directory
+
+        Returns:
+            tuple: (sorted users listed with the level, whether everyone holds it
+                through a ``*`` entry or ``default_access``)
+
+        Raises:
+            ResolutionCancelled: If ``cancel`` aborts the resolution
+        """
+        rel_path = self._relative(path)
+        chain = self._chain(rel_path, cancel=cancel)
+        _, trace = self._evaluate(rel_path, chain, "")
+        files = dict(chain)
+        rules = [
+            files[m.directory].rules[m.rule_index]
+            for m in trace
+            if m.applied and m.reason is not TraceReason.REVOKED
+        ]
+        revokes = []
+        revoked = set()
+        for match in trace:
+            rule = files[match.directory].rules[match.rule_index]
+            if match.matched and rule.revoke:
+                revokes.append(rule)
+                for level, entries in rule.revoke.items():
+                    if level <= minimum:
+                        revoked.update(self._entry_keys(entries))
+        if "*" in revoked or any(rule.is_exclusion for rule in rules):
+            return [], False
+        if not rules:
+            return [], self.default_access >= minimum
+
+        # A user listed under read and under a create verb holds create, so add up
+        # each entry's verbs across lists before comparing with the minimum
+        granted: Dict[str, Verb] = {}
+        for rule in rules:
+            for verbs, entries in rule.verb_lists():
+                for key in self._entry_keys(entries):
+                    granted[key] = granted.get(key, Verb(0)) | verbs
+        everyone = level_for_verbs(granted.pop("*", Verb(0))) >= minimum and not revoked
+        users = [
+            key
+            for key, verbs in granted.items()
+            if level_for_verbs(verbs) >= minimum and not self._revoked(revokes, key, minimum)
+        ]
+        return sorted(users), everyone
+
+    def _revoked(self, revokes: List[Rule], user: str, minimum: AccessLevel) -> bool:
+        """Whether one of the rules revokes ``minimum`` or a lower level from a user."""
+        for rule in revokes:
+            cap = rule.revoked_for(user, self.owner, matcher=self.user_matcher)
+            if cap is not None and cap <= minimum:
+                return True
+        return False
+
+    def _entry_keys(self, entries: List[str]) -> Set[str]:
+        """The user keys of allow or revoke list entries, with ``{owner}`` replaced."""
+        keys = set()
+        for entry in entries:
+            if entry == "*":
+                keys.add(entry)
+            elif entry == OWNER_PLACEHOLDER:
+                if self.owner is not None:
+                    keys.add(self._user_key(self.owner))
+            else:
+                keys.add(self._user_key(entry))
+        return keys
+
+    def resolve_dir(
+        self, path: Union[str, Path], user: str, cancel: Optional[Cancellation] = None
+    ) -> Tuple[AccessLevel, bool]:
+        """
+        Summarize a user's access to a directory for display, e.g. as a folder badge.
+
+        If a rule matches the directory path itself, its level is the directory's.
+        Otherwise the directory gets the least access the user has to any of its
+        visible children, or ``default_access`` when it has none. Only the direct
+        children are resolved; hidden entries and permission files are skipped.
+
+        Args:
+            path: Directory relative to the datasite root, or an absolute path inside it
+            user: User ID to resolve for
+            cancel: Optional Cancellation checked at every directory
+
+        Returns:
+            tuple: (level for the directory, whether any child's level differs from it)
+
+        Raises:
+            ResolutionCancelled: If ``cancel`` aborts the resolution
+        """
+        rel_dir = self._relative(path)
+        _, dirnames, filenames = next(self._walk(rel_dir), (rel_dir, [], []))
+        children = [
+            posixpath.join(rel_dir, name)
+            for name in dirnames + filenames
+            if not name.startswith(".") and name != PERMISSION_FILE_NAME
+        ]
+        child_levels = set(self.resolve_batch(children, user, cancel).values())
+
+        own_rule = False
+        if rel_dir:
+            level, trace = self.resolve_with_trace(rel_dir, user, cancel, is_dir=True)
+            own_rule = any(m.applied for m in trace)
+        if not own_rule:
+            level = min(child_levels, default=self.default_access)
+        return level, any(child != level for child in child_levels)
+
+    def resolve_with_trace(
+        self,
+        path: Union[str, Path],
+        user: str,
+        cancel: Optional[Cancellation] = None,
+        is_dir: Optional[bool] = None,
+    ) -> Tuple[AccessLevel, Trace]:
+        """
+        Resolve a path and explain the decision.
+
+        The trace lists every rule of every permission file on the path, nearest file
+        first and in the order rules are tried, with whether it matched and why it was
+        or wasn't applied.
+
+        Args:
+            path: Path relative to the datasite root, or an absolute path inside it
+            user: User ID to resolve for
+            cancel: Optional Cancellation checked at every directory
+            is_dir: Whether the path is a directory, if known (see resolve)
+
+        Returns:
+            tuple: (effective access level, Trace)
+
+        Raises:
+            ResolutionCancelled: If ``cancel`` aborts the resolution
+        """
+        memo = _MatchMemo(is_dir=is_dir, kind_known=is_dir is not None)
+        if self.metrics is None:
+            rel_path = self._relative(path)
+            return self._evaluate(rel_path, self._chain(rel_path, cancel=cancel), user, memo)
+        start = time.perf_counter()
+        rel_path = self._relative(path)
+        result = self._evaluate(rel_path, self._chain(rel_path, cancel=cancel), user, memo)
+        self.metrics.on_resolve(time.perf_counter() - start, rel_path.count("/") + 1)
+        return result
+
+    def granting_rule(
+        self,
+        path: Union[str, Path],
+        user: str,
+        cancel: Optional[Cancellation] = None,
+        is_dir: Optional[bool] = None,
+    ) -> Optional[EffectiveRule]:
+        """
+        Find the one rule responsible for a user's access level on a path.
+
+        This is the rule a "why do I have access" message should name. An exclusion
+        that matched, or a revoke that capped the level, is responsible over any rule
+        granting more. Otherwise it's the rule that granted the level: under
+        MOST_SPECIFIC the rule that decided the path, which may be one not listing the
+        user at all, and under MOST_PERMISSIVE the first rule tried of those granting
+        the resolved level. The rule's ``position`` gives its line in the file.
+
+        Args:
+            path: Path relative to the datasite root, or an absolute path inside it
+            user: User ID to resolve for
+            cancel: Optional Cancellation checked at every directory
+            is_dir: Whether the path is a directory, if known (see resolve)
+
+        Returns:
+            EffectiveRule: The responsible rule and the permission file holding it, or
+                None if no rule decided the path and the level is ``default_access``
+
+        Raises:
+            ResolutionCancelled: If ``cancel`` aborts the resolution
+        """
+        rel_path = self._relative(path)
+        chain = self._chain(rel_path, cancel=cancel)
+        memo = _MatchMemo(is_dir=is_dir, kind_known=is_dir is not None)
+        level, trace = self._evaluate(rel_path, chain, user, memo)
+        match = _decisive_match(trace, level, self.default_access)
+        if match is None:
+            return None
+        perm_file = dict(chain)[match.directory]
+        return EffectiveRule(perm_file.rules[match.rule_index], perm_file.path, match.rule_index)
+
+    def denial_reason(
+        self,
+        path: Union[str, Path],
+        user: str,
+        cancel: Optional[Cancellation] = None,
+        is_dir: Optional[bool] = None,
+    ) -> Optional[DenialReason]:
+        """
+        Find why a user has no access to a path, the counterpart of granting_rule.
+
+        An ignore file covering the path comes first, then the rule granting_rule would
+        name: an exclusion, a revoke leaving the user nothing, or a rule that decided
+        the path without granting the user a level. When no rule decided the path,
+        including when the only matching rules were blocked by their file limits, it
+        is NO_MATCHING_RULE.
+
+        Args:
+            path: Path relative to the datasite root, or an absolute path inside it
+            user: User ID to resolve for
+            cancel: Optional Cancellation checked at every directory
+            is_dir: Whether the path is a directory, if known (see resolve)
+
+        Returns:
+            DenialReason: Why the level is NONE, or None if the user has some access
+
+        Raises:
+            ResolutionCancelled: If ``cancel`` aborts the resolution
+        """
+        rel_path = self._relative(path)
+        chain = self._chain(rel_path, cancel=cancel)
+        memo = _MatchMemo(is_dir=is_dir, kind_known=is_dir is not None)
+        level, trace = self._evaluate(rel_path, chain, user, memo)
+        if level > AccessLevel.NONE:
+            return None
+        if memo.ignored:
+            return DenialReason.IGNORED
+        match = _decisive_match(trace, level, self.default_access)
+        if match is None:
+            return DenialReason.NO_MATCHING_RULE
+        if match.reason is TraceReason.EXCLUDED:
+            return DenialReason.EXCLUDED
+        if match.reason is TraceReason.REVOKED:
+            return DenialReason.REVOKED
+        return DenialReason.USER_NOT_LISTED
+
+    def resolve_for_users(
+        self, path: Union[str, Path], users: Iterable[str], cancel: Optional[Cancellation] = None
+    ) -> Dict[str, AccessLevel]:
+        """
+        Resolve one path for many users, sharing the user-independent work.
+
+        The permission files are loaded and each rule's pattern and limits are checked
+        once; only the allow and revoke lists, and ``{user}`` patterns, are looked up
+        per user. The levels are the same as calling resolve for each user. Duplicate
+        users collapse into a single entry.
+
+        Args:
+            path: Path relative to the datasite root, or an absolute path inside it
+            users: User IDs to resolve for
+            cancel: Optional Cancellation checked at every directory
+
+        Returns:
+            dict: Access level keyed by each user as given
+
+        Raises:
+            ResolutionCancelled: If ``cancel`` aborts the resolution
+        """
+        rel_path = self._relative(path)
+        chain = self._chain(rel_path, cancel=cancel)
+        memo = _MatchMemo()
+        return {
+            user: self._evaluate(rel_path, chain, user, memo)[0] for user in dict.fromkeys(users)
+        }
+
+    def resolve_batch(
+        self, paths: Iterable[Union[str, Path]], user: str, cancel: Optional[Cancellation] = None
+    ) -> Dict[str, AccessLevel]:
+        """
+        Resolve many paths for one user, sharing work across the batch.
+
+        Paths are grouped by their containing directory so each permission file and ignore
+        file on the way to the root is loaded once for the whole batch rather than once per
+        path. Input order doesn't matter and duplicate paths collapse into a single entry.
+
+        Args:
+            paths: Paths relative to the datasite root, or absolute paths inside it
+            user: User ID to resolve for
+            cancel: Optional Cancellation checked at every directory
+
+        Returns:
+            dict: Access level keyed by each path as given (str)
+
+        Raises:
+            ResolutionCancelled: If ``cancel`` aborts the resolution
+        """
+        start = time.perf_counter() if self.metrics is not None else 0.0
+        loaded: Dict[str, Optional[PermissionFile]] = {}
+        ignores: Dict[str, List[Rule]] = {}
+        by_directory: Dict[str, List[Tuple[str, str]]] = {}
+        for path in dict.fromkeys(str(p) for p in paths):
+            rel_path = self._relative(path)
+            by_directory.setdefault(posixpath.dirname(rel_path), []).append((path, rel_path))
+
+        results: Dict[str, AccessLevel] = {}
+        for entries in by_directory.values():
+            chain = self._chain(entries[0][1], loaded, cancel)
+            for path, rel_path in entries:
+                memo = _MatchMemo()
+                memo.ignored = self.ignore_files and self._is_ignored(rel_path, memo, ignores)
+                results[path] = self._evaluate(rel_path, chain, user, memo)[0]
+        if self.metrics is not None:
+            self.metrics.on_batch(time.perf_counter() - start, len(results))
+        return results
+
+    def resolve_pairs(
+        self,
+        pairs: Iterable[Tuple[Union[str, Path], str]],
+        cancel: Optional[Cancellation] = None,
+    ) -> List[AccessLevel]:
+        """
+        Resolve many (path, user) pairs where both vary, sharing work across the batch.
+
+        Pairs are grouped by path, and paths by their containing directory, so each
+        permission file on the way to the root is loaded once for the whole batch and
+        each rule is checked against a path once, however many users ask about it.
+        Compiled patterns come from the shared cache, so a pattern is compiled once no
+        matter how many files or paths use it. The levels are the same as calling
+        resolve for each pair.
+
+        Args:
+            pairs: (path, user) tuples; paths are relative to the datasite root or
+                absolute paths inside it
+            cancel: Optional Cancellation checked at every directory
+
+        Returns:
+            list: The access level of each pair, in input order
+
+        Raises:
+            ResolutionCancelled: If ``cancel`` aborts the resolution
+        """
+        start = time.perf_counter() if self.metrics is not None else 0.0
+        by_path: Dict[str, List[Tuple[int, str]]] = {}
+        count = 0
+        for count, (path, user) in enumerate(pairs, start=1):
+            by_path.setdefault(self._relative(path), []).append((count - 1, user))
+
+        loaded: Dict[str, Optional[PermissionFile]] = {}
+        ignores: Dict[str, List[Rule]] = {}
+        results = [AccessLevel.NONE] * count
+        # Sorting keeps the paths of one directory together
+        for rel_path in sorted(by_path):
+            chain = self._chain(rel_path, loaded, cancel)
+            memo = _MatchMemo()
+            memo.ignored = self.ignore_files and self._is_ignored(rel_path, memo, ignores)
+            for index, user in by_path[rel_path]:
+                results[index] = self._evaluate(rel_path, chain, user, memo)[0]
+        if self.metrics is not None:
+            self.metrics.on_batch(time.perf_counter() - start, len(by_path))
+        return results
+
+    def walk(
+        self,
+        user: str,
+        prune_no_access: bool = False,
+        cancel: Optional[Cancellation] = None,
+        skip_invalid_files: bool = False,
+        max_depth: Optional[int] = None,
+        on_depth_limit: Optional[Callable[[str], None]] = None,
+    ) -> Iterator[Tuple[str, AccessLevel]]:
+        """
+        Walk the datasite and yield every file with the user's access level.
+
+        Files are produced lazily, directory by directory in sorted order, and each
+        permission file is loaded once for the whole walk. Stop early by breaking
+        out of the loop. Hidden entries and the permission files themselves are skipped,
+        and so are ignored paths when ``ignore_files`` is set.
+
+        With ``prune_no_access`` set, a directory is not entered when nothing could
+        grant the user access inside it: no rule above or in it that lists the user
+        can reach into it, and either a terminal file covers it or it contains no
+        permission files of its own. Files in pruned directories are not yielded.
+
+        A malformed permission file aborts the walk unless ``skip_invalid_files`` is
+        set. Then the file is logged as a warning and treated as an empty terminal
+        file, so a broken file can only take access away: everything beneath it gets
+        ``default_access``.
+
+        ``max_depth`` bounds the cost of walking an unexpectedly deep tree: directories
+        below that level are never entered, whatever their permissions, and are passed
+        to ``on_depth_limit`` instead.
+
+        Args:
+            user: User ID to resolve for
+            prune_no_access: Skip directories where the user can't have any access
+            cancel: Optional Cancellation checked at every directory
+            skip_invalid_files: Log and skip malformed permission files instead of raising
+            max_depth: Deepest directory level entered, the root being 0, or None for no
+                limit. With 1, files in the root and its subdirectories are yielded.
+            on_depth_limit: Called with the datasite-relative path of every directory
+                left out because of ``max_depth``
+
+        Yields:
+            tuple: (datasite-relative posix path, AccessLevel)
+
+        Raises:
+            ResolutionCancelled: If ``cancel`` aborts the walk
+            ValueError: If a permission file is malformed and ``skip_invalid_files``
+                isn't set
+        """
+        loaded: Dict[str, Optional[PermissionFile]] = {}
+        ignores: Dict[str, List[Rule]] = {}
+        for rel_dir, dirnames, filenames in self._walk(""):
+            if cancel is not None:
+                cancel.check()
+            dirnames[:] = sorted(name for name in dirnames if not name.startswith("."))
+            self._prune_ignored(rel_dir, dirnames, ignores)
+            depth = rel_dir.count("/") + 1 if rel_dir else 0
+            if max_depth is not None and depth >= max_depth:
+                if on_depth_limit is not None:
+                    for name in dirnames:
+                        on_depth_limit(posixpath.join(rel_dir, name))
+                dirnames[:] = []
+            if prune_no_access:
+                dirnames[:] = [
+                    name
+                    for name in dirnames
+                    if not self._no_access_below(
+                        posixpath.join(rel_dir, name), user, loaded, cancel, skip_invalid_files
+                    )
+                ]
+
+            chain = self._dir_chain(rel_dir, loaded, cancel, skip_invalid_files)
+            for name in sorted(filenames):
+                if name.startswith(".") or name == PERMISSION_FILE_NAME:
+                    continue
+                rel_path = posixpath.join(rel_dir, name)
+                memo = _MatchMemo(is_dir=False, kind_known=True)
+                memo.ignored = self.ignore_files and self._is_ignored(rel_path, memo, ignores)
+                if not memo.ignored:
+                    yield rel_path, self._evaluate(rel_path, chain, user, memo)[0]
+
+    def rule_coverage(self, cancel: Optional[Cancellation] = None) -> List[RuleStat]:
+        """
+        Count the files of the datasite each rule matches, to spot overly broad rules.
+
+        The tree is walked once, visiting the files walk would yield. A rule's total
+        counts every file its pattern, depth range and directory-only flag match, even
+        where a nearer file, a terminal or a more specific rule decides instead, or the
+        rule is outside its validity window; its first matches count the files it
+        decides. Rules with a ``{user}`` pattern match one user at a time and count
+        nothing. Rules matching no file are included.
+
+        Args:
+            cancel: Optional Cancellation checked at every directory
+
+        Returns:
+            list: One RuleStat per rule of every permission file found, broadest first:
+                by total matches, then first matches, both descending
+
+        Raises:
+            ResolutionCancelled: If ``cancel`` aborts the walk
+        """
+        loaded: Dict[str, Optional[PermissionFile]] = {}
+        ignores: Dict[str, List[Rule]] = {}
+        stats: Dict[Tuple[str, int], RuleStat] = {}
+        for rel_dir, dirnames, filenames in self._walk(""):
+            if cancel is not None:
+                cancel.check()
+            dirnames[:] = sorted(name for name in dirnames if not name.startswith("."))
+            self._prune_ignored(rel_dir, dirnames, ignores)
+            chain = self._dir_chain(rel_dir, loaded, cancel)
+            for directory, perm_file in chain:
+                for index, rule in enumerate(perm_file.rules):
+                    if (directory, index) not in stats:
+                        stats[directory, index] = RuleStat(directory, index, rule, perm_file.path)
+            for name in filenames:
+                if name.startswith(".") or name == PERMISSION_FILE_NAME:
+                    continue
+                rel_path = posixpath.join(rel_dir, name)
+                memo = _MatchMemo(is_dir=False, kind_known=True)
+                memo.ignored = self.ignore_files and self._is_ignored(rel_path, memo, ignores)
+                if memo.ignored:
+                    continue
+                _, trace = self._evaluate(rel_path, chain, "", memo)
+                decisive = (m for m in trace if m.applied and m.reason is not TraceReason.REVOKED)
+                first = next(decisive, None)
+                if first is not None:
+                    stats[first.directory, first.rule_index].first_matches += 1
+                for directory, perm_file in chain:
+                    for index, rule in enumerate(perm_file.rules):
+                        rule_path = self._rule_path(rule, rel_path, directory)
+                        if self._matches(rule, rule_path, rel_path, memo, ""):
+                            stats[directory, index].total_matches += 1
+        return sorted(
+            stats.values(),
+            key=lambda s: (-s.total_matches, -s.first_matches, s.directory, s.rule_index),
+        )
+
+    def ruleset_for(
+        self, path: Union[str, Path], cancel: Optional[Cancellation] = None
+    ) -> EffectiveRuleset:
+        """
+        Get the merged rules of every permission file from the root down to a path.
+
+        Args:
+            path: Path relative to the datasite root, or an absolute path inside it
+            cancel: Optional Cancellation checked at every directory
+
+        Returns:
+            EffectiveRuleset: See merge_rule_chain
+
+        Raises:
+            ResolutionCancelled: If ``cancel`` aborts the resolution
+        """
+        chain = self._chain(self._relative(path), cancel=cancel)
+        return merge_rule_chain(perm_file for _, perm_file in chain)
+
+    def _evaluate(
+        self,
+        rel_path: str,
+        chain: List[Tuple[str, PermissionFile]],
+        user: str,
+        memo: Optional[_MatchMemo] = None,
+    ) -> Tuple[AccessLevel, Trace]:
+        """
+        Resolve a datasite-relative path against its already loaded permission chain.
+
+        Pass the same ``memo`` when evaluating one path for several users to check each
+        rule against the path only once.
+        """
+        verbs, trace = self._evaluate_verbs(rel_path, chain, user, memo)
+        return level_for_verbs(verbs), trace
+
+    def _evaluate_verbs(
+        self,
+        rel_path: str,
+        chain: List[Tuple[str, PermissionFile]],
+        user: str,
+        memo: Optional[_MatchMemo] = None,
+    ) -> Tuple[Verb, Trace]:
+        """Resolve the verbs a user holds on a path, like _evaluate does their level."""
+        if memo is None:
+            memo = _MatchMemo()
+        if memo.now is None:
+            memo.now = self.clock()
+        now = memo.now
+        if memo.ignored is None:
+            memo.ignored = self.ignore_files and self._is_ignored(rel_path, memo)
+        if memo.ignored:
+            # Ignored paths are outside the datasite as far as the rules are concerned
+            trace = Trace()
+            for directory, perm_file in reversed(chain):
+                trace.extend(self._skipped(directory, perm_file, TraceReason.IGNORED))
+            return Verb(0), trace
+        if not memo.terminal_known:
+            # The terminal file nearest the root overrides everything below it
+            memo.terminal_dir = next(
+                (d for d, f in chain if self._is_terminal_for(f, rel_path, d, memo, user)), None
+            )
+            # A terminal {user} rule makes the answer differ from one user to the next
+            memo.terminal_known = not any(
+                rule.terminal and rule.has_user_placeholder for _, f in chain for rule in f.rules
+            )
+        terminal_dir = memo.terminal_dir
+
+        # Under MOST_PERMISSIVE a decision doesn't stop later rules from being applied
+        first_match_only = self.strategy is ResolutionStrategy.MOST_SPECIFIC
+        verbs = verbs_for_level(self.default_access)
+        decided = False
+        excluded = False
+        revoked: Optional[AccessLevel] = None
+        trace = Trace()
+        for directory, perm_file in reversed(chain):
+            if terminal_dir is not None and directory != terminal_dir:
+                trace.extend(
+                    self._skipped(directory, perm_file, TraceReason.OVERRIDDEN_BY_TERMINAL)
+                )
+                continue
+            if decided and first_match_only:
+                trace.extend(self._skipped(directory, perm_file, TraceReason.SHADOWED_BY_NEARER))
+                continue
+
+            rule_index = _index_rules(perm_file, self.match_options)
+            candidates = rule_index.candidates(rel_path, self._relative_to(rel_path, directory))
+            for index, rule in rule_index.ordered_rules(perm_file):
+                if not rule.active_at(now):
+                    reason = TraceReason.INACTIVE
+                    trace.append(RuleMatch(directory, index, rule.pattern, False, False, reason))
+                    continue
+                rule_path = self._rule_path(rule, rel_path, directory)
+                key = (directory, index)
+                match_key = key + (user if rule.has_user_placeholder else None,)
+                matched = memo.matched.get(match_key)
+                if matched is None:
+                    matched = index in candidates and self._matches(
+                        rule, rule_path, rel_path, memo, user
+                    )
+                    memo.matched[match_key] = matched
+                if matched and rule.revoke:
+                    cap = rule.revoked_for(user, self.owner, matcher=self.user_matcher)
+                    if cap is not None:
+                        revoked = cap if revoked is None else min(revoked, cap)
+                        left = AccessLevel(cap - 1)
+                        reason = TraceReason.REVOKED
+                        trace.append(
+                            RuleMatch(directory, index, rule.pattern, True, True, reason, left)
+                        )
+                    elif rule.is_revoke_only:
+                        reason = TraceReason.USER_NOT_LISTED
+                        trace.append(RuleMatch(directory, index, rule.pattern, True, False, reason))
+                    if rule.is_revoke_only:
+                        continue
+                if not matched or (decided and first_match_only):
+                    reason = TraceReason.PATTERN_MISMATCH
+                    if matched:
+                        reason = TraceReason.SHADOWED_BY_SPECIFIC
+                    elif not rule.within_depth(rule_path):
+                        reason = TraceReason.OUTSIDE_DEPTH
+                    elif index in candidates and self._pattern_matches(rule, rule_path, user):
+                        reason = TraceReason.NOT_A_DIRECTORY
+                    trace.append(RuleMatch(directory, index, rule.pattern, matched, False, reason))
+                    continue
+                within_limits = memo.within_limits.get(key)
+                if within_limits is None:
+                    within_limits = memo.within_limits[key] = self._within_limits(rule, rel_path)
+                if not within_limits:
+                    reason = TraceReason.LIMIT_EXCEEDED
+                    trace.append(RuleMatch(directory, index, rule.pattern, True, False, reason))
+                    continue
+
+                granted_verbs = rule.verbs_for(user, self.owner, matcher=self.user_matcher)
+                granted = level_for_verbs(granted_verbs)
+                verbs = verbs | granted_verbs if decided else granted_verbs
+                decided = True
+                if rule.is_exclusion:
+                    excluded = True
+                    reason = TraceReason.EXCLUDED
+                elif not granted_verbs:
+                    reason = TraceReason.USER_NOT_LISTED
+                else:
+                    reason = TraceReason.APPLIED
+                trace.append(RuleMatch(directory, index, rule.pattern, True, True, reason, granted))
+
+            if terminal_dir is not None:
+                # No match in a terminal file still blocks inheritance
+                decided = True
+
+        if excluded:
+            verbs = Verb(0)
+        if revoked is not None:
+            verbs &= verbs_for_level(AccessLevel(revoked - 1))
+        return verbs, trace
+
+    def _relative(self, path: Union[str, Path]) -> str:
+        """
+        Convert a path to a normalized datasite-relative posix path.
+
+        Raises:
+            PathEscapesRootError: If an absolute path is not inside the datasite root, the
+                path has ``..`` segments that dot_segments doesn't allow, or
+                resolve_real_path is set and its real location is outside it
+        """
+        path = Path(_normalize_separators(str(path), self.match_options))
+        if path.is_absolute():
+            try:
+                path = path.relative_to(self.root)
+            except ValueError:
+                raise PathEscapesRootError(path, self.root) from None
+        if ".." in path.parts:
+            path = self._clean_dot_segments(path)
+        if self.resolve_real_path:
+            path = self._real_relative(path)
+        return _acl_norm_path(str(path))
+
+    def _clean_dot_segments(self, rel_path: Path) -> Path:
+        """Apply the dot_segments policy to a datasite-relative path containing ``..``."""
+        if self.dot_segments is DotSegments.REJECT:
+            raise PathEscapesRootError(rel_path, self.root, reason="'..' segments are not allowed")
+        cleaned = posixpath.normpath(rel_path.as_posix())
+        if cleaned == ".." or cleaned.startswith("../"):
+            raise PathEscapesRootError(rel_path, self.root)
+        return Path(cleaned)
+
+    def _real_relative(self, rel_path: Path) -> Path:
+        """Resolve symlinks in a datasite-relative path, keeping it inside the root."""
+        real_root = os.path.realpath(self.root)
+        real_path = os.path.realpath(os.path.join(real_root, rel_path))
+        if os.path.commonpath([real_root, real_path]) != real_root:
+            raise PathEscapesRootError(rel_path, self.root, real_path)
+        return Path(os.path.relpath(real_path, real_root))
+
+    @classmethod
+    def _rule_path(cls, rule: Rule, rel_path: str, directory: str) -> str:
+        """The form of a datasite-relative path a rule in ``directory`` is matched against."""
+        return rel_path if rule.is_root_anchored else cls._relative_to(rel_path, directory)
+
+    @staticmethod
+    def _relative_to(rel_path: str, directory: str) -> str:
+        """Make a datasite-relative path relative to a permission file's directory."""
+        # Stripping the directory from the path, rather than prefixing it to the pattern,
+        # keeps glob characters in directory names like "runs[1]" from being interpreted
+        if not directory:
+            return rel_path
+        return rel_path[len(directory) + 1 :]
+
+    def _chain(
+        self,
+        rel_path: str,
+        loaded: Optional[Dict[str, Optional[PermissionFile]]] = None,
+        cancel: Optional[Cancellation] = None,
+    ) -> List[Tuple[str, PermissionFile]]:
+        """
+        Load the permission files from the datasite root down to the path's directory.
+
+        Args:
+            rel_path: Datasite-relative path being resolved
+            loaded: Optional per-directory cache of already loaded files (None = no file)
+            cancel: Optional Cancellation checked before each directory is loaded
+        """
+        return self._dir_chain(posixpath.dirname(rel_path), loaded, cancel)
+
+    def _dir_chain(
+        self,
+        directory: str,
+        loaded: Optional[Dict[str, Optional[PermissionFile]]] = None,
+        cancel: Optional[Cancellation] = None,
+        skip_invalid: bool = False,
+    ) -> List[Tuple[str, PermissionFile]]:
+        """Load the permission files from the datasite root down to and including a directory."""
+        if loaded is None:
+            loaded = {}
+        segments = directory.split("/") if directory else []
+        chain = []
+        for depth in range(len(segments) + 1):
+            current = "/".join(segments[:depth])
+            if current not in loaded:
+                if cancel is not None:
+                    cancel.check()
+                loaded[current] = (
+                    self._load_or_block(current) if skip_invalid else self._load(current)
+                )
+            perm_file = loaded[current]
+            if perm_file is not None:
+                chain.append((current, perm_file))
+        return chain
+
+    def _load(self, directory: str) -> Optional[PermissionFile]:
+        """Get the permission file of one directory, if it has one."""
+        if self.permission_files is not None:
+            return self.permission_files.get(directory)
+        if self.filesystem is not None:
+            rel_path = self._stored_path(posixpath.join(directory, PERMISSION_FILE_NAME))
+            if not self.filesystem.is_file(rel_path):
+                return None
+            return parse_permission_file(
+                self.filesystem.read_text(rel_path),
+                Path(rel_path),
+                self.strict_users,
+                self.max_wildcards,
+                self.variables,
+            )
+        yaml_path = self.root / directory / PERMISSION_FILE_NAME
+        if not yaml_path.is_file():
+            return None
+        return load_permission_file(
+            yaml_path,
+            strict_users=self.strict_users,
+            max_wildcards=self.max_wildcards,
+            variables=self.variables,
+        )
+
+    def _stored_path(self, rel_path: str) -> str:
+        """How ``filesystem`` spells a path, looked up case-insensitively if matching is."""
+        if self.match_options is None or not self.match_options.case_insensitive:
+            return rel_path
+        stored = find_entry(self.filesystem, rel_path, self.match_options)
+        return rel_path if stored is None else stored
+
+    def _load_ignore(self, directory: str) -> List[Rule]:
+        """Get the ignore patterns of one directory, if it has an ignore file."""
+        rel_path = posixpath.join(directory, IGNORE_FILE_NAME)
+        if self.filesystem is not None:
+            rel_path = self._stored_path(rel_path)
+            if not self.filesystem.is_file(rel_path):
+                return []
+            content = self.filesystem.read_text(rel_path)
+            return parse_ignore_file(content, Path(rel_path), self.max_wildcards)
+        ignore_path = self.root / rel_path
+        if not ignore_path.is_file():
+            return []
+        return parse_ignore_file(ignore_path.read_text(), ignore_path, self.max_wildcards)
+
+    def _is_ignored(
+        self, rel_path: str, memo: _MatchMemo, loaded: Optional[Dict[str, List[Rule]]] = None
+    ) -> bool:
+        """
+        Whether an ignore file above a path matches it or one of its directories.
+
+        Each directory of the path is checked first, against the ignore files above it,
+        so nothing inside an ignored directory can be taken back out. Ignore files are
+        read from the root down and the last matching pattern wins, so a nested file can
+        take back what one above it ignores.
+        """
+        if not rel_path:
+            return False
+        if loaded is None:
+            loaded = {}
+        segments = rel_path.split("/")
+        for depth in range(1, len(segments) + 1):
+            current = "/".join(segments[:depth])
+            current_memo = memo if current == rel_path else _MatchMemo(is_dir=True, kind_known=True)
+            ignored = False
+            for above in range(depth):
+                directory = "/".join(segments[:above])
+                if directory not in loaded:
+                    loaded[directory] = self._load_ignore(directory)
+                for rule in loaded[directory]:
+                    rule_path = self._rule_path(rule, current, directory)
+                    if self._matches(rule, rule_path, current, current_memo, ""):
+                        ignored = not rule.is_exclusion
+            if ignored:
+                return True
+        return False
+
+    def _prune_ignored(
+        self, rel_dir: str, dirnames: List[str], loaded: Dict[str, List[Rule]]
+    ) -> None:
+        """Drop the ignored subdirectories of a walked directory, if ignore files are honored."""
+        if not self.ignore_files:
+            return
+        dirnames[:] = [
+            name
+            for name in dirnames
+            if not self._is_ignored(
+                posixpath.join(rel_dir, name), _MatchMemo(is_dir=True, kind_known=True), loaded
+            )
+        ]
+
+    def _load_or_block(self, directory: str) -> Optional[PermissionFile]:
+        """Load a directory's permission file, standing in a rule-less terminal if it's broken."""
+        try:
+            return self._load(directory)
+        except ValueError as e:
+            logger.warning("skipping invalid permission file: %s", e)
+            path = Path(posixpath.join(directory, PERMISSION_FILE_NAME))
+            return PermissionFile(rules=[], terminal=True, path=path)
+
+    def _no_access_below(
+        self,
+        directory: str,
+        user: str,
+        loaded: Dict[str, Optional[PermissionFile]],
+        cancel: Optional[Cancellation] = None,
+        skip_invalid: bool = False,
+    ) -> bool:
+        """Whether a user certainly has no access to anything inside a directory."""
+        if self.default_access > AccessLevel.NONE:
+            return False
+        chain = self._dir_chain(directory, loaded, cancel, skip_invalid)
+        # Only the terminal file nearest the root counts beneath it, nested files included
+        terminal = next(((d, f) for d, f in chain if f.terminal), None)
+        for file_dir, perm_file in [terminal] if terminal else chain:
+            for rule in perm_file.rules:
+                granted = rule.verbs_for(user, self.owner, matcher=self.user_matcher)
+                pattern = self._user_pattern(rule, user)
+                rule_dir = self._rule_path(rule, directory, file_dir)
+                if (
+                    granted
+                    and pattern is not None
+                    and _could_match_below(pattern, rule_dir, self.match_options)
+                ):
+                    return False
+        return terminal is not None or not self._has_nested_permission_files(directory, cancel)
+
+    def _user_key(self, user: str) -> str:
+        """The spelling users are compared and deduplicated in."""
+        return user if self.strict_users else canonical_user(user)
+
+    def _has_nested_permission_files(
+        self, directory: str, cancel: Optional[Cancellation] = None
+    ) -> bool:
+        """Whether any subdirectory of a directory holds a permission file."""
+        if self.permission_files is not None:
+            prefix = directory + "/" if directory else ""
+            return any(d.startswith(prefix) and d != directory for d in self.permission_files)
+        for rel_dir, dirnames, filenames in self._walk(directory):
+            if cancel is not None:
+                cancel.check()
+            dirnames[:] = [name for name in dirnames if not name.startswith(".")]
+            if PERMISSION_FILE_NAME in filenames and rel_dir != directory:
+                return True
+        return False
+
+    def _walk(self, directory: str) -> Iterator[WalkEntry]:
+        """Walk a datasite directory top-down, yielding datasite-relative directories."""
+        if self.filesystem is not None:
+            yield from self.filesystem.walk(directory)
+            return
+        for dirpath, dirnames, filenames in os.walk(self.root / directory):
+            yield _acl_norm_path(os.path.relpath(dirpath, self.root)), dirnames, filenames
+
+    def _is_terminal_for(
+        self, perm_file: PermissionFile, rel_path: str, directory: str, memo: _MatchMemo, user: str
+    ) -> bool:
+        """Whether a permission file stops inheritance for a path, file-wide or by rule."""
+        if perm_file.terminal:
+            return True
+        return any(
+            rule.terminal
+            and rule.active_at(memo.now)
+            and self._matches(
+                rule, self._rule_path(rule, rel_path, directory), rel_path, memo, user
+            )
+            for rule in perm_file.rules
+        )
+
+    def _matches(
+        self, rule: Rule, rule_path: str, rel_path: str, memo: _MatchMemo, user: str
+    ) -> bool:
+        """
+        Match a rule's pattern, depth range and directory-only flag against a path.
+
+        Patterns come from the shared compiled pattern cache. ``rule_path`` is the path
+        as the rule sees it and ``rel_path`` the datasite-relative one; ``user`` fills
+        in any ``{user}`` in the pattern.
+        """
+        if not rule.within_depth(rule_path) or not self._pattern_matches(rule, rule_path, user):
+            return False
+        return self._kind_fits(rule, rel_path, memo)
+
+    def _pattern_matches(self, rule: Rule, rule_path: str, user: str) -> bool:
+        """Match only a rule's pattern and extensions, using the shared compiled pattern cache."""
+        pattern = self._user_pattern(rule, user)
+        if pattern is None:
+            return False
+        matcher = compile_pattern(pattern, self.match_options, self.metrics)
+        if rule.extensions is None:
+            return matcher.match_path(rule_path)
+        case_insensitive = self.match_options is not None and self.match_options.case_insensitive
+        bases = rule.extension_bases(rule_path, case_insensitive)
+        return any(matcher.match_path(base) for base in bases)
+
+    def _user_pattern(self, rule: Rule, user: str) -> Optional[str]:
+        """A rule's match pattern with ``{user}`` filled in, or None if it can't be."""
+        pattern = rule.match_pattern
+        if not rule.has_user_placeholder:
+            return pattern
+        segment = user_path_segment(user, self.strict_users)
+        if segment is None:
+            return None
+        return pattern.replace(USER_PLACEHOLDER, segment)
+
+    def _kind_fits(self, rule: Rule, rel_path: str, memo: _MatchMemo) -> bool:
+        """Whether a path can be matched by a rule as far as directories are concerned."""
+        if not rule.is_directory_only:
+            return True
+        if not memo.kind_known:
+            memo.kind_known = True
+            if self.stat_func is not None:
+                try:
+                    memo.is_dir = stat.S_ISDIR(self._stat(rel_path)[0])
+                except OSError:
+                    # A path that doesn't exist yet could become either
+                    pass
+        return memo.is_dir is not False
+
+    def _skipped(
+        self, directory: str, perm_file: PermissionFile, reason: TraceReason
+    ) -> List[RuleMatch]:
+        """Trace entries for a permission file that was never consulted."""
+        return [
+            RuleMatch(directory, index, rule.pattern, False, False, reason)
+            for index, rule in perm_file.ordered_rules()
+        ]
+
+    def _within_limits(self, rule: Rule, rel_path: str) -> bool:
+        """Check a rule's file limits against the path, statting it if allowed."""
+        limits = rule.limits
+        if not limits:
+            return True
+
+        extensions = rule.allowed_extensions
+        if extensions is not None and PurePosixPath(rel_path).suffix.lower() not in extensions:
+            return False
+        if self.stat_func is None:
+            return True
+
+        try:
+            mode, size = self._stat(rel_path)
+        except OSError:
+            # A path that doesn't exist yet can't break size, dir or symlink limits
+            return True
+        is_symlink = stat.S_ISLNK(mode)
+        if not limits.get("allow_dirs", True) and stat.S_ISDIR(mode):
+            return False
+        if not limits.get("allow_symlinks", True) and is_symlink:
+            return False
+        max_file_size = rule.max_file_size
+        if max_file_size is not None and not is_symlink and size > max_file_size:
+            return False
+        return True
+
+    def _stat(self, rel_path: str) -> Tuple[int, int]:
+        """Stat a datasite-relative path, returning (mode, size)."""
+        if self.filesystem is not None:
+            result = self.filesystem.stat(self._stored_path(rel_path))
+        else:
+            result = self.stat_func(self.root / rel_path)
+        return result.st_mode, result.st_size
@@ -0,0 +1,1792 @@
+"""Typed model and loader for syft.pub.yaml permission files."""
+
+import hashlib
+import itertools
+import json
+import posixpath
+from dataclasses import dataclass, field, replace
+from datetime import datetime, timezone
+from email.utils import parseaddr
+from pathlib import Path
+from typing import Any, Dict, Iterable, Iterator, List, Mapping, Optional, Sequence, Tuple, Union
+
+import yaml
+
+from .errors import PatternSyntaxError, PermissionFileNotFoundError, UnknownAccessLevelError
+from .path_matching import (
+    DEFAULT_MAX_WILDCARDS,
+    _acl_norm_path,
+    _class_end,
+    _expand_braces,
+    _has_hidden,
+    _match_char_class,
+    _rule_precedence_key,
+    _split_negation,
+    _unescape,
+    _validate_pattern,
+    escape_pattern,
+    normalize_pattern,
+)
+from .permissions import (
+    OWNER_PLACEHOLDER,
+    USER_PLACEHOLDER,
+    AccessLevel,
+    UserMatcher,
+    Verb,
+    _user_in,
+    canonical_user,
+    level_for_verbs,
+    parse_access_level,
+    parse_verb,
+    verbs_for_level,
+)
+
+PERMISSION_FILE_NAME = "syft.pub.yaml"
+IGNORE_FILE_NAME = ".syftignore"
+
+
+@dataclass(frozen=True)
+class SourcePosition:
+    """
+    Where a rule is defined in its permission file.
+
+    Attributes:
+        path: The permission file, if the rule was loaded from disk
+        line: 1-based line of the rule's first key
+        column: 1-based column of the rule's first key
+    """
+
+    path: Optional[Path]
+    line: int
+    column: int
+
+    def __str__(self) -> str:
+        location = str(self.path) if self.path is not None else PERMISSION_FILE_NAME
+        return f"{location}:{self.line}:{self.column}"
+
+
+@dataclass
+class Rule:
+    """
+    A single rule from a syft.pub.yaml file.
+
+    Attributes:
+        pattern: Glob pattern relative to the directory holding the permission file.
+            A leading ``!`` marks the rule as an exclusion. A leading ``/`` (after any
+            ``!``) anchors it to the datasite root instead: ``/shared/**`` is matched
+            against the whole datasite-relative path. A trailing ``/`` limits the rule
+            to directories: ``data/**/`` matches the directories below ``data`` but
+            none of its files, while ``data/**`` matches both. Each ``{user}`` stands
+            for the requesting user's directory name (see user_path_segment), filled in
+            at resolution time, so ``{user}/**`` gives every user their own folder.
+        access: Users granted each access level by this rule
+        limits: Optional file limits (max_file_size, allowed_extensions, allow_dirs,
+            allow_symlinks)
+        terminal: When this rule matches a path, its file is treated as terminal for
+            that path: permission files above and below it are not consulted.
+        priority: Rules with a higher priority are tried before any rule with a lower
+            one, ahead of pattern specificity (see ``PermissionFile.ordered_rules``)
+        min_depth: Fewest ``/``-separated segments a path relative to the rule's
+            directory (or the datasite root, for a root-anchored pattern) may have for
+            the rule to match, or None for no minimum.
+            ``a.txt`` has depth 1 and ``docs/a.txt`` depth 2.
+        max_depth: Most segments such a path may have, or None for no maximum
+        revoke: Users whose access on matching paths is capped below each level,
+            whatever other rules grant them. Revoking ``write`` leaves at most
+            ``create``. A rule with only revokes never decides a path by itself.
+        not_before: The rule is ignored before this timezone-aware instant, or None
+        not_after: The rule is ignored from this instant on, or None. A rule outside
+            its window is skipped as if it weren't in the file at all.
+        verbs: Users granted single verbs on top of their access level, for grants
+            like "read and create but not delete" that no level expresses (see Verb)
+        extensions: File extensions, without the dot, the rule is limited to, or None
+            for any. A path matches when it ends in one of them and the pattern matches
+            what's left without it, so ``images/**`` with ``[jpg, png]`` matches
+            ``images/sub/a.jpg`` and ``docs/report`` with ``[pdf]`` matches
+            ``docs/report.pdf``. Extensions follow the match options' case handling and
+            don't make the pattern any more specific.
+        position: Where the rule was read from, or None for rules built in code. Not
+            part of equality, so the same rule loaded from elsewhere compares equal.
+        head_comment: Comment lines directly above the rule in its file, without the
+            ``#`` markers and joined with newlines, or None. Written back by
+            ``PermissionFile.to_yaml``; like position, not part of equality.
+        line_comment: Comment at the end of the rule's first line, or None
+    """
+
+    pattern: str
+    access: Dict[AccessLevel, List[str]] = field(default_factory=dict)
+    limits: Dict[str, Any] = field(default_factory=dict)
+    terminal: bool = False
+    priority: int = 0
+    min_depth: Optional[int] = None
+    max_depth: Optional[int] = None
+    revoke: Dict[AccessLevel, List[str]] = field(default_factory=dict)
+    not_before: Optional[datetime] = None
+    not_after: Optional[datetime] = None
+    verbs: Dict[Verb, List[str]] = field(default_factory=dict)
+    extensions: Optional[List[str]] = None
+    position: Optional[SourcePosition] = field(default=None, compare=False)
+    head_comment: Optional[str] = field(default=None, compare=False)
+    line_comment: Optional[str] = field(default=None, compare=False)
+
+    @property
+    def is_exclusion(self) -> bool:
+        """Whether this rule carves paths out instead of granting access."""
+        return _split_negation(self.pattern)[0]
+
+    @property
+    def match_pattern(self) -> str:
+        """The pattern to match paths against, without any ``!`` marker."""
+        return _split_negation(self.pattern)[1]
+
+    @property
+    def is_root_anchored(self) -> bool:
+        """Whether the pattern is matched from the datasite root, not the file's directory."""
+        return self.match_pattern.startswith("/")
+
+    @property
+    def is_directory_only(self) -> bool:
+        """Whether a trailing ``/`` limits the rule to matching directories."""
+        return self.match_pattern.endswith("/")
+
+    @property
+    def has_user_placeholder(self) -> bool:
+        """Whether the pattern depends on the requesting user through ``{user}``."""
+        return USER_PLACEHOLDER in self.pattern
+
+    def extension_bases(self, rule_path: str, case_insensitive: bool = False) -> List[str]:
+        """
+        Get what's left of a path without each of the rule's extensions it ends in.
+
+        These are the paths the pattern is matched against for a rule with
+        ``extensions``. A name that is nothing but the extension, like ``.jpg``, has no
+        base.
+
+        Args:
+            rule_path: Path relative to the rule's directory
+            case_insensitive: Compare extensions case-folded
+
+        Returns:
+            list: Bases, longest first, or ``[rule_path]`` if the rule has no extensions
+        """
+        if self.extensions is None:
+            return [rule_path]
+        stripped = rule_path.rstrip("/")
+        name = posixpath.basename(stripped)
+        compared = name.lower() if case_insensitive else name
+        bases = []
+        for extension in self.extensions:
+            suffix = "." + (extension.lower() if case_insensitive else extension)
+            if len(compared) > len(suffix) and compared.endswith(suffix):
+                end = len(stripped) - len(suffix)
+                bases.append(rule_path[:end] + rule_path[len(stripped) :])
+        return sorted(set(bases), key=len, reverse=True)
+
+    @property
+    def is_revoke_only(self) -> bool:
+        """Whether this rule only takes access away and grants none."""
+        return bool(self.revoke) and not self.access and not self.verbs
+
+    def to_dict(self) -> Dict[str, Any]:
+        """
+        Serialize to the canonical rule mapping.
+
+        Keys are always ``pattern``, ``terminal``, ``access`` and ``limits`` in that
+        order, with ``extensions`` after ``pattern`` when set, ``priority`` after
+        ``terminal`` only when it is non-zero, ``min_depth``/``max_depth`` and then
+        ``not_before``/``not_after`` (as ISO 8601 strings) after that, and ``verbs``
+        then ``revoke`` after ``access``, each only when set. Access levels are keyed by
+        name from admin down to read, and verbs from read up to admin.
+        """
+        data: Dict[str, Any] = {"pattern": self.pattern}
+        if self.extensions is not None:
+            data["extensions"] = list(self.extensions)
+        data["terminal"] = self.terminal
+        if self.priority:
+            data["priority"] = self.priority
+        if self.min_depth is not None:
+            data["min_depth"] = self.min_depth
+        if self.max_depth is not None:
+            data["max_depth"] = self.max_depth
+        if self.not_before is not None:
+            data["not_before"] = self.not_before.isoformat()
+        if self.not_after is not None:
+            data["not_after"] = self.not_after.isoformat()
+        data["access"] = {str(level): list(self.access[level]) for level in _levels(self.access)}
+        if self.verbs:
+            data["verbs"] = {
+                str(verb): list(self.verbs[verb]) for verb in Verb if verb in self.verbs
+            }
+        if self.revoke:
+            data["revoke"] = {
+                str(level): list(self.revoke[level]) for level in _levels(self.revoke)
+            }
+        data["limits"] = dict(self.limits)
+        return data
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> "Rule":
+        """
+        Build a rule from a mapping, validating it like a rule read from yaml.
+
+        Raises:
+            ValueError: If the mapping is not a valid rule
+        """
+        rule = _parse_rule(data, PERMISSION_FILE_NAME, 0)
+        _check_patterns([rule], PERMISSION_FILE_NAME)
+        return rule
+
+    @classmethod
+    def for_path(
+        cls,
+        path: str,
+        access: Optional[Dict[AccessLevel, List[str]]] = None,
+        limits: Optional[Dict[str, Any]] = None,
+        terminal: bool = False,
+    ) -> "Rule":
+        """
+        Build a rule matching exactly one path, even if its name contains glob characters.
+
+        Args:
+            path: Path relative to the permission file's directory
+            access: Users granted each access level
+            limits: Optional file limits
+            terminal: Whether the rule is terminal for the path
+
+        Returns:
+            Rule: Rule whose pattern is the escaped path
+        """
+        return cls(escape_pattern(_acl_norm_path(path)), access or {}, limits or {}, terminal)
+
+    @property
+    def max_file_size(self) -> Optional[int]:
+        """Largest file size in bytes this rule applies to, or None for no limit."""
+        return self.limits.get("max_file_size")
+
+    @property
+    def allowed_extensions(self) -> Optional[List[str]]:
+        """
+        File extensions this rule applies to, lowercased with a leading dot.
+
+        None means any extension. Files without an extension only match ``""``.
+        """
+        extensions = self.limits.get("allowed_extensions")
+        if extensions is None:
+            return None
+        return [_normalize_extension(extension) for extension in extensions]
+
+    def active_at(self, now: datetime) -> bool:
+        """
+        Check an instant against the rule's validity window.
+
+        Args:
+            now: Timezone-aware time to check
+
+        Returns:
+            bool: True if the rule applies at ``now``
+        """
+        if self.not_before is not None and now < self.not_before:
+            return False
+        return self.not_after is None or now < self.not_after
+
+    def within_depth(self, rule_path: str) -> bool:
+        """
+        Check a path against the rule's depth range.
+
+        Args:
+            rule_path: Path relative to the rule's directory
+
+        Returns:
+            bool: True if the path has between min_depth and max_depth segments
+        """
+        if self.min_depth is None and self.max_depth is None:
+            return True
+        depth = len(_acl_norm_path(rule_path).split("/"))
+        if self.min_depth is not None and depth < self.min_depth:
+            return False
+        return self.max_depth is None or depth <= self.max_depth
+
+    def users_for(self, level: AccessLevel) -> List[str]:
+        """Get the users listed directly under an access level."""
+        return self.access.get(level, [])
+
+    def verb_lists(self) -> List[Tuple[Verb, List[str]]]:
+        """Every allow list of the rule with the verbs it grants, access levels first."""
+        granted = [(verbs_for_level(level), users) for level, users in self.access.items()]
+        return granted + list(self.verbs.items())
+
+    def verbs_for(
+        self,
+        user: str,
+        owner: Optional[str] = None,
+        strict: bool = False,
+        matcher: Optional[UserMatcher] = None,
+    ) -> Verb:
+        """
+        Get the verbs this rule grants a user: their level's bundle and any listed verbs.
+
+        Args:
+            user: User ID to look up
+            owner: Datasite owner that ``{owner}`` entries stand for, if known
+            strict: Compare emails exactly instead of ignoring case
+            matcher: Compare entries with the user through this instead (see level_for)
+
+        Returns:
+            Verb: The verbs granted, ``Verb(0)`` if none
+        """
+        if self.is_exclusion:
+            return Verb(0)
+        granted = Verb(0)
+        for level in sorted(self.access, reverse=True):
+            if _user_in(self.access[level], user, owner, strict, matcher):
+                granted = verbs_for_level(level)
+                break
+        for verb, users in self.verbs.items():
+            if verb not in granted and _user_in(users, user, owner, strict, matcher):
+                granted |= verb
+        return granted
+
+    def level_for(
+        self,
+        user: str,
+        owner: Optional[str] = None,
+        strict: bool = False,
+        matcher: Optional[UserMatcher] = None,
+    ) -> AccessLevel:
+        """
+        Get the highest access level this rule grants a user.
+
+        Verbs count toward the level whose whole bundle they complete (see
+        level_for_verbs), so read plus a create verb is CREATE.
+
+        Args:
+            user: User ID to look up
+            owner: Datasite owner that ``{owner}`` entries stand for, if known
+            strict: Compare emails exactly instead of ignoring case
+            matcher: Compare entries with the user through this instead; ``strict`` is
+                then unused
+
+        Returns:
+            AccessLevel: Highest level the user's granted verbs cover, or NONE
+        """
+        return level_for_verbs(self.verbs_for(user, owner, strict, matcher))
+
+    def revoked_for(
+        self,
+        user: str,
+        owner: Optional[str] = None,
+        strict: bool = False,
+        matcher: Optional[UserMatcher] = None,
+    ) -> Optional[AccessLevel]:
+        """
+        Get the lowest access level this rule revokes from a user.
+
+        Args:
+            user: User ID to look up
+            owner: Datasite owner that ``{owner}`` entries stand for, if known
+            strict: Compare emails exactly instead of ignoring case
+            matcher: Compare entries with the user through this instead (see level_for)
+
+        Returns:
+            AccessLevel: Lowest revoked level with an entry covering the user, or None
+        """
+        for level in sorted(self.revoke):
+            if _user_in(self.revoke[level], user, owner, strict, matcher):
+                return level
+        return None
+
+
+@dataclass
+class PermissionFile:
+    """
+    A parsed syft.pub.yaml file.
+
+    Attributes:
+        rules: Rules in declaration order
+        terminal: Whether this file stops inheritance from parent directories
+        path: Location the file was loaded from, if any
+    """
+
+    rules: List[Rule] = field(default_factory=list)
+    terminal: bool = False
+    path: Optional[Path] = None
+
+    @property
+    def directory(self) -> Optional[Path]:
+        """Directory the rule patterns are relative to."""
+        return self.path.parent if self.path is not None else None
+
+    def upsert_rule(self, rule: Rule) -> Optional[Rule]:
+        """
+        Add a rule, or replace the rule with the same pattern where it stands.
+
+        Patterns are compared after normalize_pattern, ``!`` marker included, so
+        re-running a config generator updates its rules instead of piling up copies.
+        Rules limited to different extensions are different rules. Later rules with
+        the pattern, which could never apply, are dropped. The new rule takes over the
+        comments of the one it replaces if it has none of its own.
+        Appending to ``rules`` directly still adds a rule unconditionally.
+
+        Args:
+            rule: Rule to add or to replace its namesake with
+
+        Returns:
+            Rule: The rule replaced, or None if the rule was appended
+        """
+        key = _match_key(rule)
+        same = [index for index, existing in enumerate(self.rules) if _match_key(existing) == key]
+        if not same:
+            self.rules.append(rule)
+            return None
+        replaced = self.rules[same[0]]
+        if rule.head_comment is None and rule.line_comment is None:
+            rule = replace(
+                rule, head_comment=replaced.head_comment, line_comment=replaced.line_comment
+            )
+        self.rules[same[0]] = rule
+        for index in reversed(same[1:]):
+            del self.rules[index]
+        return replaced
+
+    def to_dict(self) -> Dict[str, Any]:
+        """
+        Serialize to a plain mapping with a stable shape.
+
+        The shape is ``{"terminal": bool, "rules": [rule, ...]}`` with each rule as in
+        ``Rule.to_dict``. The file's location is not part of the content and is left out.
+        """
+        return {"terminal": self.terminal, "rules": [rule.to_dict() for rule in self.rules]}
+
+    @classmethod
+    def from_dict(cls, data: Any, path: Optional[Path] = None) -> "PermissionFile":
+        """
+        Build a permission file from a mapping, validating it like parsed yaml.
+
+        Raises:
+            ValueError: If the mapping is not a valid permission file
+            PatternSyntaxError: If any rule pattern is malformed
+        """
+        return _build_permission_file(data, path)
+
+    def to_json(self, indent: Optional[int] = None) -> str:
+        """Serialize to json in the shape documented on ``to_dict``."""
+        return json.dumps(self.to_dict(), indent=indent)
+
+    @classmethod
+    def from_json(cls, content: str, path: Optional[Path] = None) -> "PermissionFile":
+        """
+        Load a permission file from json produced by ``to_json``.
+
+        Raises:
+            ValueError: If the json is malformed or describes an invalid permission file
+        """
+        try:
+            data = json.loads(content)
+        except json.JSONDecodeError as e:
+            source = str(path) if path is not None else PERMISSION_FILE_NAME
+            raise ValueError(f"{source}: invalid json: {e}") from None
+        return _build_permission_file(data, path)
+
+    def to_yaml(self) -> str:
+        """
+        Write the model out in canonical syft.pub.yaml form.
+
+        ``terminal: true`` comes first when the file is terminal, then the rules. Each
+        rule's keys are written in this order, each only when set: ``pattern``,
+        ``extensions``, ``terminal``, ``priority`` (when non-zero), ``min_depth``,
+        ``max_depth``, ``not_before`` and ``not_after`` (as ISO 8601 strings),
+        ``access`` from admin down to read, ``verbs`` from read up to admin, ``revoke``
+        and ``limits``. A rule's head comment goes on the lines above it and its line
+        comment after its first line.
+
+        Returns:
+            str: The yaml text; parsing it and writing it again gives the same bytes
+        """
+        text = "terminal: true\n" if self.terminal else ""
+        if not self.rules:
+            return text + "rules: []\n"
+        text += "rules:\n"
+        for rule in self.rules:
+            raw = rule.to_dict()
+            for key in ("terminal", "access", "limits"):
+                if not raw[key]:
+                    del raw[key]
+            if rule.head_comment is not None:
+                for line in rule.head_comment.split("\n"):
+                    text += _comment_line(line) + "\n"
+            # A line comment must follow the whole pattern, so long ones aren't wrapped
+            width = float("inf") if rule.line_comment is not None else None
+            item = yaml.safe_dump(
+                [raw], default_flow_style=False, sort_keys=False, indent=2, width=width
+            )
+            if rule.line_comment is not None:
+                first, rest = item.split("\n", 1)
+                item = f"{first}  {_comment_line(rule.line_comment)}\n{rest}"
+            text += item
+        return text
+
+    def ordered_rules(self) -> List[Tuple[int, Rule]]:
+        """
+        Rules with their declaration index, in the order they are tried.
+
+        Highest priority first; within a priority the most specific pattern first, then
+        exclusions before inclusions, then declaration order.
+        """
+        indexed = enumerate(self.rules)
+        return sorted(
+            indexed,
+            key=lambda item: _rule_precedence_key(item[1].pattern, item[0], item[1].priority),
+        )
+
+    def fingerprint(self) -> str:
+        """
+        A stable hash of the file's rules, for telling whether two files say the same thing.
+
+        The hash covers the terminal flag and each rule's normalized pattern, access and
+        revoke levels with their user lists sorted, terminal flag, priority, depth range,
+        validity window and limits. The file's location and rule positions are left out.
+
+        Rules are hashed in the order they are tried (``ordered_rules``), not the order
+        they are declared in. Reordering rules whose precedence already differs keeps the
+        fingerprint; swapping two rules with the same precedence changes it, because
+        declaration order is what decides between them.
+
+        Returns:
+            str: Hex SHA-256 digest, the same across runs and processes
+        """
+        rules = [_fingerprint_rule(rule) for _, rule in self.ordered_rules()]
+        return _digest({"terminal": self.terminal, "rules": rules})
+
+    def validate(
+        self, directory: str = "", ancestors: Sequence[Tuple[str, "PermissionFile"]] = ()
+    ) -> List[Union["InvalidUser", "DuplicateUser", "RuleConflict", "UnreachableRule"]]:
+        """
+        Find malformed users, duplicate listings, conflicting rules and unreachable rules.
+
+        Args:
+            directory: Datasite-relative directory of this file
+            ancestors: Permission files above this one as (datasite-relative
+                directory, file) pairs, ordered from the root down
+
+        Returns:
+            list: The InvalidUser, DuplicateUser, RuleConflict and UnreachableRule
+                findings, in that order
+        """
+        return (
+            self._invalid_users()
+            + self._duplicate_users()
+            + self._conflicts()
+            + self._unreachable_rules(directory, ancestors)
+        )
+
+    def _invalid_users(self) -> List["InvalidUser"]:
+        """Entries that are neither ``*``, a ``*@domain`` wildcard, a placeholder nor an email."""
+        return [
+            InvalidUser(index, rule, user)
+            for index, rule in enumerate(self.rules)
+            for user in dict.fromkeys(_entries(rule) + _revoke_entries(rule))
+            if not _is_user_pattern(user) and not _is_email(user)
+        ]
+
+    def _duplicate_users(self) -> List["DuplicateUser"]:
+        """Users listed under several levels of one rule, which only grants the highest."""
+        duplicates = []
+        for index, rule in enumerate(self.rules):
+            listed: Dict[str, List[AccessLevel]] = {}
+            for level in sorted(rule.access):
+                for user in rule.access[level]:
+                    listed.setdefault(user, []).append(level)
+            for user, levels in listed.items():
+                if len(levels) > 1:
+                    duplicates.append(DuplicateUser(index, rule, user, tuple(levels)))
+        return duplicates
+
+    def _conflicts(self) -> List["RuleConflict"]:
+        """Non-exclusion rule pairs whose patterns overlap and give a user different levels."""
+        rank = {index: position for position, (index, _) in enumerate(self.ordered_rules())}
+        conflicts = []
+        for first, second in itertools.combinations(range(len(self.rules)), 2):
+            a, b = self.rules[first], self.rules[second]
+            if a.is_exclusion or b.is_exclusion or _disjoint_extensions(a, b):
+                continue
+            overlap = _pattern_overlap(
+                normalize_pattern(a.match_pattern), normalize_pattern(b.match_pattern)
+            )
+            if overlap is None:
+                continue
+            applies = first if rank[first] < rank[second] else second
+            for user in dict.fromkeys(_entries(a) + _entries(b)):
+                # Compare {owner} entries with each other as if the placeholder were a user
+                levels = (
+                    a.level_for(user, OWNER_PLACEHOLDER),
+                    b.level_for(user, OWNER_PLACEHOLDER),
+                )
+                if AccessLevel.NONE in levels or levels[0] == levels[1]:
+                    continue
+                conflicts.append(RuleConflict(first, second, user, *levels, overlap, applies))
+        return conflicts
+
+    def _unreachable_rules(
+        self, directory: str, ancestors: Sequence[Tuple[str, "PermissionFile"]]
+    ) -> List["UnreachableRule"]:
+        """Rules that a terminal rule tried first, here or in an ancestor, always shadows."""
+        ordered = self.ordered_rules()
+        unreachable = []
+        for position, (index, rule) in enumerate(ordered):
+            for ancestor_dir, ancestor in ancestors:
+                if ancestor.terminal:
+                    unreachable.append(UnreachableRule(index, rule, None, None, ancestor_dir))
+                    break
+                shadow = _terminal_shadow(ancestor.ordered_rules(), ancestor_dir, rule, directory)
+                if shadow is not None:
+                    unreachable.append(UnreachableRule(index, rule, *shadow, ancestor_dir))
+                    break
+            else:
+                if rule.revoke:
+                    continue
+                earlier = ordered[:position]
+                shadow = _terminal_shadow(earlier, directory, rule, directory, same_file=True)
+                if shadow is not None:
+                    unreachable.append(UnreachableRule(index, rule, *shadow, None))
+        return unreachable
+
+    def minimize(self, directory: str = "") -> "PermissionFile":
+        """
+        Get an equivalent file without the rules that provably never change a level.
+
+        A rule is dropped when another rule always decides the paths it matches in its
+        place: one whose pattern is identical or covers the rule's segment by segment,
+        that has no file limits, depth range or validity window, isn't directory-only
+        unless the rule is, and grants each allow-list entry of the rule at least the
+        same verbs. That rule must either be tried first, or be tried right after it and
+        grant exactly the same. Exclusions are only covered by exclusions, though an
+        exclusion tried first covers any rule.
+
+        Rules that revoke are always kept, and so is a terminal rule unless a terminal
+        one covers it, as are patterns naming dotfiles or using ``{user}`` or
+        ``extensions``, unless identical. When the tests can't settle it the rule stays,
+        so some redundant rules may remain, but every path resolves the same for every
+        user under either strategy.
+
+        Args:
+            directory: Datasite-relative directory of this file, needed to compare
+                root-anchored patterns with the others
+
+        Returns:
+            PermissionFile: A copy holding the remaining rules in declaration order
+        """
+        ordered = [rule for _, rule in self.ordered_rules()]
+        position = 0
+        while position < len(ordered):
+            rule = ordered[position]
+            earlier = ordered[:position]
+            # Only the rule tried right after it is sure to take over its paths
+            following = ordered[position + 1 : position + 2]
+            if any(_covers(cover, rule, directory, True) for cover in earlier) or any(
+                _covers(cover, rule, directory, False) for cover in following
+            ):
+                del ordered[position]
+            else:
+                position += 1
+        kept = {id(rule) for rule in ordered}
+        return replace(self, rules=[rule for rule in self.rules if id(rule) in kept])
+
+
+@dataclass(frozen=True)
+class InvalidUser:
+    """
+    A user entry that is neither a wildcard, a placeholder nor a well-formed email.
+
+    Attributes:
+        index: Declaration index of the rule
+        rule: The rule listing the entry
+        user: The malformed allow- or revoke-list entry
+    """
+
+    index: int
+    rule: Rule
+    user: str
+
+    def __str__(self) -> str:
+        return f"{_describe_rule(self.index, self.rule)} lists {self.user!r}, which is not an email"
+
+
+@dataclass(frozen=True)
+class DuplicateUser:
+    """
+    A user listed under more than one access level of the same rule.
+
+    Attributes:
+        index: Declaration index of the rule
+        rule: The rule listing the user
+        user: The allow-list entry listed more than once
+        levels: Levels it is listed under, lowest first; the last one is granted
+    """
+
+    index: int
+    rule: Rule
+    user: str
+    levels: Tuple[AccessLevel, ...]
+
+    def __str__(self) -> str:
+        names = ", ".join(f"'{level}'" for level in self.levels)
+        return (
+            f"{_describe_rule(self.index, self.rule)} lists {self.user} under {names}; "
+            f"the highest, '{self.levels[-1]}', applies"
+        )
+
+
+@dataclass(frozen=True)
+class RuleConflict:
+    """
+    Two overlapping rules in one file that give a user different access levels.
+
+    Attributes:
+        first_index: Index of the earlier rule in declaration order
+        second_index: Index of the later rule in declaration order
+        user: Allow-list entry the rules disagree on
+        first_level: Level the earlier rule grants the user
+        second_level: Level the later rule grants the user
+        overlap: "identical", "subset" (first inside second) or "superset"
+        applies: Index of the rule that wins where both match
+    """
+
+    first_index: int
+    second_index: int
+    user: str
+    first_level: AccessLevel
+    second_level: AccessLevel
+    overlap: str
+    applies: int
+
+    def __str__(self) -> str:
+        return (
+            f"rules {self.first_index} and {self.second_index} overlap ({self.overlap}) and "
+            f"grant {self.user} '{self.first_level}' vs '{self.second_level}'; "
+            f"rule {self.applies} applies where both match"
+        )
+
+
+@dataclass(frozen=True)
+class UnreachableRule:
+    """
+    A rule that can never take effect because a terminal rule always matches first.
+
+    Attributes:
+        index: Declaration index of the unreachable rule in the validated file
+        rule: The unreachable rule
+        shadowed_by: Declaration index of the terminal rule, or None when the whole
+            ancestor file is terminal
+        terminal_rule: The terminal rule, or None when the whole file is terminal
+        ancestor_directory: Datasite-relative directory of the ancestor file holding
+            the terminal rule, or None if it is in the validated file itself
+    """
+
+    index: int
+    rule: Rule
+    shadowed_by: Optional[int]
+    terminal_rule: Optional[Rule]
+    ancestor_directory: Optional[str]
+
+    def __str__(self) -> str:
+        if self.ancestor_directory is None:
+            where = "earlier in this file"
+        else:
+            where = f"in the permission file of {self.ancestor_directory or '/'!r}"
+        if self.terminal_rule is None:
+            cause = f"the permission file of {self.ancestor_directory or '/'!r} is terminal"
+        else:
+            cause = (
+                f"terminal {_describe_rule(self.shadowed_by, self.terminal_rule)} {where} "
+                "matches every path it does"
+            )
+        return f"{_describe_rule(self.index, self.rule)} can never apply: {cause}"
+
+
+def _describe_rule(index: int, rule: Rule) -> str:
+    """Name a rule by index and pattern, with its location when it was loaded from yaml."""
+    location = f" at {rule.position}" if rule.position is not None else ""
+    return f"rule {index} ({rule.pattern!r}){location}"
+
+
+def _terminal_shadow(
+    candidates: Sequence[Tuple[int, Rule]],
+    candidate_dir: str,
+    rule: Rule,
+    directory: str,
+    same_file: bool = False,
+) -> Optional[Tuple[int, Rule]]:
+    """The first candidate terminal rule matching every path ``rule`` can match, if any."""
+    inner = _root_pattern(rule, directory)
+    for index, candidate in candidates:
+        ranged = candidate.min_depth is not None or candidate.max_depth is not None
+        expiring = candidate.not_before is not None or candidate.not_after is not None
+        if not candidate.terminal or ranged or expiring or (same_file and candidate.limits):
+            continue
+        if candidate.is_directory_only and not rule.is_directory_only:
+            continue
+        if candidate.has_user_placeholder or rule.has_user_placeholder:
+            # Which paths these match depends on who asks
+            continue
+        if candidate.extensions is not None or rule.extensions is not None:
+            # The pattern alone doesn't say which paths these match
+            continue
+        if _pattern_subset(inner, _root_pattern(candidate, escape_pattern(candidate_dir))):
+            return index, candidate
+    return None
+
+
+def _covers(cover: Rule, rule: Rule, directory: str, tried_first: bool) -> bool:
+    """Whether ``cover`` decides every path ``rule`` matches, granting as it would."""
+    if rule.revoke or (rule.terminal and not cover.terminal):
+        return False
+    ranged = cover.min_depth is not None or cover.max_depth is not None
+    expiring = cover.not_before is not None or cover.not_after is not None
+    if cover.limits or ranged or expiring or cover.is_revoke_only:
+        return False
+    if cover.is_directory_only and not rule.is_directory_only:
+        return False
+    if not _matches_within(rule, cover, directory):
+        return False
+    if rule.is_exclusion or cover.is_exclusion:
+        return cover.is_exclusion and (tried_first or rule.is_exclusion)
+    if not _grants_within(rule, cover):
+        return False
+    return tried_first or _grants_within(cover, rule)
+
+
+def _matches_within(inner: Rule, outer: Rule, directory: str) -> bool:
+    """Whether every path ``inner`` matches, for any user, is also matched by ``outer``."""
+    same_pattern = normalize_pattern(inner.match_pattern) == normalize_pattern(outer.match_pattern)
+    if same_pattern and _match_key(inner)[1] == _match_key(outer)[1]:
+        return True
+    if inner.has_user_placeholder or outer.has_user_placeholder:
+        return False
+    if inner.extensions is not None or outer.extensions is not None:
+        return False
+    pattern = _root_pattern(inner, directory)
+    if _has_hidden(_unescape(pattern)):
+        # Whether wildcards match dotfiles depends on the match options
+        return False
+    return _pattern_subset(pattern, _root_pattern(outer, escape_pattern(directory)))
+
+
+def _pattern_subset(inner: str, outer: str) -> bool:
+    """
+    Whether every path ``inner`` matches is matched by ``outer``, segment by segment.
+
+    A wildcard of ``inner`` is never mistaken for the literal characters of a name:
+    within a segment, ``*`` is only covered by ``*`` and ``?`` by ``?`` or ``*``.
+    """
+    outers = [alternative.rstrip("/").split("/") for alternative in _expand_braces(outer)]
+    return all(
+        any(_segments_within(alternative.rstrip("/").split("/"), segments) for segments in outers)
+        for alternative in _expand_braces(inner)
+    )
+
+
+def _segments_within(inner: List[str], outer: List[str], leading: bool = True) -> bool:
+    """The segment lists of _pattern_subset; ``leading`` while ``outer`` is still whole."""
+    if not outer:
+        return not inner
+    if outer[0] == "**":
+        if len(outer) == 1 and not leading:
+            # A trailing ** after other segments matches at least one
+            return bool(inner)
+        if _segments_within(inner, outer[1:], False):
+            return True
+        return bool(inner) and _segments_within(inner[1:], outer, leading)
+    if not inner or inner[0] == "**":
+        return False
+    if inner[0] != outer[0] and not _segment_within(inner[0], outer[0]):
+        return False
+    return _segments_within(inner[1:], outer[1:], False)
+
+
+def _segment_within(inner: str, outer: str) -> bool:
+    """Whether every name the segment ``inner`` matches is matched by the segment ``outer``."""
+    inner_tokens, outer_tokens = _segment_tokens(inner), _segment_tokens(outer)
+    if inner_tokens is None or outer_tokens is None:
+        return False
+    seen: Dict[Tuple[int, int], bool] = {}
+
+    def within(i: int, j: int) -> bool:
+        if (i, j) in seen:
+            return seen[(i, j)]
+        if j == len(outer_tokens):
+            result = i == len(inner_tokens)
+        elif outer_tokens[j][0] == "*":
+            result = within(i, j + 1) or (i < len(inner_tokens) and within(i + 1, j))
+        elif i == len(inner_tokens) or inner_tokens[i][0] == "*":
+            # Only an outer * matches the names an inner * does
+            result = False
+        else:
+            (kind, value), (outer_kind, outer_value) = inner_tokens[i], outer_tokens[j]
+            if outer_kind == "?" or (kind, value) == (outer_kind, outer_value):
+                result = within(i + 1, j + 1)
+            elif outer_kind == "[" and kind == "":
+                result = _match_char_class(outer_value, 0, value) and within(i + 1, j + 1)
+            else:
+                result = False
+        seen[(i, j)] = result
+        return result
+
+    return within(0, 0)
+
+
+def _segment_tokens(segment: str) -> Optional[List[Tuple[str, str]]]:
+    """
+    Split a segment into ``("*", "")``, ``("?", "")``, ``("[", class)`` and ``("", char)``.
+
+    Returns:
+        list: The tokens, or None for an unterminated class, a trailing backslash or a
+            ``**`` inside the segment, which the matcher doesn't treat as ``*``
+    """
+    if "**" in segment:
+        return None
+    tokens: List[Tuple[str, str]] = []
+    i = 0
+    while i < len(segment):
+        char = segment[i]
+        if char in "*?":
+            tokens.append((char, ""))
+            i += 1
+        elif char == "[":
+            end = _class_end(segment, i)
+            if end == -1:
+                return None
+            tokens.append(("[", segment[i : end + 1]))
+            i = end + 1
+        elif char == "\\":
+            if i + 1 == len(segment):
+                return None
+            tokens.append(("", segment[i + 1]))
+            i += 2
+        else:
+            tokens.append(("", char))
+            i += 1
+    return tokens
+
+
+def _grants_within(inner: Rule, outer: Rule) -> bool:
+    """Whether ``outer`` grants each allow-list entry of ``inner`` at least the same verbs."""
+    outer_lists = outer.verb_lists()
+    for verbs, users in inner.verb_lists():
+        for user in users:
+            granted = Verb(0)
+            for outer_verbs, outer_users in outer_lists:
+                if user in outer_users:
+                    granted |= outer_verbs
+            if verbs not in granted:
+                return False
+    return True
+
+
+def _match_key(rule: Rule) -> Tuple[str, Optional[Tuple[str, ...]]]:
+    """What makes two rules match the same paths: the normalized pattern and extensions."""
+    extensions = tuple(sorted(set(rule.extensions))) if rule.extensions is not None else None
+    return normalize_pattern(rule.pattern), extensions
+
+
+def _disjoint_extensions(a: Rule, b: Rule) -> bool:
+    """Whether two rules are limited to extensions that have none in common."""
+    if a.extensions is None or b.extensions is None:
+        return False
+    return not {e.lower() for e in a.extensions} & {e.lower() for e in b.extensions}
+
+
+def _root_pattern(rule: Rule, directory: str) -> str:
+    """A rule's pattern, without any ``!``, spelled from the datasite root."""
+    pattern = normalize_pattern(rule.match_pattern)
+    if rule.is_root_anchored:
+        return pattern[1:]
+    return posixpath.join(directory, pattern) if directory else pattern
+
+
+@dataclass
+class EffectiveRule:
+    """
+    A rule in an effective ruleset, annotated with where it came from.
+
+    Attributes:
+        rule: The rule itself; its pattern is relative to the source file's directory
+        source: Path of the permission file holding the rule, if known
+        rule_index: Index of the rule in its file's declaration order
+    """
+
+    rule: Rule
+    source: Optional[Path]
+    rule_index: int
+
+
+@dataclass
+class EffectiveRuleset:
+    """
+    The combined rules of a chain of permission files.
+
+    Attributes:
+        rules: Rules root-first by file, and in the order they are tried within each file
+        terminal_source: The terminal file that truncated the chain, if any
+    """
+
+    rules: List[EffectiveRule] = field(default_factory=list)
+    terminal_source: Optional[Path] = None
+
+    @property
+    def sources(self) -> List[Optional[Path]]:
+        """Permission files contributing rules, root-first and without repeats."""
+        return list(dict.fromkeys(entry.source for entry in self.rules))
+
+    def fingerprint(self, root: Optional[Path] = None) -> str:
+        """
+        A stable hash of the ruleset, for telling whether a path's rules have changed.
+
+        Each rule is hashed as in ``PermissionFile.fingerprint``, in the ruleset's order,
+        together with the file it came from; the terminal source is included too. Rule
+        indexes are left out, so a file reordered without changing precedence keeps its
+        fingerprint.
+
+        Args:
+            root: Directory that sources are made relative to, so the same datasite
+                fingerprints alike wherever it lives. Sources outside it are kept as is.
+
+        Returns:
+            str: Hex SHA-256 digest, the same across runs and processes
+        """
+        rules = [
+            {
+                "source": _fingerprint_source(entry.source, root),
+                "rule": _fingerprint_rule(entry.rule),
+            }
+            for entry in self.rules
+        ]
+        terminal_source = _fingerprint_source(self.terminal_source, root)
+        return _digest({"terminal_source": terminal_source, "rules": rules})
+
+    def __iter__(self) -> Iterator[EffectiveRule]:
+        return iter(self.rules)
+
+    def __len__(self) -> int:
+        return len(self.rules)
+
+
+def _fingerprint_rule(rule: Rule) -> Dict[str, Any]:
+    """The canonical mapping of a rule that fingerprints are computed over."""
+    data = rule.to_dict()
+    data["pattern"] = normalize_pattern(rule.pattern)
+    if rule.extensions is not None:
+        data["extensions"] = sorted(set(rule.extensions))
+    for key in ("access", "verbs", "revoke"):
+        if key in data:
+            data[key] = {level: sorted(set(users)) for level, users in data[key].items()}
+    for key in ("not_before", "not_after"):
+        instant = getattr(rule, key)
+        if instant is not None:
+            data[key] = instant.astimezone(timezone.utc).isoformat()
+    return data
+
+
+def _fingerprint_source(source: Optional[Path], root: Optional[Path]) -> Optional[str]:
+    """A rule's source as a posix path, relative to root when it lies inside it."""
+    if source is None:
+        return None
+    if root is not None:
+        try:
+            return source.relative_to(root).as_posix()
+        except ValueError:
+            pass
+    return source.as_posix()
+
+
+def _digest(data: Any) -> str:
+    """Hex SHA-256 of data serialized as compact json with sorted keys."""
+    content = json.dumps(data, sort_keys=True, separators=(",", ":"))
+    return hashlib.sha256(content.encode("utf-8")).hexdigest()
+
+
+def _entries(rule: Rule) -> List[str]:
+    """Every allow-list entry of a rule, across all levels and verbs."""
+    return [user for _, users in rule.verb_lists() for user in users]
+
+
+def _revoke_entries(rule: Rule) -> List[str]:
+    """Every revoke-list entry of a rule, across all levels."""
+    return [user for users in rule.revoke.values() for user in users]
+
+
+def _is_user_pattern(user: str) -> bool:
+    """Whether an entry stands for users other than by their email: a wildcard or placeholder."""
+    return user == "*" or user.startswith("*@") or (user.startswith("{") and user.endswith("}"))
+
+
+def _is_email(user: str) -> bool:
+    """Whether an entry is structurally a bare email address, ``local@domain``."""
+    local, at, domain = user.partition("@")
+    if not local or not at or not domain or "@" in domain:
+        return False
+    return parseaddr(user) == ("", user) and not any(c.isspace() for c in user)
+
+
+def _pattern_overlap(first: str, second: str) -> Optional[str]:
+    """Classify how two patterns overlap, or None if no clear relationship is found."""
+    if first == second:
+        return "identical"
+    if _pattern_subset(first, second):
+        return "subset"
+    if _pattern_subset(second, first):
+        return "superset"
+    return None
+
+
+def _levels(access: Dict[AccessLevel, List[str]]) -> List[AccessLevel]:
+    """Access levels present in a rule, in canonical order (admin first)."""
+    return sorted(access, reverse=True)
+
+
+def _normalize_extension(extension: str) -> str:
+    """Lowercase an extension and give it a leading dot (``"CSV"`` -> ``".csv"``)."""
+    extension = extension.strip().lower()
+    if extension and not extension.startswith("."):
+        extension = "." + extension
+    return extension
+
+
+def _flow_placeholder(user: Any) -> Any:
+    """Turn a yaml ``{name}`` flow mapping back into the placeholder string it was meant as."""
+    if isinstance(user, dict) and len(user) == 1:
+        name, value = next(iter(user.items()))
+        if isinstance(name, str) and value is None:
+            return "{" + name + "}"
+    return user
+
+
+def _group_reference(user: Any) -> Optional[Any]:
+    """The group name of a ``{group: name}`` entry, or None for any other entry."""
+    if isinstance(user, dict) and list(user) == ["group"]:
+        return user["group"]
+    return None
+
+
+def _interpolate(text: str, variables: Mapping[str, str], context: str) -> str:
+    """Expand the ``${name}`` references in one string; ``$$`` stands for a literal ``$``."""
+    parts = []
+    start = 0
+    while True:
+        dollar = text.find("$", start)
+        if dollar == -1:
+            parts.append(text[start:])
+            return "".join(parts)
+        parts.append(text[start:dollar])
+        if text.startswith("$$", dollar):
+            parts.append("$")
+            start = dollar + 2
+        elif text.startswith("${", dollar):
+            end = text.find("}", dollar + 2)
+            if end == -1:
+                raise ValueError(f"{context}: unterminated variable reference in {text!r}")
+            name = text[dollar + 2 : end]
+            if name not in variables:
+                raise ValueError(f"{context}: undefined variable {name!r} in {text!r}")
+            parts.append(variables[name])
+            start = end + 1
+        else:
+            parts.append("$")
+            start = dollar + 1
+
+
+def _interpolate_value(value: Any, variables: Mapping[str, str], context: str) -> Any:
+    """Expand variables in every string of a decoded yaml value; mapping keys are kept."""
+    if isinstance(value, str):
+        return _interpolate(value, variables, context)
+    if isinstance(value, list):
+        return [_interpolate_value(item, variables, context) for item in value]
+    if isinstance(value, dict):
+        return {key: _interpolate_value(item, variables, context) for key, item in value.items()}
+    return value
+
+
+def _interpolate_rule(raw: Any, variables: Mapping[str, str], source: str, index: int) -> Any:
+    """Expand variables in the pattern and user lists of one rule's yaml mapping."""
+    if not isinstance(raw, dict):
+        return raw
+    pattern = raw.get("pattern")
+    context = f"{source}: rule {index}" + (f" ({pattern!r})" if isinstance(pattern, str) else "")
+    expanded = dict(raw)
+    for key in ("pattern", "extensions", "access", "verbs", "revoke"):
+        if key in raw:
+            expanded[key] = _interpolate_value(raw[key], variables, context)
+    return expanded
+
+
+def _parse_groups(raw: Any, source: str) -> Dict[str, List[str]]:
+    """Validate the top-level ``groups`` mapping of group name to member list."""
+    if raw is None:
+        return {}
+    if not isinstance(raw, dict):
+        raise ValueError(f"{source}: groups must be a mapping")
+    groups = {}
+    for name, members in raw.items():
+        if isinstance(members, str):
+            members = [members]
+        if not isinstance(members, list) or not all(isinstance(m, str) for m in members):
+            raise ValueError(f"{source}: group {name!r} must be a list of strings")
+        groups[name] = members
+    return groups
+
+
+def _parse_users(
+    value: Any,
+    source: str,
+    index: int,
+    level: Union[AccessLevel, Verb],
+    strict_users: bool = False,
+    groups: Optional[Dict[str, List[str]]] = None,
+) -> List[str]:
+    """Normalize the user list of one access level or verb into a list of strings."""
+    if value is None:
+        return []
+    if isinstance(value, str) or _group_reference(value) is not None:
+        value = [value]
+    if isinstance(value, list):
+        entries = []
+        for user in value:
+            group = _group_reference(user)
+            if group is not None:
+                if groups is None or group not in groups:
+                    raise ValueError(f"{source}: rule {index}: unknown group {group!r}")
+                entries.extend(groups[group])
+            elif isinstance(user, list):
+                # An alias inside a flow list, e.g. [*admins, bob], nests the whole list
+                entries.extend(user)
+            else:
+                # Unquoted [{owner}] is yaml for a one-key mapping; read it as the placeholder
+                entries.append(_flow_placeholder(user))
+        value = entries
+    if not isinstance(value, list) or not all(isinstance(user, str) for user in value):
+        raise ValueError(f"{source}: rule {index}: users for '{level}' must be a list of strings")
+    for user in value:
+        if user.startswith("{") and user.endswith("}") and user != OWNER_PLACEHOLDER:
+            raise ValueError(
+                f"{source}: rule {index}: unknown placeholder {user!r} for '{level}'"
+                f" (expected {OWNER_PLACEHOLDER})"
+            )
+    # "public" is accepted as an alias for "*" everywhere else in syft-perm
+    users = ["*" if user == "public" else user for user in value]
+    if strict_users:
+        return users
+    # Entries differing only by case are one user; keep the first spelling's position
+    return list(dict.fromkeys(canonical_user(user) for user in users))
+
+
+def _parse_rule(
+    raw: Any,
+    source: str,
+    index: int,
+    strict_users: bool = False,
+    groups: Optional[Dict[str, List[str]]] = None,
+) -> Rule:
+    """Build a Rule from its yaml mapping, validating access levels."""
+    if not isinstance(raw, dict):
+        raise ValueError(f"{source}: rule {index} must be a mapping")
+
+    pattern = raw.get("pattern")
+    if not isinstance(pattern, str) or not pattern:
+        raise ValueError(f"{source}: rule {index} is missing a pattern")
+
+    access = _parse_levels(raw, "access", source, index, strict_users, groups)
+    revoke = _parse_levels(raw, "revoke", source, index, strict_users, groups)
+    verbs = _parse_verbs(raw, source, index, strict_users, groups)
+
+    limits = raw.get("limits") or {}
+    if not isinstance(limits, dict):
+        raise ValueError(f"{source}: rule {index} ({pattern!r}): limits must be a mapping")
+    max_file_size = limits.get("max_file_size")
+    if max_file_size is not None and (
+        isinstance(max_file_size, bool) or not isinstance(max_file_size, int) or max_file_size < 0
+    ):
+        raise ValueError(
+            f"{source}: rule {index} ({pattern!r}): max_file_size must be a non-negative integer"
+        )
+    extensions = limits.get("allowed_extensions")
+    if extensions is not None and (
+        not isinstance(extensions, list) or not all(isinstance(ext, str) for ext in extensions)
+    ):
+        raise ValueError(
+            f"{source}: rule {index} ({pattern!r}): allowed_extensions must be a list of strings"
+        )
+
+    priority = raw.get("priority", 0)
+    if isinstance(priority, bool) or not isinstance(priority, int):
+        raise ValueError(f"{source}: rule {index} ({pattern!r}): priority must be an integer")
+
+    terminal = raw.get("terminal", False)
+    if not isinstance(terminal, bool):
+        raise ValueError(f"{source}: rule {index} ({pattern!r}): terminal must be a boolean")
+
+    depths = {}
+    for key in ("min_depth", "max_depth"):
+        depth = raw.get(key)
+        if depth is not None and (
+            isinstance(depth, bool) or not isinstance(depth, int) or depth < 1
+        ):
+            raise ValueError(
+                f"{source}: rule {index} ({pattern!r}): {key} must be a positive integer"
+            )
+        depths[key] = depth
+    if None not in depths.values() and depths["min_depth"] > depths["max_depth"]:
+        raise ValueError(
+            f"{source}: rule {index} ({pattern!r}): min_depth is greater than max_depth"
+        )
+
+    window = {key: _parse_timestamp(raw, key, source, index) for key in ("not_before", "not_after")}
+    if None not in window.values() and window["not_before"] >= window["not_after"]:
+        raise ValueError(
+            f"{source}: rule {index} ({pattern!r}): not_before is not earlier than not_after"
+        )
+
+    return Rule(
+        pattern=pattern,
+        access=access,
+        limits=limits,
+        terminal=terminal,
+        priority=priority,
+        revoke=revoke,
+        verbs=verbs,
+        extensions=_parse_extensions(raw, source, index),
+        **depths,
+        **window,
+    )
+
+
+def _parse_extensions(raw: Dict[str, Any], source: str, index: int) -> Optional[List[str]]:
+    """Read a rule's ``extensions`` list, dropping leading dots and repeats."""
+    extensions = raw.get("extensions")
+    if extensions is None:
+        return None
+    if isinstance(extensions, str):
+        extensions = [extensions]
+    if (
+        not isinstance(extensions, list)
+        or not extensions
+        or not all(isinstance(ext, str) and ext.lstrip(".") for ext in extensions)
+        or any("/" in ext for ext in extensions)
+    ):
+        raise ValueError(
+            f"{source}: rule {index} ({raw['pattern']!r}): extensions must be a non-empty "
+            "list of extensions like 'jpg'"
+        )
+    return list(dict.fromkeys(ext[1:] if ext.startswith(".") else ext for ext in extensions))
+
+
+def _parse_timestamp(raw: Dict[str, Any], key: str, source: str, index: int) -> Optional[datetime]:
+    """Read an RFC 3339 timestamp field of a rule, as parsed by yaml or still a string."""
+    value = raw.get(key)
+    if value is None:
+        return None
+    if isinstance(value, str):
+        try:
+            # fromisoformat only learned to read a "Z" suffix in Python 3.11
+            value = datetime.fromisoformat(value.strip().replace("Z", "+00:00"))
+        except ValueError:
+            pass
+    if not isinstance(value, datetime) or value.tzinfo is None:
+        raise ValueError(
+            f"{source}: rule {index} ({raw['pattern']!r}): {key} must be an RFC 3339 "
+            "timestamp with a UTC offset"
+        )
+    return value
+
+
+def _parse_levels(
+    raw: Dict[str, Any],
+    key: str,
+    source: str,
+    index: int,
+    strict_users: bool = False,
+    groups: Optional[Dict[str, List[str]]] = None,
+) -> Dict[AccessLevel, List[str]]:
+    """Parse a rule's ``access`` or ``revoke`` mapping of level names to user lists."""
+    pattern = raw["pattern"]
+    raw_levels = raw.get(key) or {}
+    if not isinstance(raw_levels, dict):
+        raise ValueError(f"{source}: rule {index} ({pattern!r}): {key} must be a mapping")
+
+    verb = "grant" if key == "access" else key
+    levels: Dict[AccessLevel, List[str]] = {}
+    for name, users in raw_levels.items():
+        try:
+            level = parse_access_level(name)
+        except UnknownAccessLevelError as e:
+            raise UnknownAccessLevelError(
+                e.value, e.known, f"{source}: rule {index} ({pattern!r})"
+            ) from None
+        if level == AccessLevel.NONE:
+            raise ValueError(f"{source}: rule {index} ({pattern!r}): cannot {verb} '{level}'")
+        levels[level] = _parse_users(users, source, index, level, strict_users, groups)
+    return levels
+
+
+def _parse_verbs(
+    raw: Dict[str, Any],
+    source: str,
+    index: int,
+    strict_users: bool = False,
+    groups: Optional[Dict[str, List[str]]] = None,
+) -> Dict[Verb, List[str]]:
+    """Parse a rule's ``verbs`` mapping of verb names to user lists."""
+    pattern = raw["pattern"]
+    raw_verbs = raw.get("verbs") or {}
+    if not isinstance(raw_verbs, dict):
+        raise ValueError(f"{source}: rule {index} ({pattern!r}): verbs must be a mapping")
+
+    verbs: Dict[Verb, List[str]] = {}
+    for name, users in raw_verbs.items():
+        try:
+            verb = parse_verb(name)
+        except UnknownAccessLevelError as e:
+            raise UnknownAccessLevelError(
+                e.value, e.known, f"{source}: rule {index} ({pattern!r})"
+            ) from None
+        verbs[verb] = _parse_users(users, source, index, verb, strict_users, groups)
+    return verbs
+
+
+def parse_permission_file(
+    content: str,
+    path: Optional[Path] = None,
+    strict_users: bool = False,
+    max_wildcards: Optional[int] = DEFAULT_MAX_WILDCARDS,
+    variables: Optional[Mapping[str, str]] = None,
+) -> PermissionFile:
+    """
+    Parse the contents of a syft.pub.yaml file.
+
+    Emails in user lists are lowercased and entries differing only by case are
+    merged, unless ``strict_users`` keeps them exactly as written.
+
+    Yaml anchors and aliases are resolved, and an alias inside a user list is spliced
+    in, so ``[*admins, bob@example.com]`` lists every admin and bob. A top-level
+    ``groups`` mapping names lists of users that an access level can reference with
+    ``{group: name}``, alone or as a list entry. Groups are expanded while loading;
+    the model only holds the resulting users.
+
+    A top-level ``public`` list of patterns is shorthand for rules granting read to
+    ``*`` on each of them, and an entry starting with ``!`` for an exclusion rule,
+    which takes the paths out of every grant, not only the public one. They are
+    added after the file's own rules and are rules like any other from then on:
+    ``PermissionFile.to_yaml`` writes them out under ``rules`` and never writes a
+    ``public`` list, so reading its output gives back the same model.
+
+    Patterns with more than ``max_wildcards`` wildcards are rejected, since matching
+    them can get very expensive. A run of ``*`` counts once and so does each ``?``.
+
+    Given ``variables``, every ``${name}`` in a pattern, user list or group is replaced
+    by its value before anything is validated, so one template can serve many
+    datasites. ``$$`` stands for a literal ``$``, and a ``$`` followed by anything else
+    is kept as is. Values are inserted as written: wildcards in them still match.
+    Without ``variables`` nothing is expanded.
+
+    Args:
+        content: Raw yaml text
+        path: Where the content came from, used in error messages
+        strict_users: Keep user entries exactly as written
+        max_wildcards: Most wildcards a pattern may contain; None for no limit
+        variables: Values for the ``${name}`` references in patterns and user lists
+
+    Returns:
+        PermissionFile: The parsed rules
+
+    Raises:
+        UnknownAccessLevelError: If a rule uses an unknown access level
+        ValueError: If the yaml is malformed in any other way, or references a variable
+            that ``variables`` doesn't define
+        PatternSyntaxError: If any rule pattern is malformed
+    """
+    source = str(path) if path is not None else PERMISSION_FILE_NAME
+    loader = yaml.SafeLoader(content or "")
+    try:
+        node = loader.get_single_node()
+        data = loader.construct_document(node) if node is not None else None
+    except yaml.YAMLError as e:
+        raise ValueError(f"{source}: invalid yaml: {e}") from None
+    finally:
+        loader.dispose()
+    perm_file = _build_permission_file(
+        data,
+        path,
+        _rule_positions(node, path),
+        strict_users,
+        max_wildcards,
+        variables,
+        _rule_positions(node, path, "public"),
+    )
+    _attach_comments(perm_file.rules, (content or "").splitlines())
+    return perm_file
+
+
+def _rule_positions(
+    node: Optional[yaml.Node], path: Optional[Path], key: str = "rules"
+) -> List[SourcePosition]:
+    """Find where each entry of a top-level list (``rules`` or ``public``) starts in the yaml."""
+    if not isinstance(node, yaml.MappingNode):
+        return []
+    for key_node, value_node in node.value:
+        if key_node.value == key and isinstance(value_node, yaml.SequenceNode):
+            return [
+                SourcePosition(path, item.start_mark.line + 1, item.start_mark.column + 1)
+                for item in value_node.value
+            ]
+    return []
+
+
+def _attach_comments(rules: List[Rule], lines: List[str]) -> None:
+    """
+    Copy the yaml comments around each rule into its head and line comment.
+
+    The head comment is the run of comment lines directly above the rule's first line
+    that are indented no deeper than it, so a comment closing the previous rule's body
+    stays behind. Comments inside a rule's body are not kept.
+    """
+    for rule in rules:
+        if rule.position is None:
+            continue
+        index = rule.position.line - 1
+        first_line = lines[index]
+        indent = len(first_line) - len(first_line.lstrip())
+        head = []
+        while index > 0:
+            above = lines[index - 1]
+            stripped = above.lstrip()
+            if not stripped.startswith("#") or len(above) - len(stripped) > indent:
+                break
+            head.append(_comment_text(stripped))
+            index -= 1
+        if head:
+            rule.head_comment = "\n".join(reversed(head))
+        comment_start = _line_comment_start(first_line, rule.position.column - 1)
+        if comment_start != -1:
+            rule.line_comment = _comment_text(first_line[comment_start:])
+
+
+def _line_comment_start(line: str, start: int) -> int:
+    """Index of the ``#`` opening a comment on a yaml line, skipping quoted text, or -1."""
+    quote = None
+    i = start
+    while i < len(line):
+        c = line[i]
+        if quote == '"' and c == "\\":
+            i += 2
+            continue
+        if quote is not None:
+            if c == quote:
+                quote = None
+        elif c in "'\"":
+            quote = c
+        elif c == "#" and i > 0 and line[i - 1] in " \t":
+            return i
+        i += 1
+    return -1
+
+
+def _comment_text(comment: str) -> str:
+    """The text of a ``# ...`` comment, without the marker and the space after it."""
+    text = comment.strip()[1:]
+    return text[1:] if text.startswith(" ") else text.rstrip()
+
+
+def _comment_line(text: str) -> str:
+    """A comment's text written as a yaml comment."""
+    return f"# {text}" if text else "#"
+
+
+def _check_patterns(
+    rules: List[Rule], source: str, max_wildcards: Optional[int] = DEFAULT_MAX_WILDCARDS
+) -> None:
+    """Report every malformed pattern at once instead of failing later during resolution."""
+    pattern_errors = []
+    for index, rule in enumerate(rules):
+        try:
+            _validate_pattern(rule.match_pattern, max_wildcards)
+        except ValueError as e:
+            pattern_errors.append((index, rule.pattern, str(e)))
+    if pattern_errors:
+        lines = {
+            index: rule.position.line
+            for index, rule in enumerate(rules)
+            if rule.position is not None
+        }
+        raise PatternSyntaxError(source, pattern_errors, lines)
+
+
+def _build_permission_file(
+    data: Any,
+    path: Optional[Path],
+    positions: Optional[List[SourcePosition]] = None,
+    strict_users: bool = False,
+    max_wildcards: Optional[int] = DEFAULT_MAX_WILDCARDS,
+    variables: Optional[Mapping[str, str]] = None,
+    public_positions: Optional[List[SourcePosition]] = None,
+) -> PermissionFile:
+    """Validate a decoded yaml or json document and build the model from it."""
+    source = str(path) if path is not None else PERMISSION_FILE_NAME
+    if data is None:
+        data = {}
+    if not isinstance(data, dict):
+        raise ValueError(f"{source}: expected a mapping at the top level")
+
+    raw_rules = data.get("rules") or []
+    if not isinstance(raw_rules, list):
+        raise ValueError(f"{source}: rules must be a list")
+
+    raw_groups = data.get("groups")
+    if variables is not None:
+        raw_groups = _interpolate_value(raw_groups, variables, f"{source}: groups")
+    groups = _parse_groups(raw_groups, source)
+    positions = positions or []
+    rules = []
+    for index, raw in enumerate(raw_rules):
+        position = positions[index] if index < len(positions) else None
+        rule_source = f"{source}:{position.line}" if position is not None else source
+        if variables is not None:
+            raw = _interpolate_rule(raw, variables, rule_source, index)
+        rule = _parse_rule(raw, rule_source, index, strict_users, groups)
+        rule.position = position
+        rules.append(rule)
+    rules.extend(_public_rules(data.get("public"), source, public_positions or [], variables))
+    _check_patterns(rules, source, max_wildcards)
+    terminal = data.get("terminal", False)
+    if not isinstance(terminal, bool):
+        raise ValueError(f"{source}: terminal must be a boolean")
+    return PermissionFile(rules=rules, terminal=terminal, path=path)
+
+
+def _public_rules(
+    raw: Any,
+    source: str,
+    positions: List[SourcePosition],
+    variables: Optional[Mapping[str, str]] = None,
+) -> List[Rule]:
+    """Desugar the top-level ``public`` list into rules granting everyone read."""
+    if raw is None:
+        return []
+    if isinstance(raw, str):
+        raw = [raw]
+    if not isinstance(raw, list) or not all(isinstance(entry, str) and entry for entry in raw):
+        raise ValueError(f"{source}: public must be a list of patterns")
+    rules = []
+    for index, pattern in enumerate(raw):
+        if variables is not None:
+            pattern = _interpolate(pattern, variables, f"{source}: public entry {index}")
+        negated, _ = _split_negation(pattern)
+        rule = Rule(pattern) if negated else Rule(pattern, {AccessLevel.READ: ["*"]})
+        rule.position = positions[index] if index < len(positions) else None
+        rules.append(rule)
+    return rules
+
+
+def merge_rule_chain(perm_files: Iterable[PermissionFile]) -> EffectiveRuleset:
+    """
+    Merge the permission files along a path into one effective ruleset.
+
+    Files are given root-first, as found walking from the datasite root down to a
+    directory. A file-level terminal makes its file self-contained, so the terminal
+    nearest the root truncates the chain to just that file. Rules marked terminal are
+    kept with their flag set: they only cut off other files for the paths they match,
+    which a path-independent ruleset can't decide. A rule repeated within one file is
+    listed once, since only its first occurrence can ever apply; patterns are compared
+    after normalize_pattern, so ``data//x`` repeats ``data/x``, along with extensions.
+
+    Args:
+        perm_files: Permission files ordered from the datasite root downwards
+
+    Returns:
+        EffectiveRuleset: Ordered, de-duplicated rules with their source files
+    """
+    perm_files = list(perm_files)
+    terminal = next((perm_file for perm_file in perm_files if perm_file.terminal), None)
+    if terminal is not None:
+        perm_files = [terminal]
+
+    seen = set()
+    entries = []
+    for perm_file in perm_files:
+        file_key = perm_file.path if perm_file.path is not None else id(perm_file)
+        for index, rule in perm_file.ordered_rules():
+            key = (file_key, _match_key(rule))
+            if key in seen:
+                continue
+            seen.add(key)
+            entries.append(EffectiveRule(rule=rule, source=perm_file.path, rule_index=index))
+
+    return EffectiveRuleset(
+        rules=entries, terminal_source=terminal.path if terminal is not None else None
+    )
+
+
+def load_permission_file(
+    path: Union[str, Path],
+    strict_users: bool = False,
+    max_wildcards: Optional[int] = DEFAULT_MAX_WILDCARDS,
+    variables: Optional[Mapping[str, str]] = None,
+) -> PermissionFile:
+    """
+    Load and validate a syft.pub.yaml file from disk.
+
+    Args:
+        path: Path to the permission file
+        strict_users: Keep user entries exactly as written instead of lowercasing emails
+        max_wildcards: Most wildcards a pattern may contain; None for no limit
+        variables: Values for ``${name}`` references, as in parse_permission_file
+
+    Returns:
+        PermissionFile: The parsed rules
+
+    Raises:
+        PermissionFileNotFoundError: If there is no file at the path
+        OSError: If the file cannot be read
+        UnknownAccessLevelError: If a rule uses an unknown access level
+        ValueError: If the file is malformed in any other way, or references an
+            undefined variable
+        PatternSyntaxError: If any rule pattern is malformed
+    """
+    path = Path(path)
+    try:
+        content = path.read_text()
+    except FileNotFoundError:
+        raise PermissionFileNotFoundError(path) from None
+    return parse_permission_file(content, path, strict_users, max_wildcards, variables)
+
+
+def parse_ignore_file(
+    content: str,
+    path: Optional[Path] = None,
+    max_wildcards: Optional[int] = DEFAULT_MAX_WILDCARDS,
+) -> List[Rule]:
+    """
+    Parse the contents of a .syftignore file into one grant-less rule per pattern.
+
+    Each non-blank line is a pattern in the syntax of permission file rules, relative
+    to the ignore file's directory. Lines starting with ``#`` are comments and trailing
+    whitespace is dropped. A pattern starting with ``!`` takes paths back out of the
+    ones ignored by earlier lines; for every path the last matching line wins.
+
+    Args:
+        content: Raw text of the ignore file
+        path: Where the content came from, used in error messages and rule positions
+        max_wildcards: Most wildcards a pattern may contain; None for no limit
+
+    Returns:
+        list: The patterns as rules without access, in file order
+
+    Raises:
+        PatternSyntaxError: If any pattern is malformed
+    """
+    source = str(path) if path is not None else IGNORE_FILE_NAME
+    rules = []
+    for number, line in enumerate((content or "").splitlines(), start=1):
+        pattern = line.rstrip()
+        if not pattern or pattern.startswith("#"):
+            continue
+        rules.append(Rule(pattern, position=SourcePosition(path, number, 1)))
+    _check_patterns(rules, source, max_wildcards)
+    return rules
@@ -0,0 +1,454 @@
+"""Parsed permission files for many datasites, reloadable while requests are being served."""
+
+import queue
+import threading
+import time
+from collections import OrderedDict
+from datetime import datetime
+from pathlib import Path
+from typing import Callable, Dict, List, Mapping, Optional, Tuple, Union
+
+from .datasite import Datasite, load_datasite
+from .filesystem import FileSystem, OSFileSystem
+from .metrics import Metrics
+from .path_matching import DEFAULT_MAX_WILDCARDS, MatchOptions, _acl_norm_path
+from .permissions import AccessLevel, UserMatcher, canonical_user
+from .resolver import DotSegments, ResolutionStrategy, Resolver, _utc_now
+from .rules import PERMISSION_FILE_NAME
+
+# Editors often write a file twice in a row; changes this close together reload once
+DEFAULT_DEBOUNCE = 0.2
+
+# Event types that can add, change or remove a permission file
+_CHANGE_EVENTS = frozenset({"created", "modified", "deleted", "moved"})
+
+# How many (datasite, user) views resolve_cached keeps before evicting the oldest
+DEFAULT_CACHED_USERS = 1024
+
+
+class _PendingReloads:
+    """Datasites waiting to be reloaded, each due once its changes have settled."""
+
+    def __init__(self, debounce: float):
+        self.debounce = debounce
+        self._due: Dict[str, float] = {}
+        self._lock = threading.Lock()
+
+    def add(self, datasite: str, now: Optional[float] = None) -> None:
+        """Record a change, pushing the datasite's reload back by the debounce window."""
+        now = time.monotonic() if now is None else now
+        with self._lock:
+            self._due[datasite] = now + self.debounce
+
+    def pop_due(self, now: Optional[float] = None) -> List[str]:
+        """Remove and return the datasites with no change in the last debounce window."""
+        now = time.monotonic() if now is None else now
+        with self._lock:
+            ready = sorted(name for name, due in self._due.items() if due <= now)
+            for name in ready:
+                del self._due[name]
+        return ready
+
+    def wait_time(self, now: Optional[float] = None) -> float:
+        """Seconds until the next reload is due, or one debounce window if none is pending."""
+        now = time.monotonic() if now is None else now
+        with self._lock:
+            if not self._due:
+                return self.debounce
+            return max(0.0, min(self._due.values()) - now)
+
+
+class _UserView:
+    """Levels already resolved for one user against one datasite snapshot."""
+
+    def __init__(self, snapshot: Resolver, now: datetime):
+        self.snapshot = snapshot
+        self.levels: Dict[str, AccessLevel] = {}
+        # The levels hold until some rule's validity window opens or closes
+        self.valid_until = _next_window_change(snapshot, now)
+
+    def is_current(self, snapshot: Resolver, now: datetime) -> bool:
+        """Whether the levels still hold for a snapshot at an instant."""
+        return snapshot is self.snapshot and (self.valid_until is None or now < self.valid_until)
+
+
+def _next_window_change(snapshot: Resolver, now: datetime) -> Optional[datetime]:
+    """The first instant after ``now`` at which a rule of the snapshot starts or stops applying."""
+    changes = [
+        instant
+        for perm_file in (snapshot.permission_files or {}).values()
+        for rule in perm_file.rules
+        for instant in (rule.not_before, rule.not_after)
+        if instant is not None and instant > now
+    ]
+    return min(changes, default=None)
+
+
+class _UserViewCache:
+    """Bounded LRU of user views keyed by (datasite, user), safe to share across threads."""
+
+    def __init__(self, max_users: int):
+        self.max_users = max_users
+        self._views: "OrderedDict[Tuple[str, str], _UserView]" = OrderedDict()
+        self._lock = threading.Lock()
+
+    def get(self, key: Tuple[str, str], snapshot: Resolver, now: datetime) -> _UserView:
+        """The view for a key, replacing it if it's from an older snapshot or has expired."""
+        with self._lock:
+            view = self._views.get(key)
+            if view is None or not view.is_current(snapshot, now):
+                view = _UserView(snapshot, now)
+                self._views[key] = view
+                while len(self._views) > self.max_users:
+                    self._views.popitem(last=False)
+            else:
+                self._views.move_to_end(key)
+            return view
+
+    def rebase(
+        self, datasite: str, old: Resolver, new: Resolver, directory: str, now: datetime
+    ) -> None:
+        """Move a datasite's views to a new snapshot, dropping the levels under a directory."""
+        prefix = directory + "/" if directory else ""
+        with self._lock:
+            for key in [key for key in self._views if key[0] == datasite]:
+                view = self._views[key]
+                if view.snapshot is new:
+                    continue
+                if not view.is_current(old, now):
+                    del self._views[key]
+                    continue
+                # Readers may still be adding levels to the old view; copy it in one go
+                levels = view.levels.copy()
+                rebased = _UserView(new, now)
+                rebased.levels = {
+                    path: level
+                    for path, level in levels.items()
+                    if path != directory and not path.startswith(prefix)
+                }
+                self._views[key] = rebased
+
+    def invalidate(self, datasite: Optional[str] = None) -> None:
+        """Drop the views of one datasite, or of every datasite."""
+        with self._lock:
+            if datasite is None:
+                self._views.clear()
+                return
+            for key in [key for key in self._views if key[0] == datasite]:
+                del self._views[key]
+
+    def __len__(self) -> int:
+        with self._lock:
+            return len(self._views)
+
+
+class PermissionStore:
+    """
+    Holds a parsed snapshot of every datasite's permission files in memory.
+
+    Each datasite is a direct subdirectory of ``datasites_root``, named after its
+    owner, who is what ``{owner}`` in a user list resolves to. Its snapshot is a
+    Resolver over the permission files as they were when last loaded, so resolving
+    never re-reads syft.pub.yaml. Reloading parses the new files first and then
+    swaps the snapshot in one assignment: readers never take a lock and always see
+    either the complete old snapshot or the complete new one. Reloads are
+    serialized with each other.
+
+    ``resolve_cached`` additionally remembers the levels it resolved for each of the
+    ``cached_users`` most recently active (datasite, user) pairs. Reloading a
+    datasite drops its cached levels, so they never outlive the snapshot they came
+    from, and they are dropped too when a rule's validity window opens or closes.
+    Reloading a single file with reload_file only drops the levels beneath it.
+
+    Args:
+        datasites_root: Directory containing one subdirectory per datasite
+        match_options: Matching options passed to every snapshot's Resolver
+        default_access: Level for paths no rule matches, passed to every Resolver
+        filesystem: Read the datasites from this filesystem, rooted at the datasites
+            directory, instead of from ``datasites_root`` on disk. Snapshots then stat
+            paths through it too.
+        strict_users: Match user IDs exactly as written instead of ignoring the case
+            of emails, when loading and in every snapshot
+        strategy: How matching rules combine, passed to every Resolver
+        max_wildcards: Reject permission files with patterns containing more wildcards
+            than this; None for no limit
+        cached_users: Most (datasite, user) pairs resolve_cached keeps levels for
+        dot_segments: How ``..`` segments in queried paths are handled, passed to every
+            Resolver
+        metrics: Told about resolve_cached hits and misses, and passed to every Resolver
+        clock: Current time for rule validity windows, passed to every Resolver
+        variables: Values for ``${name}`` references in every datasite's permission
+            files, as in parse_permission_file
+        user_matcher: Decides which list entries cover a user, passed to every Resolver.
+            resolve_cached then keys its levels by user IDs exactly as given.
+    """
+
+    def __init__(
+        self,
+        datasites_root: Union[str, Path],
+        match_options: Optional[MatchOptions] = None,
+        default_access: AccessLevel = AccessLevel.NONE,
+        filesystem: Optional[FileSystem] = None,
+        strict_users: bool = False,
+        strategy: ResolutionStrategy = ResolutionStrategy.MOST_SPECIFIC,
+        max_wildcards: Optional[int] = DEFAULT_MAX_WILDCARDS,
+        cached_users: int = DEFAULT_CACHED_USERS,
+        dot_segments: DotSegments = DotSegments.REJECT,
+        metrics: Optional[Metrics] = None,
+        clock: Callable[[], datetime] = _utc_now,
+        variables: Optional[Mapping[str, str]] = None,
+        user_matcher: Optional[UserMatcher] = None,
+    ):
+        self.datasites_root = Path(datasites_root)
+        self.match_options = match_options
+        self.default_access = default_access
+        self.filesystem = filesystem
+        self.strict_users = strict_users
+        self.strategy = strategy
+        self.max_wildcards = max_wildcards
+        self.dot_segments = dot_segments
+        self.metrics = metrics
+        self.clock = clock
+        self.variables = variables
+        self.user_matcher = user_matcher
+        self._fs = filesystem if filesystem is not None else OSFileSystem(self.datasites_root)
+        # Never mutated in place; reloads build a new dict and rebind it
+        self._snapshots: Dict[str, Resolver] = {}
+        self._reload_lock = threading.Lock()
+        self._user_views = _UserViewCache(cached_users)
+
+    def get(self, datasite: str) -> Optional[Resolver]:
+        """
+        Get the current snapshot of a datasite.
+
+        Args:
+            datasite: Datasite directory name, e.g. the owner's email
+
+        Returns:
+            Resolver: In-memory resolver for the datasite, or None if it isn't loaded
+        """
+        return self._snapshots.get(datasite)
+
+    def resolve_cached(self, datasite: str, path: Union[str, Path], user: str) -> AccessLevel:
+        """
+        Resolve a path for a user, reusing levels resolved earlier for the same user.
+
+        Suited to answering the same few users over and over. Levels are resolved by the
+        current snapshot, like ``get(datasite).resolve(path, user)``, but are only
+        forgotten when the permission files are reloaded: a level that depended on the
+        tree, through a file's size or whether the path is a directory or symlink, is
+        answered as first resolved until then. Use the snapshot when the tree changes
+        between reloads.
+
+        Args:
+            datasite: Datasite directory name
+            path: Path relative to the datasite root, or an absolute path inside it
+            user: User ID to resolve for
+
+        Returns:
+            AccessLevel: Effective access level
+
+        Raises:
+            KeyError: If the datasite isn't loaded
+        """
+        snapshot = self._snapshots.get(datasite)
+        if snapshot is None:
+            raise KeyError(f"datasite {datasite!r} is not loaded")
+        exact = self.strict_users or self.user_matcher is not None
+        user_key = user if exact else canonical_user(user)
+        now = self.clock()
+        view = self._user_views.get((datasite, user_key), snapshot, now)
+        rel_path = snapshot._relative(path)
+        level = view.levels.get(rel_path)
+        if self.metrics is not None:
+            self.metrics.on_cache(level is not None)
+        if level is None:
+            level = snapshot.resolve(rel_path, user)
+            view.levels[rel_path] = level
+        return level
+
+    @property
+    def datasites(self) -> List[str]:
+        """Names of the loaded datasites, sorted."""
+        return sorted(self._snapshots)
+
+    def reload(self, datasite: str) -> None:
+        """
+        Re-read one datasite's permission files and swap in the new snapshot.
+
+        A datasite whose directory no longer exists is dropped from the store.
+
+        Args:
+            datasite: Datasite directory name
+
+        Raises:
+            OSError: If a permission file cannot be read
+            ValueError: If a permission file is malformed; the old snapshot is kept
+        """
+        with self._reload_lock:
+            snapshots = dict(self._snapshots)
+            if self._fs.is_dir(datasite):
+                snapshots[datasite] = self._load_snapshot(datasite)
+            else:
+                snapshots.pop(datasite, None)
+            self._snapshots = snapshots
+            self._user_views.invalidate(datasite)
+
+    def reload_file(self, datasite: str, directory: str) -> None:
+        """
+        Re-read one permission file of a datasite and swap in the updated snapshot.
+
+        Only that file is parsed; the datasite's other files are carried over from the
+        current snapshot, as in Datasite.reload_file. Readers see the complete old
+        snapshot or the complete new one, as with reload. A permission file only
+        governs paths beneath its own directory, so resolve_cached keeps the levels it
+        has for paths elsewhere in the datasite and only drops those under it.
+
+        Args:
+            datasite: Datasite directory name
+            directory: Datasite-relative directory of the file ("" for the root)
+
+        Raises:
+            KeyError: If the datasite isn't loaded
+            OSError: If the file cannot be read
+            ValueError: If the file is malformed; the old snapshot is kept
+        """
+        with self._reload_lock:
+            current = self._snapshots.get(datasite)
+            if current is None:
+                raise KeyError(f"datasite {datasite!r} is not loaded")
+            loaded = Datasite(
+                current.root,
+                files=dict(current.permission_files or {}),
+                filesystem=self._fs.sub(datasite),
+                strict_users=self.strict_users,
+                max_wildcards=self.max_wildcards,
+                variables=self.variables,
+            )
+            loaded.reload_file(directory)
+            snapshot = self._snapshot_of(datasite, loaded)
+            snapshots = dict(self._snapshots)
+            snapshots[datasite] = snapshot
+            self._snapshots = snapshots
+            directory = _acl_norm_path(directory)
+            self._user_views.rebase(datasite, current, snapshot, directory, self.clock())
+
+    def reload_all(self) -> None:
+        """
+        Re-read every datasite under the root and swap in all snapshots together.
+
+        Hidden directories are ignored. Datasites that disappeared are dropped.
+
+        Raises:
+            OSError: If a permission file cannot be read
+            ValueError: If any permission file is malformed; no snapshot is replaced
+        """
+        with self._reload_lock:
+            snapshots = {}
+            _, dirnames, _ = next(self._fs.walk(""), ("", [], []))
+            for name in sorted(dirnames):
+                if not name.startswith("."):
+                    snapshots[name] = self._load_snapshot(name)
+            self._snapshots = snapshots
+            self._user_views.invalidate()
+
+    def watch(
+        self,
+        stop: threading.Event,
+        debounce: float = DEFAULT_DEBOUNCE,
+        errors: "Optional[queue.Queue[Exception]]" = None,
+    ) -> None:
+        """
+        Reload datasites automatically when their permission files change on disk.
+
+        Blocks until ``stop`` is set, then shuts the watcher down; run it in its own
+        thread. Changes to one datasite within ``debounce`` seconds of each other are
+        coalesced into a single reload. Deleting a permission file drops its rules
+        on the next reload, and removing a datasite directory drops the datasite.
+        Requires the ``watchdog`` package and the local filesystem.
+
+        Args:
+            stop: Event that ends the watch when set
+            debounce: Seconds a datasite must go without changes before reloading
+            errors: Queue that receives the error of every failed reload; without one,
+                failures are ignored and the previous snapshot stays in place
+
+        Raises:
+            ValueError: If the store reads from a filesystem other than the local disk
+        """
+        if self.filesystem is not None:
+            raise ValueError("only stores reading from the local disk can be watched")
+        from watchdog.events import FileSystemEventHandler
+        from watchdog.observers import Observer
+
+        pending = _PendingReloads(debounce)
+        store = self
+
+        class _Handler(FileSystemEventHandler):
+            def on_any_event(self, event):
+                for path in (event.src_path, getattr(event, "dest_path", "")):
+                    datasite = store._changed_datasite(path, event.event_type, event.is_directory)
+                    if datasite is not None:
+                        pending.add(datasite)
+
+        observer = Observer()
+        observer.schedule(_Handler(), str(self.datasites_root), recursive=True)
+        observer.start()
+        try:
+            while not stop.wait(pending.wait_time()):
+                for datasite in pending.pop_due():
+                    try:
+                        self.reload(datasite)
+                    except (OSError, ValueError) as e:
+                        if errors is not None:
+                            errors.put(e)
+        finally:
+            observer.stop()
+            observer.join()
+
+    def _changed_datasite(
+        self, path: Union[str, Path], event_type: str, is_directory: bool
+    ) -> Optional[str]:
+        """The datasite a filesystem event should reload, if any."""
+        if not path or event_type not in _CHANGE_EVENTS:
+            return None
+        try:
+            parts = Path(path).relative_to(self.datasites_root).parts
+        except ValueError:
+            return None
+        if not parts or parts[0].startswith("."):
+            return None
+        if is_directory:
+            # Directories gaining new files only report "modified"; skip those, but
+            # created, removed or renamed directories can carry permission files along
+            return parts[0] if event_type != "modified" else None
+        return parts[0] if parts[-1] == PERMISSION_FILE_NAME else None
+
+    def _load_snapshot(self, datasite: str) -> Resolver:
+        """Parse every permission file of a datasite into an in-memory resolver."""
+        loaded = load_datasite(
+            self.datasites_root / datasite,
+            filesystem=self._fs.sub(datasite),
+            strict_users=self.strict_users,
+            max_wildcards=self.max_wildcards,
+            variables=self.variables,
+        )
+        return self._snapshot_of(datasite, loaded)
+
+    def _snapshot_of(self, datasite: str, loaded: Datasite) -> Resolver:
+        """An in-memory resolver over a datasite's loaded permission files."""
+        site = self._fs.sub(datasite)
+        return Resolver(
+            loaded.root,
+            match_options=self.match_options,
+            default_access=self.default_access,
+            permission_files=loaded.files,
+            owner=datasite,
+            filesystem=site if self.filesystem is not None else None,
+            strict_users=self.strict_users,
+            strategy=self.strategy,
+            dot_segments=self.dot_segments,
+            metrics=self.metrics,
+            clock=self.clock,
+            user_matcher=self.user_matcher,
+        )
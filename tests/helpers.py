@@ -0,0 +1,51 @@
+"""Shared helpers for the test suite."""
+
+from pathlib import Path
+
+
+def write_file(root: Path, rel_path: str, content: str) -> Path:
+    """Write ``content`` to ``root / rel_path``, creating parent directories."""
+    full_path = root / rel_path
+    full_path.parent.mkdir(parents=True, exist_ok=True)
+    full_path.write_text(content)
+    return full_path
+
+
+# Permission files for a small datasite whose rules give users different levels.
+DATASITE = {
+    "syft.pub.yaml": """rules:
+- pattern: "**"
+  access:
+    admin: [alice@example.com]
+    read: ["*"]
+- pattern: "users/{user}/**"
+  access:
+    write: ["*"]
+""",
+    "data/syft.pub.yaml": """rules:
+- pattern: "*.csv"
+  access:
+    write: [alice@example.com, bob@example.com]
+    read: ["*"]
+  revoke:
+    read: [dan@example.com]
+- pattern: "**/*.{json,parquet}"
+  access:
+    admin: [carol@example.com]
+- pattern: "**"
+  access:
+    create: [carol@other.org]
+""",
+    "data/private/syft.pub.yaml": """terminal: true
+rules:
+- pattern: "**"
+  access:
+    admin: [bob@example.com]
+""",
+}
+
+
+def build_datasite(root: Path) -> None:
+    """Write the ``DATASITE`` permission files under ``root``."""
+    for rel_path, content in DATASITE.items():
+        write_file(root, rel_path, content)
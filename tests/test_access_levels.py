@@ -0,0 +1,132 @@
+"""Tests for the AccessLevel enum and access validation in the permission file loader."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    load_permission_file,
+    parse_access_level,
+    parse_permission_file,
+)
+
+
+class TestAccessLevel(unittest.TestCase):
+    """Test AccessLevel ordering and parsing."""
+
+    def test_levels_are_ordered(self):
+        """admin > write > create > read > none."""
+        self.assertGreater(AccessLevel.ADMIN, AccessLevel.WRITE)
+        self.assertGreater(AccessLevel.WRITE, AccessLevel.CREATE)
+        self.assertGreater(AccessLevel.CREATE, AccessLevel.READ)
+        self.assertGreater(AccessLevel.READ, AccessLevel.NONE)
+        self.assertEqual(max(AccessLevel.READ, AccessLevel.ADMIN), AccessLevel.ADMIN)
+
+    def test_parse_known_levels(self):
+        """Level names parse case-insensitively."""
+        self.assertEqual(parse_access_level("read"), AccessLevel.READ)
+        self.assertEqual(parse_access_level("create"), AccessLevel.CREATE)
+        self.assertEqual(parse_access_level("write"), AccessLevel.WRITE)
+        self.assertEqual(parse_access_level("Admin"), AccessLevel.ADMIN)
+        self.assertEqual(parse_access_level("none"), AccessLevel.NONE)
+
+    def test_parse_unknown_level_raises(self):
+        """A typo is rejected instead of silently granting nothing."""
+        with self.assertRaises(ValueError) as ctx:
+            parse_access_level("wrtie")
+        self.assertIn("wrtie", str(ctx.exception))
+
+    def test_str_round_trips(self):
+        """str() gives the yaml spelling and parses back to the same level."""
+        for level in AccessLevel:
+            self.assertEqual(parse_access_level(str(level)), level)
+        self.assertEqual(str(AccessLevel.WRITE), "write")
+
+
+class TestLoaderAccessValidation(unittest.TestCase):
+    """Test that the loader validates access levels in rules."""
+
+    def setUp(self):
+        """Create a temporary directory for test files."""
+        self.test_dir = tempfile.mkdtemp(prefix="syft_perm_test_")
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_valid_file_loads(self):
+        """Access keys are parsed into AccessLevel members."""
+        yaml_file = Path(self.test_dir) / "syft.pub.yaml"
+        yaml_file.write_text(
+            """terminal: true
+rules:
+- pattern: "**/*.csv"
+  access:
+    read:
+    - "*"
+    write:
+    - alice@example.com
+"""
+        )
+
+        perm_file = load_permission_file(yaml_file)
+        self.assertTrue(perm_file.terminal)
+        self.assertEqual(perm_file.path, yaml_file)
+        self.assertEqual(len(perm_file.rules), 1)
+
+        rule = perm_file.rules[0]
+        self.assertEqual(rule.pattern, "**/*.csv")
+        self.assertEqual(rule.users_for(AccessLevel.WRITE), ["alice@example.com"])
+        self.assertEqual(rule.level_for("alice@example.com"), AccessLevel.WRITE)
+        self.assertEqual(rule.level_for("bob@example.com"), AccessLevel.READ)
+
+    def test_unknown_access_level_is_descriptive_error(self):
+        """A misspelled level fails the load and names the file, rule and level."""
+        yaml_file = Path(self.test_dir) / "syft.pub.yaml"
+        yaml_file.write_text(
+            """rules:
+- pattern: "*.txt"
+  access:
+    wrtie:
+    - alice@example.com
+"""
+        )
+
+        with self.assertRaises(ValueError) as ctx:
+            load_permission_file(yaml_file)
+        message = str(ctx.exception)
+        self.assertIn(str(yaml_file), message)
+        self.assertIn("rule 0", message)
+        self.assertIn("wrtie", message)
+
+    def test_public_alias_normalized(self):
+        """'public' is stored as the '*' wildcard."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "**"
+  access:
+    read: [public]
+"""
+        )
+        self.assertEqual(perm_file.rules[0].users_for(AccessLevel.READ), ["*"])
+
+    def test_exclusion_grants_nothing(self):
+        """An exclusion rule never grants access, whatever it lists."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "!secret.txt"
+"""
+        )
+        rule = perm_file.rules[0]
+        self.assertTrue(rule.is_exclusion)
+        self.assertEqual(rule.match_pattern, "secret.txt")
+        self.assertEqual(rule.level_for("alice@example.com"), AccessLevel.NONE)
+
+
+if __name__ == "__main__":
+    unittest.main()
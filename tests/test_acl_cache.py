@@ -0,0 +1,103 @@
+"""Tests for exporting a datasite's permissions as a flat ACL cache."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import (  # noqa: E402
+    WILDCARD_USER,
+    AccessLevel,
+    Cancellation,
+    ResolutionCancelled,
+    Resolver,
+    export_acl_cache,
+)
+
+
+class TestExportAclCache(unittest.TestCase):
+    """Test that the flat map agrees with resolving each path directly."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  access:
+    admin: [{owner}]
+    read: ["*"]
+""",
+        )
+        write_file(
+            self.test_dir,
+            "team/syft.pub.yaml",
+            """terminal: true
+rules:
+- pattern: "*.csv"
+  access:
+    write: [bob@example.com]
+    read: ["*@example.com"]
+""",
+        )
+        write_file(self.test_dir, "readme.md", "hello")
+        write_file(self.test_dir, "team/data.csv", "1,2")
+        write_file(self.test_dir, "team/notes.txt", "private")
+        write_file(self.test_dir, ".git/config", "ignored")
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_flattened_map(self):
+        """Every file is listed with the named users, the wildcard and domain keys."""
+        cache = export_acl_cache(self.test_dir, owner="alice@example.com")
+        self.assertEqual(
+            cache,
+            {
+                "readme.md": {
+                    WILDCARD_USER: AccessLevel.READ,
+                    "*@example.com": AccessLevel.READ,
+                    "alice@example.com": AccessLevel.ADMIN,
+                    "bob@example.com": AccessLevel.READ,
+                },
+                "team/data.csv": {
+                    "*@example.com": AccessLevel.READ,
+                    "alice@example.com": AccessLevel.READ,
+                    "bob@example.com": AccessLevel.WRITE,
+                },
+                "team/notes.txt": {},
+            },
+        )
+
+    def test_agrees_with_resolver(self):
+        """Each entry is exactly what the resolver returns for that user."""
+        cache = export_acl_cache(self.test_dir, owner="alice@example.com")
+        resolver = Resolver(self.test_dir, owner="alice@example.com")
+        for path, users in cache.items():
+            for user, level in users.items():
+                with self.subTest(path=path, user=user):
+                    self.assertEqual(resolver.resolve(path, user), level)
+
+    def test_owner_unknown(self):
+        """Without an owner, {owner} names nobody."""
+        cache = export_acl_cache(self.test_dir)
+        self.assertNotIn("alice@example.com", cache["readme.md"])
+        self.assertEqual(cache["team/data.csv"]["bob@example.com"], AccessLevel.WRITE)
+
+    def test_cancelled(self):
+        """A cancelled export raises instead of returning a partial map."""
+        cancel = Cancellation()
+        cancel.cancel()
+        with self.assertRaises(ResolutionCancelled):
+            export_acl_cache(self.test_dir, cancel=cancel)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,134 @@
+"""Tests for {a,b,c} brace expansion in glob patterns."""
+
+import os
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+import yaml
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+import syft_perm  # noqa: E402
+from syft_perm._impl import clear_permission_cache  # noqa: E402
+from syft_perm.core import match  # noqa: E402
+from syft_perm.core.path_matching import _expand_braces  # noqa: E402
+
+
+class TestBraceExpansion(unittest.TestCase):
+    """Test brace expansion in the matcher."""
+
+    def test_simple_alternatives(self):
+        """Each alternative of a group is tried."""
+        pattern = "data/{train,test,val}/*.parquet"
+        self.assertTrue(match(pattern, "data/train/part-0.parquet"))
+        self.assertTrue(match(pattern, "data/test/part-0.parquet"))
+        self.assertTrue(match(pattern, "data/val/part-0.parquet"))
+        self.assertFalse(match(pattern, "data/holdout/part-0.parquet"))
+        self.assertFalse(match(pattern, "data/train/part-0.csv"))
+
+    def test_nested_braces(self):
+        """Groups nested inside alternatives are expanded too."""
+        self.assertEqual(
+            _expand_braces("img/{raw,thumb{s,nails}}/*"),
+            ("img/raw/*", "img/thumbs/*", "img/thumbnails/*"),
+        )
+        self.assertTrue(match("img/{raw,thumb{s,nails}}/*", "img/thumbnails/a.png"))
+        self.assertFalse(match("img/{raw,thumb{s,nails}}/*", "img/thumb/a.png"))
+
+    def test_empty_alternative(self):
+        """file{,.bak} matches both the file and its backup."""
+        self.assertEqual(_expand_braces("file{,.bak}"), ("file", "file.bak"))
+        self.assertTrue(match("file{,.bak}", "file"))
+        self.assertTrue(match("file{,.bak}", "file.bak"))
+        self.assertFalse(match("file{,.bak}", "file.old"))
+
+    def test_escaped_brace_is_literal(self):
+        """A backslash-escaped brace never starts a group."""
+        pattern = "notes\\{a,b\\}.txt"
+        self.assertEqual(_expand_braces(pattern), (pattern,))
+        self.assertTrue(match(pattern, "notes{a,b}.txt"))
+        self.assertFalse(match(pattern, "notesa.txt"))
+
+    def test_unbalanced_brace_is_literal(self):
+        """An unclosed brace is matched literally rather than dropped."""
+        self.assertEqual(_expand_braces("data/{train"), ("data/{train",))
+        self.assertTrue(match("data/{train", "data/{train"))
+
+    def test_multiple_groups_combine(self):
+        """Several groups in one pattern produce their cartesian product."""
+        expanded = _expand_braces("{a,b}/{c,d}")
+        self.assertEqual(expanded, ("a/c", "a/d", "b/c", "b/d"))
+
+    def test_doublestar_with_braces(self):
+        """Brace groups compose with ** recursion."""
+        pattern = "**/*.{jpg,png}"
+        self.assertTrue(match(pattern, "photos/2024/a.jpg"))
+        self.assertTrue(match(pattern, "b.png"))
+        self.assertFalse(match(pattern, "photos/a.gif"))
+
+
+class TestBraceExpansionPermissions(unittest.TestCase):
+    """Test that permission resolution honors brace patterns."""
+
+    def setUp(self):
+        """Create a temporary directory for test files."""
+        self.test_dir = tempfile.mkdtemp(prefix="syft_perm_test_")
+        clear_permission_cache()
+
+    def tearDown(self):
+        """Clean up test directory."""
+        if os.path.exists(self.test_dir):
+            shutil.rmtree(self.test_dir)
+        clear_permission_cache()
+
+    def test_brace_rule_grants_access(self):
+        """A single brace rule replaces three near-identical rules."""
+        files = [
+            "data/train/a.parquet",
+            "data/test/a.parquet",
+            "data/val/a.parquet",
+            "data/raw/a.parquet",
+        ]
+        for file_path in files:
+            full_path = Path(self.test_dir) / file_path
+            full_path.parent.mkdir(parents=True, exist_ok=True)
+            full_path.write_text("content")
+
+        yaml_file = Path(self.test_dir) / "syft.pub.yaml"
+        yaml_content = {
+            "rules": [
+                {
+                    "pattern": "data/{train,test,val}/*.parquet",
+                    "access": {"read": ["alice@example.com"]},
+                }
+            ]
+        }
+        with open(yaml_file, "w") as f:
+            yaml.dump(yaml_content, f)
+
+        for file_path in files[:3]:
+            syft_file = syft_perm.open(Path(self.test_dir) / file_path)
+            self.assertTrue(syft_file.has_read_access("alice@example.com"), file_path)
+
+        syft_raw = syft_perm.open(Path(self.test_dir) / "data/raw/a.parquet")
+        self.assertFalse(syft_raw.has_read_access("alice@example.com"))
+
+
+class TestBraceExpansionGroups(unittest.TestCase):
+    """Test patterns whose groups multiply into many alternatives."""
+
+    def test_three_groups_of_four(self):
+        """A rule with three groups of four alternatives matches every combination."""
+        pattern = "{a,b,c,d}/{e,f,g,h}/**/*.{csv,json,parquet,txt}"
+        self.assertEqual(len(_expand_braces(pattern)), 64)
+
+        paths = [f"d/h/level{i}/nested/file{i}.txt" for i in range(200)]
+        paths += [f"x/y/level{i}/file{i}.bin" for i in range(200)]
+        self.assertEqual(sum(1 for path in paths if match(pattern, path)), 200)
+
+
+if __name__ == "__main__":
+    unittest.main()
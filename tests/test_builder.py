@@ -0,0 +1,101 @@
+"""Tests for building permission files with the fluent builder."""
+
+import sys
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    PermissionFileBuilder,
+    PermissionFileBuildError,
+    Resolver,
+    Rule,
+    parse_permission_file,
+)
+
+
+class TestPermissionFileBuilder(unittest.TestCase):
+    """Test that the builder produces the same files the parser would."""
+
+    def test_builds_rules_in_order(self):
+        """Chained calls configure the most recently added rule."""
+        perm_file = (
+            PermissionFileBuilder()
+            .add_rule("*.csv")
+            .grant("write", "alice@example.com")
+            .grant(AccessLevel.READ, "*")
+            .terminal()
+            .add_rule("**")
+            .grant("read", "bob@example.com", "carol@example.com")
+            .limits(max_file_size=1024)
+            .terminal_file()
+            .build()
+        )
+        self.assertTrue(perm_file.terminal)
+        self.assertEqual(
+            perm_file.rules,
+            [
+                Rule(
+                    "*.csv",
+                    {AccessLevel.WRITE: ["alice@example.com"], AccessLevel.READ: ["*"]},
+                    terminal=True,
+                ),
+                Rule(
+                    "**",
+                    {AccessLevel.READ: ["bob@example.com", "carol@example.com"]},
+                    {"max_file_size": 1024},
+                ),
+            ],
+        )
+        self.assertEqual(parse_permission_file(perm_file.to_yaml()).rules, perm_file.rules)
+
+    def test_built_file_resolves(self):
+        """Priority and depth settings carry through to resolution."""
+        perm_file = (
+            PermissionFileBuilder()
+            .add_rule("a/**")
+            .grant("admin", "{owner}")
+            .priority(10)
+            .add_rule("**")
+            .grant("read", "*")
+            .depth(max_depth=1)
+            .build()
+        )
+        resolver = Resolver(
+            ".", stat_func=None, permission_files={"": perm_file}, owner="alice@example.com"
+        )
+        self.assertEqual(resolver.resolve("a/b.txt", "alice@example.com"), AccessLevel.ADMIN)
+        self.assertEqual(resolver.resolve("a.txt", "bob@example.com"), AccessLevel.READ)
+        self.assertEqual(resolver.resolve("a/b.txt", "bob@example.com"), AccessLevel.NONE)
+
+    def test_errors_are_accumulated(self):
+        """Every mistake is reported by build, not just the first."""
+        builder = (
+            PermissionFileBuilder()
+            .grant("read", "*")
+            .add_rule("data/[abc")
+            .grant("owner", "alice@example.com")
+            .add_rule("**")
+            .grant("none", "*")
+            .grant("read", "{team}")
+            .depth(min_depth=3, max_depth=2)
+        )
+        with self.assertRaises(PermissionFileBuildError) as ctx:
+            builder.build()
+        errors = ctx.exception.errors
+        self.assertEqual(len(errors), 5, errors)
+        self.assertIn("before add_rule", errors[0])
+        self.assertIn("unterminated", errors[1])
+        self.assertIn("Unknown access level 'owner'", errors[2])
+        self.assertIn("cannot grant 'none'", errors[3])
+        self.assertIn("unknown placeholder", errors[4])
+
+    def test_empty_builder(self):
+        """A builder with no rules builds an empty file."""
+        self.assertEqual(PermissionFileBuilder().build().rules, [])
+
+
+if __name__ == "__main__":
+    unittest.main()
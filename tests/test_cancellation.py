@@ -0,0 +1,98 @@
+"""Tests for aborting resolutions through a Cancellation."""
+
+import shutil
+import sys
+import tempfile
+import threading
+import unittest
+from pathlib import Path
+from unittest.mock import patch
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    Cancellation,
+    ResolutionCancelled,
+    ResolutionTimeout,
+    Resolver,
+)
+from syft_perm.core import resolver as resolver_module  # noqa: E402
+
+GRANT = """rules:
+- pattern: "**"
+  access:
+    read: ["*"]
+"""
+
+
+class TestCancellation(unittest.TestCase):
+    """Test that resolver methods stop at directory boundaries once cancelled."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        self.resolver = Resolver(self.test_dir)
+        for depth in range(5):
+            directory = self.test_dir.joinpath(*[f"d{i}" for i in range(depth)])
+            directory.mkdir(parents=True, exist_ok=True)
+            (directory / "syft.pub.yaml").write_text(GRANT)
+            (directory / "file.txt").write_text("x")
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_no_cancellation_resolves_normally(self):
+        """An unexpired, uncancelled Cancellation doesn't change results."""
+        cancel = Cancellation(timeout=60)
+        path = "d0/d1/d2/d3/file.txt"
+        self.assertEqual(self.resolver.resolve(path, "alice@example.com", cancel), AccessLevel.READ)
+        self.assertEqual(len(list(self.resolver.walk("alice@example.com", cancel=cancel))), 5)
+
+    def test_cancel_mid_resolution(self):
+        """Cancelling while the chain is loading aborts before the next directory."""
+        cancel = Cancellation()
+        real_load = resolver_module.load_permission_file
+
+        def load_then_cancel(path, **kwargs):
+            cancel.cancel()
+            return real_load(path, **kwargs)
+
+        with patch.object(
+            resolver_module, "load_permission_file", side_effect=load_then_cancel
+        ) as load_mock:
+            with self.assertRaises(ResolutionCancelled):
+                self.resolver.resolve("d0/d1/d2/d3/file.txt", "alice@example.com", cancel)
+        self.assertEqual(load_mock.call_count, 1)
+
+    def test_cancel_mid_walk(self):
+        """A walk raises at the next directory after being cancelled from the loop."""
+        cancel = Cancellation()
+        seen = []
+        with self.assertRaises(ResolutionCancelled):
+            for rel_path, _ in self.resolver.walk("alice@example.com", cancel=cancel):
+                seen.append(rel_path)
+                cancel.cancel()
+        self.assertEqual(seen, ["file.txt"])
+
+    def test_cancel_from_another_thread(self):
+        """A shared event set elsewhere stops a batch."""
+        event = threading.Event()
+        cancel = Cancellation(event=event)
+        threading.Thread(target=event.set).start()
+        event.wait()
+        with self.assertRaises(ResolutionCancelled):
+            self.resolver.resolve_batch(["file.txt"], "alice@example.com", cancel)
+
+    def test_timeout(self):
+        """An expired timeout raises ResolutionTimeout, which is also a TimeoutError."""
+        cancel = Cancellation(timeout=0)
+        with self.assertRaises(ResolutionTimeout):
+            self.resolver.users_with_access("file.txt", cancel=cancel)
+        with self.assertRaises(TimeoutError):
+            self.resolver.check_access("file.txt", "alice@example.com", AccessLevel.READ, cancel)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,45 @@
+"""Tests for feature detection of the matcher's extended glob support."""
+
+import dataclasses
+import sys
+import unittest
+from pathlib import Path
+from unittest.mock import patch
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import MatchCapabilities, capabilities, path_matching  # noqa: E402
+
+
+class TestCapabilities(unittest.TestCase):
+    """Test that capabilities reports what the matcher really supports."""
+
+    def tearDown(self):
+        """Drop probe results so other tests see the real matcher's."""
+        capabilities.cache_clear()
+
+    def test_all_features_supported(self):
+        """This build supports every extended feature the struct describes."""
+        supported = capabilities()
+        self.assertIsInstance(supported, MatchCapabilities)
+        for feature in dataclasses.fields(MatchCapabilities):
+            with self.subTest(feature=feature.name):
+                self.assertTrue(getattr(supported, feature.name))
+
+    def test_cached_and_frozen(self):
+        """Probing runs once, and the result can't be changed by callers."""
+        self.assertIs(capabilities(), capabilities())
+        with self.assertRaises(dataclasses.FrozenInstanceError):
+            capabilities().braces = False
+
+    def test_probes_the_matcher(self):
+        """A matcher without brace expansion is reported as lacking it."""
+        capabilities.cache_clear()
+        with patch.object(path_matching, "_expand_braces", side_effect=lambda p: (p,)):
+            supported = capabilities()
+        self.assertFalse(supported.braces)
+        self.assertTrue(supported.negation)
+
+
+if __name__ == "__main__":
+    unittest.main()
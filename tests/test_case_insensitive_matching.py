@@ -0,0 +1,70 @@
+"""Tests for opt-in case-insensitive glob matching."""
+
+import sys
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import MatchOptions, PatternMatcher, match, match_fold  # noqa: E402
+
+
+class TestCaseInsensitiveMatching(unittest.TestCase):
+    """Test MatchOptions(case_insensitive=True) and the match_fold helper."""
+
+    def test_default_matching_is_case_sensitive(self):
+        """Without options patterns keep the case-sensitive Linux behavior."""
+        self.assertFalse(match("*.CSV", "report.csv"))
+        self.assertFalse(match("**/*.TXT", "a/b/c.txt"))
+        self.assertFalse(match("*.CSV", "report.csv", MatchOptions()))
+        self.assertTrue(match("*.csv", "report.csv"))
+
+    def test_fold_matches_extension_case(self):
+        """An upper-case extension pattern matches a lower-case file when folding."""
+        self.assertTrue(match_fold("*.CSV", "report.csv"))
+        self.assertTrue(match("*.CSV", "report.csv", MatchOptions(case_insensitive=True)))
+
+    def test_fold_doublestar_nested(self):
+        """**/*.TXT matches a/b/c.txt at any depth when folding."""
+        self.assertTrue(match_fold("**/*.TXT", "a/b/c.txt"))
+        self.assertTrue(match_fold("**/*.TXT", "c.txt"))
+        self.assertFalse(match_fold("**/*.TXT", "a/b/c.csv"))
+
+    def test_fold_mixed_case_nested_paths(self):
+        """Directory segments are folded just like file names."""
+        self.assertTrue(match_fold("Data/Reports/**", "data/REPORTS/q1/Summary.csv"))
+        self.assertTrue(match_fold("src/**/Docs/*.MD", "SRC/lib/docs/ReadMe.md"))
+        self.assertFalse(match_fold("Data/Reports/**", "data/archive/q1.csv"))
+
+    def test_fold_keeps_single_star_within_segment(self):
+        """Folding must not let a single * cross a directory boundary."""
+        self.assertTrue(match_fold("DATA/*", "data/file.txt"))
+        self.assertFalse(match_fold("DATA/*", "data/sub/file.txt"))
+
+    def test_fold_non_ascii(self):
+        """Non-ASCII characters fold with plain lower-casing semantics."""
+        self.assertTrue(match_fold("ÜBER/*.CSV", "über/daten.csv"))
+        self.assertTrue(match_fold("*.TXT", "fıle.txt"))
+        # "I" lower-cases to "i", never to the Turkish dotless "ı"
+        self.assertFalse(match_fold("DIŞ/*.csv", "dış/a.csv"))
+        self.assertTrue(match_fold("DIŞ/*.csv", "diş/a.csv"))
+
+
+    def test_fold_negated_class(self):
+        """A negated class rejects both cases of its members and nothing else."""
+        self.assertFalse(match_fold("[!A].txt", "a.txt"))
+        self.assertFalse(match_fold("[!A].txt", "A.txt"))
+        self.assertFalse(match_fold("[!a].txt", "A.txt"))
+        self.assertTrue(match_fold("[!A].txt", "b.txt"))
+
+    def test_fold_class_range(self):
+        """A range keeps its members and matches either case of its letters."""
+        self.assertTrue(match_fold("[A-Z].txt", "q.txt"))
+        self.assertTrue(match_fold("[A-Z].txt", "Q.txt"))
+        self.assertFalse(match_fold("[A-Z].txt", "1.txt"))
+        self.assertTrue(match_fold("[A-z].txt", "_.txt"))
+        matcher = PatternMatcher("[A-Z]/*", MatchOptions(case_insensitive=True))
+        self.assertTrue(matcher.match_path("q/a.txt"))
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,123 @@
+"""Tests for [...] character classes: ranges, negation and escapes."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    Resolver,
+    compile_pattern,
+    match,
+    match_fold,
+    parse_permission_file,
+)
+
+CASES = [
+    # Ranges
+    ("data/[0-9]*.csv", "data/2024.csv", True),
+    ("data/[0-9]*.csv", "data/q1.csv", False),
+    ("[0-9]", "7", True),
+    ("[0-9]", "a", False),
+    ("[a-cx-z]", "y", True),
+    ("[a-cx-z]", "d", False),
+    ("[a-]", "-", True),
+    ("[-a]", "-", True),
+    # Negation
+    ("[!a-z]x", "Ax", True),
+    ("[!a-z]x", "ax", False),
+    ("[^0-9].txt", "a.txt", True),
+    ("[^0-9].txt", "1.txt", False),
+    # ] as a member, first or escaped
+    ("[]a]x", "]x", True),
+    ("[]a]x", "ax", True),
+    ("[a\\]]x", "]x", True),
+    ("[a\\]]x", "\\x", False),
+    ("[!\\]]x", "]x", False),
+    ("[!\\]]x", "bx", True),
+    ("[\\-a]", "-", True),
+    ("[a\\-z]", "m", False),
+    # Never /
+    ("a[/]b", "a/b", False),
+    ("a[!x]b", "a/b", False),
+    ("a[^a-z]b", "a/b", False),
+    # A class matches one character, not its own spelling
+    ("[ab]", "[ab]", False),
+    ("x/[ab]/**", "x/[ab]/y.txt", False),
+    ("x/[ab]/**", "x/b/y.txt", True),
+    # Combined with ** and braces
+    ("**/[0-9][0-9].log", "a/b/42.log", True),
+    ("**/[0-9][0-9].log", "a/b/4x.log", False),
+    ("{a,[,b]}.txt", ",.txt", True),
+    ("{a,[,b]}.txt", "b.txt", True),
+    ("{a,[,b]}.txt", "[.txt", False),
+]
+
+
+class TestCharacterClasses(unittest.TestCase):
+    """Test character classes against every matching entry point."""
+
+    def test_match_functions(self):
+        """Plain and compiled matching agree on every case."""
+        for pattern, path, expected in CASES:
+            with self.subTest(pattern=pattern, path=path):
+                self.assertEqual(match(pattern, path), expected)
+                self.assertEqual(compile_pattern(pattern).match_path(path), expected)
+
+    def test_digit_range_vs_letter(self):
+        """``[0-9]`` takes any digit and no letter, in either case."""
+        for char in "0123456789":
+            self.assertTrue(match("[0-9]", char))
+        for char in "aZ":
+            self.assertFalse(match("[0-9]", char))
+            self.assertFalse(match_fold("[0-9]", char))
+
+    def test_case_folding(self):
+        """Ignoring case, a lowercase range covers uppercase letters too."""
+        self.assertFalse(match("[a-z].txt", "Q.txt"))
+        self.assertTrue(match_fold("[a-z].txt", "Q.txt"))
+
+    def test_validation(self):
+        """Classes that are never closed are rejected when loading rules."""
+        for pattern in ("[]", "[abc", "[a\\]", "data/[!"):
+            with self.subTest(pattern=pattern):
+                with self.assertRaises(ValueError):
+                    parse_permission_file(f"rules:\n- pattern: '{pattern}'\n")
+        parse_permission_file("rules:\n- pattern: '[]]'\n")
+
+
+class TestCharacterClassResolution(unittest.TestCase):
+    """Test character classes in rules read from disk."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        (self.test_dir / "syft.pub.yaml").write_text(
+            """rules:
+- pattern: "data/[0-9]*.csv"
+  access:
+    read: [bob@example.com]
+- pattern: "data/[!0-9]*.csv"
+  access:
+    write: [bob@example.com]
+"""
+        )
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_resolution(self):
+        """Files are told apart by their first character."""
+        resolver = Resolver(self.test_dir)
+        self.assertEqual(resolver.resolve("data/2024.csv", "bob@example.com"), AccessLevel.READ)
+        self.assertEqual(resolver.resolve("data/raw.csv", "bob@example.com"), AccessLevel.WRITE)
+        self.assertEqual(resolver.resolve("data/x/1.csv", "bob@example.com"), AccessLevel.NONE)
+
+
+if __name__ == "__main__":
+    unittest.main()
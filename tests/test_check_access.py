@@ -0,0 +1,70 @@
+"""Tests for the resolver's boolean access helpers."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import AccessLevel, Resolver  # noqa: E402
+
+
+class TestCheckAccess(unittest.TestCase):
+    """Test can_read/can_create/can_write/can_admin against resolve()."""
+
+    def setUp(self):
+        """Create a temporary datasite with one user per access level."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        (self.test_dir / "syft.pub.yaml").write_text(
+            """rules:
+- pattern: "**"
+  access:
+    read: [reader@example.com]
+    create: [creator@example.com]
+    write: [writer@example.com]
+    admin: [admin@example.com]
+"""
+        )
+        self.resolver = Resolver(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_hierarchy(self):
+        """Higher levels imply every lower one."""
+        expected = {
+            "reader@example.com": (True, False, False, False),
+            "creator@example.com": (True, True, False, False),
+            "writer@example.com": (True, True, True, False),
+            "admin@example.com": (True, True, True, True),
+            "nobody@example.com": (False, False, False, False),
+        }
+        for user, flags in expected.items():
+            actual = (
+                self.resolver.can_read("a/b.txt", user),
+                self.resolver.can_create("a/b.txt", user),
+                self.resolver.can_write("a/b.txt", user),
+                self.resolver.can_admin("a/b.txt", user),
+            )
+            self.assertEqual(actual, flags, user)
+
+    def test_consistent_with_resolve(self):
+        """check_access agrees with comparing resolve() against the level."""
+        for user in ["reader@example.com", "writer@example.com", "nobody@example.com"]:
+            level = self.resolver.resolve("x.txt", user)
+            for required in AccessLevel:
+                self.assertEqual(
+                    self.resolver.check_access("x.txt", user, required), level >= required
+                )
+
+    def test_errors_propagate(self):
+        """Paths outside the datasite raise like resolve() does."""
+        with self.assertRaises(ValueError):
+            self.resolver.can_read(Path("/elsewhere/file.txt"), "reader@example.com")
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,124 @@
+"""Tests for the syftperm command-line tool."""
+
+import io
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.cli import EXIT_DENIED, EXIT_ERROR, EXIT_OK, main  # noqa: E402
+
+
+class TestCli(unittest.TestCase):
+    """Test the check, validate and list subcommands and their exit codes."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "data/*.csv"
+  access:
+    write: [alice@example.com]
+- pattern: "**"
+  access:
+    read: [alice@example.com]
+    admin: ["{owner}"]
+""",
+        )
+        write_file(self.test_dir, "data/file.csv", "1,2")
+        write_file(self.test_dir, "notes.txt", "hello")
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def _run(self, *args):
+        out, err = io.StringIO(), io.StringIO()
+        status = main(list(args), out, err)
+        return status, out.getvalue(), err.getvalue()
+
+    def test_check(self):
+        """The effective level is printed and the exit code says if it is enough."""
+        check = ["check", "--root", str(self.test_dir)]
+        status, out, _ = self._run(*check, "--user", "alice@example.com", "data/file.csv")
+        self.assertEqual((status, out), (EXIT_OK, "write\n"))
+
+        status, out, _ = self._run(*check, "--user", "bob@example.com", "notes.txt")
+        self.assertEqual((status, out), (EXIT_DENIED, "none\n"))
+
+        status, _, _ = self._run(*check, "--user", "alice@example.com", "--level", "admin", "a")
+        self.assertEqual(status, EXIT_DENIED)
+
+    def test_check_trace(self):
+        """With --trace every rule considered is listed and the applied one is marked."""
+        status, out, _ = self._run(
+            "check", "--root", str(self.test_dir), "--user", "alice@example.com", "--trace", "a.txt"
+        )
+        self.assertEqual(status, EXIT_OK)
+        lines = out.splitlines()
+        self.assertEqual(lines[0], "read")
+        self.assertEqual(
+            lines[1:],
+            [
+                "  syft.pub.yaml rule 0 ('data/*.csv'): pattern did not match",
+                "* syft.pub.yaml rule 1 ('**'): applied -> read",
+            ],
+        )
+
+    def test_validate(self):
+        """Valid files pass with warnings, malformed ones fail and missing ones are errors."""
+        good = str(self.test_dir / "syft.pub.yaml")
+        write_file(self.test_dir, "bad/syft.pub.yaml", 'rules:\n- pattern: "[oops"\n')
+        bad = str(self.test_dir / "bad" / "syft.pub.yaml")
+
+        status, out, _ = self._run("validate", good)
+        self.assertEqual(status, EXIT_OK)
+        self.assertEqual(
+            out.splitlines(),
+            [
+                f"{good}: warning: rules 0 and 1 overlap (subset) and grant alice@example.com"
+                " 'write' vs 'read'; rule 0 applies where both match",
+                f"{good}: ok (2 rules)",
+            ],
+        )
+        status, out, _ = self._run("validate", good, bad)
+        self.assertEqual(status, EXIT_DENIED)
+        self.assertIn("unterminated character class", out)
+
+        status, _, err = self._run("validate", str(self.test_dir / "missing.yaml"))
+        self.assertEqual(status, EXIT_ERROR)
+        self.assertIn("permission file not found", err)
+
+    def test_list(self):
+        """Every file the user can access is listed with its level."""
+        list_files = ["list", "--root", str(self.test_dir)]
+        status, out, _ = self._run(*list_files, "--user", "alice@example.com")
+        self.assertEqual(status, EXIT_OK)
+        self.assertEqual(out, "read\tnotes.txt\nwrite\tdata/file.csv\n")
+
+        owner = ["--user", "x@example.com", "--owner", "x@example.com", "--level", "admin"]
+        self.assertEqual(self._run(*list_files, *owner)[1], "admin\tnotes.txt\n")
+        self.assertEqual(self._run(*list_files, "--user", "bob@example.com")[1], "")
+
+    def test_errors(self):
+        """A missing datasite or a malformed permission file exits with the error code."""
+        missing = str(self.test_dir / "nope")
+        status, _, err = self._run("check", "--root", missing, "--user", "a", "x")
+        self.assertEqual(status, EXIT_ERROR)
+        self.assertIn("is not a directory", err)
+
+        write_file(self.test_dir, "syft.pub.yaml", "rules: [")
+        status, _, err = self._run("check", "--root", str(self.test_dir), "--user", "a", "x")
+        self.assertEqual(status, EXIT_ERROR)
+        self.assertIn("invalid yaml", err)
+
+
+if __name__ == "__main__":
+    unittest.main()
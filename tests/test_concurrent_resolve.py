@@ -0,0 +1,100 @@
+"""Tests for resolving from many threads against shared permission files."""
+
+import sys
+import threading
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    PermissionCache,
+    Resolver,
+    clear_pattern_cache,
+    parse_permission_file,
+)
+
+THREADS = 100
+
+RULES = """rules:
+- pattern: "**/*.csv"
+  access:
+    read: ["*"]
+- pattern: "{docs,notes}/**"
+  access:
+    write: [bob@example.com]
+- pattern: "private/**"
+  terminal: true
+  access:
+    admin: [carol@example.com]
+- pattern: "!**/*.tmp"
+"""
+
+PATHS = [f"{folder}/{name}" for folder in ("data", "docs", "private") for name in "abc"]
+PATHS += [f"{path}.csv" for path in PATHS] + [f"{path}.tmp" for path in PATHS]
+USERS = ["bob@example.com", "carol@example.com", "dave@example.com"]
+
+
+def _hammer(work):
+    """Run work on THREADS threads released together and collect what they raise."""
+    barrier = threading.Barrier(THREADS)
+    errors = []
+
+    def run(index):
+        barrier.wait()
+        try:
+            work(index)
+        except Exception as e:  # noqa: BLE001 - reported to the test below
+            errors.append(e)
+
+    threads = [threading.Thread(target=run, args=(i,)) for i in range(THREADS)]
+    for thread in threads:
+        thread.start()
+    for thread in threads:
+        thread.join()
+    return errors
+
+
+class TestConcurrentResolve(unittest.TestCase):
+    """Test that read-only resolution needs no locking by the caller."""
+
+    def test_shared_permission_file(self):
+        """Threads sharing one resolver and one parsed file all see the serial result."""
+        perm_file = parse_permission_file(RULES, Path("syft.pub.yaml"))
+        resolver = Resolver(Path("/unused"), stat_func=None, permission_files={"": perm_file})
+        expected = {(path, user): resolver.resolve(path, user) for path in PATHS for user in USERS}
+        snapshot = perm_file.to_dict()
+        # Empty the shared cache so threads race to compile the same patterns
+        clear_pattern_cache()
+        mismatches = []
+
+        def work(index):
+            for _ in range(5):
+                for path in PATHS:
+                    user = USERS[index % len(USERS)]
+                    level = resolver.resolve(path, user)
+                    if level != expected[(path, user)]:
+                        mismatches.append((path, user, level))
+
+        self.assertEqual(_hammer(work), [])
+        self.assertEqual(mismatches, [])
+        self.assertEqual(perm_file.to_dict(), snapshot)
+
+    def test_shared_permission_cache(self):
+        """The permission lookup cache survives concurrent reads, writes and evictions."""
+        cache = PermissionCache(max_size=50)
+
+        def work(index):
+            for i in range(200):
+                path = f"/datasite/{index % 10}/{i % 80}"
+                cache.set(path, {"read": [f"user{index}@example.com"]})
+                cache.get(path)
+                if i % 25 == 0:
+                    cache.invalidate(f"/datasite/{index % 10}/")
+
+        self.assertEqual(_hammer(work), [])
+        self.assertLessEqual(len(cache.cache), 50)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,84 @@
+"""Tests for the resolver's configurable default access level."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import AccessLevel, Resolver  # noqa: E402
+
+
+class TestDefaultAccess(unittest.TestCase):
+    """Test the level returned when no rule matches a path."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "public/**"
+  access:
+    read: ["*"]
+- pattern: "shared/*.csv"
+  access:
+    write: [alice@example.com]
+- pattern: "!public/secret.txt"
+""",
+        )
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_unset_default_is_none(self):
+        """Without a default, unmatched paths resolve to NONE as before."""
+        resolver = Resolver(self.test_dir)
+        self.assertEqual(resolver.default_access, AccessLevel.NONE)
+        self.assertEqual(resolver.resolve("other/file.txt", "bob@example.com"), AccessLevel.NONE)
+
+    def test_default_applies_only_without_match(self):
+        """Unmatched paths get the default; matched paths keep their rule's decision."""
+        resolver = Resolver(self.test_dir, default_access=AccessLevel.READ)
+        self.assertEqual(resolver.resolve("other/file.txt", "bob@example.com"), AccessLevel.READ)
+        self.assertEqual(resolver.resolve("shared/a.csv", "alice@example.com"), AccessLevel.WRITE)
+        # A matching rule that doesn't list the user is an explicit decision
+        self.assertEqual(resolver.resolve("shared/a.csv", "bob@example.com"), AccessLevel.NONE)
+        # So is an exclusion
+        self.assertEqual(resolver.resolve("public/secret.txt", "bob@example.com"), AccessLevel.NONE)
+
+    def test_terminal_without_match_uses_default(self):
+        """A terminal file blocks inherited rules, leaving the default for unmatched paths."""
+        write_file(
+            self.test_dir,
+            "public/vault/syft.pub.yaml",
+            """terminal: true
+rules:
+- pattern: "*.key"
+  access:
+    read: [alice@example.com]
+""",
+        )
+        resolver = Resolver(self.test_dir, default_access=AccessLevel.CREATE)
+        # Without the terminal the root's public/** read grant would apply
+        self.assertEqual(
+            resolver.resolve("public/vault/notes.txt", "bob@example.com"), AccessLevel.CREATE
+        )
+        self.assertEqual(
+            resolver.resolve("public/vault/a.key", "bob@example.com"), AccessLevel.NONE
+        )
+
+    def test_batch_uses_default(self):
+        """resolve_batch honors the default like resolve does."""
+        resolver = Resolver(self.test_dir, default_access=AccessLevel.READ)
+        results = resolver.resolve_batch(["x.txt", "shared/a.csv"], "bob@example.com")
+        self.assertEqual(results, {"x.txt": AccessLevel.READ, "shared/a.csv": AccessLevel.NONE})
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,106 @@
+"""Tests for finding why a user has no access to a path."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import AccessLevel, DenialReason, Resolver  # noqa: E402
+
+ROOT_RULES = """rules:
+- pattern: "data/**"
+  access:
+    read: [bob@example.com]
+- pattern: "!data/secret/**"
+- pattern: "data/frozen/**"
+  revoke:
+    read: [bob@example.com]
+- pattern: "data/capped/**"
+  revoke:
+    write: [bob@example.com]
+- pattern: "big/**"
+  access:
+    read: [carol@example.com]
+  limits:
+    max_file_size: 1
+"""
+
+BOB = "bob@example.com"
+
+
+class TestDenialReason(unittest.TestCase):
+    """Test each reason a path can resolve to no access."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        write_file(self.test_dir, "syft.pub.yaml", ROOT_RULES)
+        write_file(self.test_dir, ".syftignore", "**/*.tmp\n")
+        self.resolver = Resolver(self.test_dir, ignore_files=True)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_granted(self):
+        """There is no denial reason when the user has some access."""
+        self.assertIsNone(self.resolver.denial_reason("data/a.csv", BOB))
+        self.assertIsNone(self.resolver.denial_reason("data/capped/a.csv", BOB))
+
+    def test_no_matching_rule(self):
+        """A path no rule covers falls back to the default."""
+        self.assertEqual(
+            self.resolver.denial_reason("docs/a.txt", BOB), DenialReason.NO_MATCHING_RULE
+        )
+
+    def test_limits_exceeded(self):
+        """A rule blocked by its file limits doesn't decide the path."""
+        write_file(self.test_dir, "big/huge.bin", "too large")
+        self.assertEqual(
+            self.resolver.denial_reason("big/huge.bin", "carol@example.com"),
+            DenialReason.NO_MATCHING_RULE,
+        )
+
+    def test_user_not_listed(self):
+        """A matching rule that doesn't list the user denies them."""
+        self.assertEqual(
+            self.resolver.denial_reason("data/a.csv", "carol@example.com"),
+            DenialReason.USER_NOT_LISTED,
+        )
+
+    def test_excluded(self):
+        """An exclusion denies everyone."""
+        self.assertEqual(
+            self.resolver.denial_reason("data/secret/key.pem", BOB), DenialReason.EXCLUDED
+        )
+
+    def test_revoked(self):
+        """A revoke leaving the user nothing denies them."""
+        self.assertEqual(
+            self.resolver.denial_reason("data/frozen/a.csv", BOB), DenialReason.REVOKED
+        )
+
+    def test_ignored(self):
+        """An ignore file takes precedence over any rule."""
+        self.assertEqual(self.resolver.denial_reason("data/a.tmp", BOB), DenialReason.IGNORED)
+        self.assertEqual(
+            self.resolver.denial_reason("data/secret/a.tmp", BOB), DenialReason.IGNORED
+        )
+
+    def test_always_set_when_denied(self):
+        """Every path resolving to NONE has a reason, and only those."""
+        paths = ["data/a.csv", "data/secret/x", "data/frozen/x", "data/capped/x", "a.tmp", "x"]
+        for path in paths:
+            for user in (BOB, "carol@example.com"):
+                with self.subTest(path=path, user=user):
+                    denied = self.resolver.resolve(path, user) == AccessLevel.NONE
+                    reason = self.resolver.denial_reason(path, user)
+                    self.assertEqual(reason is not None, denied)
+
+
+if __name__ == "__main__":
+    unittest.main()
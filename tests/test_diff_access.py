@@ -0,0 +1,91 @@
+"""Tests for previewing access changes between two sets of permission files."""
+
+import sys
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessChange,
+    AccessLevel,
+    Resolver,
+    diff_access,
+    parse_permission_file,
+)
+
+ROOT = parse_permission_file(
+    """rules:
+- pattern: "**"
+  access:
+    read: ["*"]
+"""
+)
+
+
+class TestDiffAccess(unittest.TestCase):
+    """Test that only (path, user) pairs whose level changes are reported."""
+
+    def test_unchanged_ruleset_reports_nothing(self):
+        """Diffing a ruleset against itself is empty."""
+        self.assertEqual(diff_access(ROOT, ROOT, ["a.txt", "b/c.txt"], ["alice@example.com"]), [])
+
+    def test_reports_gains_and_losses(self):
+        """Raising one user and revoking another shows both, path by path."""
+        new = parse_permission_file(
+            """rules:
+- pattern: "*.csv"
+  access:
+    write: [alice@example.com]
+- pattern: "**"
+  access:
+    read: [alice@example.com]
+"""
+        )
+        changes = diff_access(
+            ROOT, new, ["data.csv", "readme.md"], ["alice@example.com", "bob@example.com"]
+        )
+        self.assertEqual(
+            changes,
+            [
+                AccessChange("data.csv", "alice@example.com", AccessLevel.READ, AccessLevel.WRITE),
+                AccessChange("data.csv", "bob@example.com", AccessLevel.READ, AccessLevel.NONE),
+                AccessChange("readme.md", "bob@example.com", AccessLevel.READ, AccessLevel.NONE),
+            ],
+        )
+        self.assertTrue(changes[0].gained)
+        self.assertTrue(changes[1].lost)
+
+    def test_nested_permission_files(self):
+        """Adding a terminal file below the root only changes the paths beneath it."""
+        vault = parse_permission_file(
+            """terminal: true
+rules:
+- pattern: "**"
+  access:
+    admin: [alice@example.com]
+"""
+        )
+        changes = diff_access(
+            {"": ROOT},
+            {"": ROOT, "vault": vault},
+            ["readme.md", "vault/keys.pem"],
+            ["alice@example.com", "bob@example.com"],
+        )
+        self.assertEqual(
+            [(c.path, c.user, c.after) for c in changes],
+            [
+                ("vault/keys.pem", "alice@example.com", AccessLevel.ADMIN),
+                ("vault/keys.pem", "bob@example.com", AccessLevel.NONE),
+            ],
+        )
+
+    def test_in_memory_resolver_reads_no_files(self):
+        """The resolver used for diffing never looks at the root directory."""
+        resolver = Resolver("/nonexistent", stat_func=None, permission_files={".": ROOT, "b": ROOT})
+        self.assertEqual(resolver.resolve("b/c.txt", "x@example.com"), AccessLevel.READ)
+        self.assertEqual(resolver.resolve("./b/c.txt", "x@example.com"), AccessLevel.READ)
+
+
+if __name__ == "__main__":
+    unittest.main()
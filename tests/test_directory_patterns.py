@@ -0,0 +1,152 @@
+"""Tests for patterns ending in / matching only directories."""
+
+import os
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    Resolver,
+    TraceReason,
+    UnreachableRule,
+    parse_permission_file,
+)
+
+DIRECTORY_ONLY = """rules:
+- pattern: "data/**/"
+  access:
+    write: [bob@example.com]
+- pattern: "data/**"
+  access:
+    read: [bob@example.com]
+"""
+
+
+class TestDirectoryPatterns(unittest.TestCase):
+    """Test that a trailing slash tells directories and files apart."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        write_file(self.test_dir, "syft.pub.yaml", DIRECTORY_ONLY)
+        (self.test_dir / "data" / "reports").mkdir(parents=True)
+        write_file(self.test_dir, "data/reports/q1.csv", "1")
+        write_file(self.test_dir, "data/notes.txt", "2")
+        self.resolver = Resolver(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def _resolve(self, path, **kwargs):
+        return self.resolver.resolve(path, "bob@example.com", **kwargs)
+
+    def test_statted_dir_vs_file(self):
+        """The same base pattern gives directories and files different levels."""
+        self.assertEqual(self._resolve("data/reports"), AccessLevel.WRITE)
+        self.assertEqual(self._resolve("data/reports/q1.csv"), AccessLevel.READ)
+        self.assertEqual(self._resolve("data/notes.txt"), AccessLevel.READ)
+
+    def test_given_type(self):
+        """A caller-supplied is_dir is trusted without statting the path."""
+        calls = []
+
+        def stat_func(path):
+            calls.append(path)
+            return os.lstat(path)
+
+        resolver = Resolver(self.test_dir, stat_func=stat_func)
+        self.assertEqual(
+            resolver.resolve("data/later", "bob@example.com", is_dir=True), AccessLevel.WRITE
+        )
+        self.assertEqual(
+            resolver.resolve("data/reports", "bob@example.com", is_dir=False), AccessLevel.READ
+        )
+        self.assertEqual(calls, [])
+        resolver.resolve("data/reports", "bob@example.com")
+        self.assertEqual(len(calls), 1)
+
+    def test_unknown_type_ignores_slash(self):
+        """Without a way to tell, a directory-only rule still matches."""
+        self.assertEqual(self._resolve("data/missing.csv"), AccessLevel.WRITE)
+        resolver = Resolver(self.test_dir, stat_func=None)
+        self.assertEqual(resolver.resolve("data/notes.txt", "bob@example.com"), AccessLevel.WRITE)
+
+    def test_directory_only_terminal(self):
+        """A directory-only terminal rule doesn't cut files off from nested rules."""
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "shared/*/"
+  terminal: true
+  access:
+    read: [bob@example.com]
+""",
+        )
+        write_file(
+            self.test_dir,
+            "shared/team/syft.pub.yaml",
+            'rules:\n- pattern: "*.txt"\n  access:\n    write: [bob@example.com]\n',
+        )
+        (self.test_dir / "shared" / "team").mkdir(parents=True, exist_ok=True)
+        self.assertEqual(self._resolve("shared/team"), AccessLevel.READ)
+        self.assertEqual(self._resolve("shared/team/a.txt", is_dir=False), AccessLevel.WRITE)
+
+    def test_walk_treats_entries_as_files(self):
+        """Walking yields files, which directory-only rules never match."""
+        listed = dict(self.resolver.walk("bob@example.com"))
+        self.assertEqual(listed["data/reports/q1.csv"], AccessLevel.READ)
+
+    def test_resolve_dir(self):
+        """A folder's own level comes from rules that match it as a directory."""
+        level, _ = self.resolver.resolve_dir("data/reports", "bob@example.com")
+        self.assertEqual(level, AccessLevel.WRITE)
+
+    def test_trace(self):
+        """A directory-only rule skipped for a file says why."""
+        _, trace = self.resolver.resolve_with_trace("data/notes.txt", "bob@example.com")
+        self.assertEqual(trace[0].reason, TraceReason.NOT_A_DIRECTORY)
+        self.assertFalse(trace[0].matched)
+        self.assertEqual(trace[1].reason, TraceReason.APPLIED)
+
+
+class TestDirectoryPatternModel(unittest.TestCase):
+    """Test how directory-only rules are modeled and validated."""
+
+    def test_rule_property(self):
+        """Rules know whether a trailing slash limits them, with or without ``!``."""
+        perm_file = parse_permission_file(
+            'rules:\n- pattern: "a/"\n- pattern: "!a/**/"\n- pattern: "a/**"\n'
+        )
+        self.assertEqual([r.is_directory_only for r in perm_file.rules], [True, True, False])
+
+    def test_terminal_directory_rule_shadows_no_files(self):
+        """A terminal directory-only rule doesn't make file rules unreachable."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "**/"
+  terminal: true
+  priority: 1
+  access:
+    read: ["*"]
+- pattern: "*.csv"
+  access:
+    write: [bob@example.com]
+- pattern: "data/"
+  access:
+    write: [bob@example.com]
+"""
+        )
+        findings = [f for f in perm_file.validate() if isinstance(f, UnreachableRule)]
+        self.assertEqual([f.index for f in findings], [2])
+
+
+if __name__ == "__main__":
+    unittest.main()
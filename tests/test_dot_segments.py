@@ -0,0 +1,88 @@
+"""Tests for refusing .. segments in patterns and queried paths."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    DotSegments,
+    PathEscapesRootError,
+    PatternSyntaxError,
+    Resolver,
+    parse_permission_file,
+)
+
+
+class TestDotSegmentPatterns(unittest.TestCase):
+    """Test that patterns climbing out of their directory are rejected at load time."""
+
+    def test_malicious_patterns_rejected(self):
+        """A .. segment anywhere, including inside braces, fails validation."""
+        for pattern in ["data/../secrets/*", "../**", "**/..", "{a,..}/x.txt"]:
+            with self.subTest(pattern=pattern):
+                with self.assertRaises(PatternSyntaxError) as ctx:
+                    parse_permission_file(f'rules:\n- pattern: "{pattern}"\n')
+                self.assertIn("'..' segments", str(ctx.exception))
+
+    def test_dots_in_names_allowed(self):
+        """Names merely containing dots are fine."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "..data/*"
+- pattern: "notes..txt"
+- pattern: "**/.config/*"
+"""
+        )
+        self.assertEqual(len(perm_file.rules), 3)
+
+
+class TestDotSegmentPaths(unittest.TestCase):
+    """Test the REJECT and CLEAN policies for queried paths."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        (self.test_dir / "data").mkdir()
+        (self.test_dir / "data" / "syft.pub.yaml").write_text(
+            """rules:
+- pattern: "**"
+  access:
+    read: ["*"]
+"""
+        )
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_rejected_by_default(self):
+        """Any .. segment is refused, even one that stays inside the datasite."""
+        resolver = Resolver(self.test_dir)
+        for path in ["data/../secrets/key.pem", "../other@example.com/x", "data/.."]:
+            with self.subTest(path=path):
+                with self.assertRaises(PathEscapesRootError) as ctx:
+                    resolver.resolve(path, "bob@example.com")
+                self.assertEqual(ctx.exception.reason, "'..' segments are not allowed")
+        with self.assertRaises(PathEscapesRootError):
+            resolver.resolve(self.test_dir / "data" / ".." / "x.txt", "bob@example.com")
+        self.assertEqual(resolver.resolve("data/..x/a.txt", "bob@example.com"), AccessLevel.READ)
+
+    def test_cleaned_when_allowed(self):
+        """With CLEAN, paths are resolved where they lead as long as that is inside."""
+        resolver = Resolver(self.test_dir, dot_segments=DotSegments.CLEAN)
+        self.assertEqual(resolver.resolve("data/../secrets/key.pem", "bob"), AccessLevel.NONE)
+        self.assertEqual(resolver.resolve("secrets/../data/a.txt", "bob"), AccessLevel.READ)
+        for path in ["../other@example.com/x", "data/../../x", ".."]:
+            with self.subTest(path=path):
+                with self.assertRaises(PathEscapesRootError) as ctx:
+                    resolver.resolve(path, "bob")
+                self.assertIsNone(ctx.exception.reason)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,85 @@
+"""Tests for whether wildcards match names starting with a dot."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    MatchOptions,
+    Resolver,
+    compile_pattern,
+    match,
+)
+
+NO_DOTFILES = MatchOptions(match_dotfiles=False)
+
+
+class TestMatchDotfiles(unittest.TestCase):
+    """Test the match_dotfiles option through match() and compiled patterns."""
+
+    def _assert_matches(self, cases, options):
+        for pattern, path, expected in cases:
+            with self.subTest(pattern=pattern, path=path):
+                self.assertEqual(match(pattern, path, options), expected)
+                self.assertEqual(compile_pattern(pattern, options).match_path(path), expected)
+
+    def test_default_matches_dotfiles(self):
+        """By default wildcards match hidden names, like doublestar."""
+        self.assertTrue(MatchOptions().match_dotfiles)
+        self._assert_matches(
+            [
+                ("**/*", ".hidden", True),
+                ("**/*", "dir/.env", True),
+                ("**", ".git/config", True),
+                ("dir/*", "dir/.env", True),
+            ],
+            None,
+        )
+
+    def test_dotfiles_off(self):
+        """With the option off, only a literal leading dot matches a hidden name."""
+        self._assert_matches(
+            [
+                ("**/*", ".hidden", False),
+                ("**/*", "dir/.env", False),
+                ("**", ".git/config", False),
+                ("dir/*", "dir/.env", False),
+                ("?env", ".env", False),
+                ("**/*", "dir/file.txt", True),
+                ("**/.env", "dir/.env", True),
+                (".*", ".env", True),
+                (".git/**", ".git/config", True),
+                ("{*,.env}", ".env", True),
+                ("dir/.env", "dir/.env", True),
+            ],
+            NO_DOTFILES,
+        )
+
+    def test_resolver_honors_option(self):
+        """A resolver built with the option leaves hidden files to explicit rules."""
+        test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        self.addCleanup(shutil.rmtree, test_dir, ignore_errors=True)
+        (test_dir / "syft.pub.yaml").write_text(
+            """rules:
+- pattern: "**/.env"
+  access:
+    admin: [alice@example.com]
+- pattern: "**"
+  access:
+    read: ["*"]
+"""
+        )
+        resolver = Resolver(test_dir, match_options=NO_DOTFILES)
+        self.assertEqual(resolver.resolve("app/.env", "bob@example.com"), AccessLevel.NONE)
+        self.assertEqual(resolver.resolve("app/.env", "alice@example.com"), AccessLevel.ADMIN)
+        self.assertEqual(resolver.resolve(".cache/x", "bob@example.com"), AccessLevel.NONE)
+        self.assertEqual(resolver.resolve("app/main.py", "bob@example.com"), AccessLevel.READ)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,90 @@
+"""
+Correctness guards for patterns with several ** segments.
+
+Their timings are measured by benchmarks/bench_doublestar.py, outside the test suite.
+"""
+
+import itertools
+import sys
+import unittest
+from pathlib import Path
+from unittest.mock import patch
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import match  # noqa: E402
+from syft_perm.core import path_matching  # noqa: E402
+
+PATTERNS = [
+    "src/**/docs/**/test/*.py",
+    "src/**/test/*.py",
+    "**/a/**/b/**/c.txt",
+    "**/*.py",
+    "data/**",
+    "data*/**/x",
+    "a**b",
+    "a/**/a",
+    "**/docs/**",
+    "**/te?t/**/*.p?",
+    "src/**/[dt]ocs/**",
+    "{src,lib}/**/test/*.py",
+]
+
+PATHS = [
+    "src/main/docs/api/test/test_api.py",
+    "src/utils/docs/guide/test/test_guide.py",
+    "src/core/internal/docs/security/test/test_auth.py",
+    "src/legacy/docs/test/basic.py",
+    "other/src/docs/test/not_matching.py",
+    "src/no_docs/test/also_not_matching.py",
+    "src/tools/docs/no_test/not_matching.py",
+    "src/web/docs/ui/test/final.js",
+    "lib/x/test/y.py",
+    "a/b/c.txt",
+    "a/x/b/y/c.txt",
+    "a/a",
+    "ab",
+    "a/b",
+    "data",
+    "data/x",
+    "data1/q/x",
+    "docs",
+    "x/docs/y",
+    "x/test/y/z.py",
+]
+
+
+def _deep_path(depth: int, tail: str) -> str:
+    middle = "/".join(f"d{i}" for i in range(depth))
+    return f"src/{middle}/docs/{middle}/{tail}"
+
+
+class TestDoublestarCorrectness(unittest.TestCase):
+    """The literal pre-check never changes a match result."""
+
+    def test_same_results_without_precheck(self):
+        """Every pattern agrees with the matcher running without the pre-check."""
+        fast = {(p, q): match(p, q) for p, q in itertools.product(PATTERNS, PATHS)}
+        with patch.object(path_matching, "_literal_runs_possible", return_value=True):
+            for (pattern, path), expected in fast.items():
+                self.assertEqual(match(pattern, path), expected, (pattern, path))
+
+    def test_deep_paths(self):
+        """Multi-** patterns still match at any depth."""
+        pattern = "src/**/docs/**/test/*.py"
+        for depth in (0, 1, 10, 100):
+            self.assertTrue(match(pattern, _deep_path(depth, "test/x.py")), depth)
+            self.assertFalse(match(pattern, _deep_path(depth, "tst/x.py")), depth)
+            self.assertFalse(match(pattern, _deep_path(depth, "test/x.pyc")), depth)
+
+    def test_repeated_segments_miss(self):
+        """Misses where every ** could split in many places are still misses."""
+        path = "/".join(["a", "b", "c"] * 64) + "/x.txt"
+        self.assertFalse(match("**/a/**/b/**/c/**/d.txt", path))
+        # The pre-check can't reject literals that only appear inside other segments
+        near_miss = "/".join(["ca", "cb", "cc"] * 64) + "/x.txt"
+        self.assertFalse(match("**/a/**/b/**/c/**/x.txt", near_miss))
+
+
+if __name__ == "__main__":
+    unittest.main()
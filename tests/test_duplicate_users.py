@@ -0,0 +1,79 @@
+"""Tests for users listed under several access levels of one rule."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    DuplicateUser,
+    Resolver,
+    parse_permission_file,
+)
+
+WRITE_AFTER_READ = """rules:
+- pattern: "**"
+  access:
+    write: [alice@example.com]
+    read: [alice@example.com, bob@example.com]
+"""
+
+READ_AFTER_WRITE = """rules:
+- pattern: "**"
+  access:
+    read: [alice@example.com, bob@example.com]
+    write: [Alice@Example.com]
+"""
+
+
+class TestDuplicateUsers(unittest.TestCase):
+    """Test that the highest listed level wins and the duplicate is reported."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_highest_level_wins(self):
+        """A user in both read and write resolves to write, in either order."""
+        for content in (WRITE_AFTER_READ, READ_AFTER_WRITE):
+            with self.subTest(content=content):
+                (self.test_dir / "syft.pub.yaml").write_text(content)
+                resolver = Resolver(self.test_dir)
+                self.assertEqual(resolver.resolve("a.txt", "alice@example.com"), AccessLevel.WRITE)
+                self.assertEqual(resolver.resolve("a.txt", "bob@example.com"), AccessLevel.READ)
+
+    def test_reported(self):
+        """validate names the user, the rule and every level it's listed under."""
+        findings = parse_permission_file(READ_AFTER_WRITE).validate()
+        self.assertEqual(len(findings), 1)
+        finding = findings[0]
+        self.assertIsInstance(finding, DuplicateUser)
+        self.assertEqual(finding.index, 0)
+        self.assertEqual(finding.user, "alice@example.com")
+        self.assertEqual(finding.levels, (AccessLevel.READ, AccessLevel.WRITE))
+        self.assertIn("rule 0 ('**') at syft.pub.yaml:2:3", str(finding))
+        self.assertIn("the highest, 'write', applies", str(finding))
+
+    def test_strict_users_differ(self):
+        """Parsed strict, spellings differing by case are different users."""
+        self.assertEqual(parse_permission_file(READ_AFTER_WRITE, strict_users=True).validate(), [])
+
+    def test_before_conflicts(self):
+        """Duplicates come before the findings that compare rules."""
+        perm_file = parse_permission_file(
+            WRITE_AFTER_READ + '- pattern: "*.txt"\n  access:\n    admin: [bob@example.com]\n'
+        )
+        kinds = [type(finding).__name__ for finding in perm_file.validate()]
+        self.assertEqual(kinds, ["DuplicateUser", "RuleConflict"])
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,107 @@
+"""Tests for matching user emails regardless of case."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+import syft_perm  # noqa: E402
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    PermissionStore,
+    Resolver,
+    Rule,
+    _user_matches,
+    canonical_user,
+    parse_permission_file,
+)
+
+MIXED_CASE = """rules:
+- pattern: "**"
+  access:
+    write: [Alice@Org.com, alice@org.com, ALICE@ORG.COM, Bob@Org.com]
+    read: [service-Account]
+"""
+
+
+class TestEmailCase(unittest.TestCase):
+    """Test that emails differing only by case are the same user by default."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        (self.test_dir / "syft.pub.yaml").write_text(MIXED_CASE)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_canonical_user(self):
+        """Only emails are lowercased."""
+        self.assertEqual(canonical_user("Alice@Org.com"), "alice@org.com")
+        self.assertEqual(canonical_user("service-Account"), "service-Account")
+        self.assertEqual(canonical_user("*"), "*")
+
+    def test_loading_lowercases_and_dedupes(self):
+        """Spellings of one email collapse into a single lowercase entry."""
+        rule = parse_permission_file(MIXED_CASE).rules[0]
+        self.assertEqual(rule.users_for(AccessLevel.WRITE), ["alice@org.com", "bob@org.com"])
+        self.assertEqual(rule.users_for(AccessLevel.READ), ["service-Account"])
+
+    def test_mixed_case_rule_and_query(self):
+        """Any spelling of the requesting user matches any spelling in the rule."""
+        resolver = Resolver(self.test_dir)
+        for user in ["alice@org.com", "Alice@Org.com", "BOB@org.COM"]:
+            with self.subTest(user=user):
+                self.assertEqual(resolver.resolve("a.txt", user), AccessLevel.WRITE)
+        self.assertEqual(resolver.resolve("a.txt", "service-account"), AccessLevel.NONE)
+        self.assertEqual(
+            resolver.users_with_access("a.txt", AccessLevel.WRITE),
+            (["alice@org.com", "bob@org.com"], False),
+        )
+
+    def test_rules_built_in_code(self):
+        """Rules that never went through the parser still match case-insensitively."""
+        rule = Rule("**", {AccessLevel.READ: ["Carol@Org.com"]})
+        self.assertEqual(rule.level_for("carol@org.COM"), AccessLevel.READ)
+        self.assertEqual(rule.level_for("carol@org.COM", strict=True), AccessLevel.NONE)
+
+    def test_owner_compared_ignoring_case(self):
+        """The datasite owner matches {owner} whatever the case of the request."""
+        self.assertTrue(_user_matches("{owner}", "ALICE@org.com", "alice@Org.com"))
+        self.assertFalse(_user_matches("{owner}", "ALICE@org.com", "alice@Org.com", True))
+
+    def test_strict_mode(self):
+        """Strict resolvers and stores keep entries as written and match exactly."""
+        rule = parse_permission_file(MIXED_CASE, strict_users=True).rules[0]
+        self.assertEqual(len(rule.users_for(AccessLevel.WRITE)), 4)
+
+        resolver = Resolver(self.test_dir, strict_users=True)
+        self.assertEqual(resolver.resolve("a.txt", "Alice@Org.com"), AccessLevel.WRITE)
+        self.assertEqual(resolver.resolve("a.txt", "bob@org.com"), AccessLevel.NONE)
+
+        (self.test_dir / "alice@org.com").mkdir()
+        (self.test_dir / "alice@org.com" / "syft.pub.yaml").write_text(MIXED_CASE)
+        store = PermissionStore(self.test_dir, strict_users=True)
+        store.reload_all()
+        snapshot = store.get("alice@org.com")
+        self.assertEqual(snapshot.resolve("a.txt", "bob@org.com"), AccessLevel.NONE)
+        self.assertEqual(snapshot.resolve("a.txt", "Bob@Org.com"), AccessLevel.WRITE)
+
+
+    def test_legacy_objects_match_exactly(self):
+        """SyftFile and SyftFolder keep comparing users exactly as listed."""
+        (self.test_dir / "a.txt").write_text("x")
+        (self.test_dir / "sub").mkdir()
+        for obj in (syft_perm.open(self.test_dir / "a.txt"), syft_perm.open(self.test_dir / "sub")):
+            with self.subTest(obj=type(obj).__name__):
+                self.assertTrue(obj.has_write_access("Alice@Org.com"))
+                self.assertFalse(obj.has_write_access("bob@org.com"))
+                self.assertFalse(obj._check_permission_with_reasons("bob@org.com", "read")[0])
+                self.assertTrue(obj._check_permission_with_reasons("Bob@Org.com", "read")[0])
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,95 @@
+"""Tests for the typed errors raised by the loader and resolver."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import (  # noqa: E402
+    InvalidPatternError,
+    PathEscapesRootError,
+    PatternSyntaxError,
+    PermissionFileNotFoundError,
+    Resolver,
+    SyftPermError,
+    UnknownAccessLevelError,
+    compile_pattern,
+    load_permission_file,
+    parse_access_level,
+    parse_permission_file,
+)
+
+
+class TestErrors(unittest.TestCase):
+    """Test that each failure has its own type carrying what it failed on."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_invalid_pattern(self):
+        """Bad patterns report the pattern and the reason."""
+        with self.assertRaises(InvalidPatternError) as ctx:
+            compile_pattern("data/[abc")
+        self.assertEqual(ctx.exception.pattern, "data/[abc")
+        self.assertIn("unterminated", ctx.exception.reason)
+
+        with self.assertRaises(InvalidPatternError) as ctx:
+            parse_permission_file('rules:\n- pattern: "ok/**"\n- pattern: "x\\\\"\n')
+        self.assertIsInstance(ctx.exception, PatternSyntaxError)
+        self.assertEqual(ctx.exception.pattern, "x\\")
+
+    def test_unknown_access_level(self):
+        """Unknown levels keep the name and, from a file, where it was found."""
+        with self.assertRaises(UnknownAccessLevelError) as ctx:
+            parse_access_level("owner")
+        self.assertEqual(ctx.exception.value, "owner")
+        self.assertIsNone(ctx.exception.context)
+
+        write_file(
+            self.test_dir, "syft.pub.yaml", 'rules:\n- pattern: "**"\n  access:\n    own: ["*"]\n'
+        )
+        with self.assertRaises(UnknownAccessLevelError) as ctx:
+            Resolver(self.test_dir).resolve("a.txt", "bob@example.com")
+        self.assertEqual(ctx.exception.value, "own")
+        self.assertIn("syft.pub.yaml", ctx.exception.context)
+        self.assertIn("rule 0", str(ctx.exception))
+
+    def test_permission_file_not_found(self):
+        """Loading a missing file names it and is still a FileNotFoundError."""
+        missing = self.test_dir / "syft.pub.yaml"
+        with self.assertRaises(PermissionFileNotFoundError) as ctx:
+            load_permission_file(missing)
+        self.assertEqual(ctx.exception.path, missing)
+        self.assertIsInstance(ctx.exception, FileNotFoundError)
+
+    def test_path_escapes_root(self):
+        """Absolute paths outside the root are rejected with both paths attached."""
+        with self.assertRaises(PathEscapesRootError) as ctx:
+            Resolver(self.test_dir).resolve("/elsewhere/a.txt", "bob@example.com")
+        self.assertEqual(ctx.exception.root, self.test_dir)
+        self.assertIsNone(ctx.exception.real_path)
+
+    def test_common_base_and_builtin_types(self):
+        """Every typed error shares one base and keeps its builtin type for old callers."""
+        for error, builtin in [
+            (InvalidPatternError, ValueError),
+            (UnknownAccessLevelError, ValueError),
+            (PermissionFileNotFoundError, OSError),
+            (PathEscapesRootError, ValueError),
+        ]:
+            with self.subTest(error=error.__name__):
+                self.assertTrue(issubclass(error, SyftPermError))
+                self.assertTrue(issubclass(error, builtin))
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,94 @@
+"""Tests for escaping literal file names into patterns."""
+
+import sys
+import unittest
+from pathlib import Path, PureWindowsPath
+from unittest.mock import patch
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    PatternMatcher,
+    PermissionFile,
+    Resolver,
+    Rule,
+    clear_pattern_cache,
+    escape_pattern,
+    match,
+    parse_permission_file,
+    path_matching,
+)
+
+TRICKY_NAMES = [
+    "report[final].csv",
+    "a*b?.txt",
+    "{x,y}.md",
+    "back\\slash.txt",
+    "!important.txt",
+    "runs[1]/out{a}.txt",
+    "**",
+    "plain.txt",
+]
+
+# Paths the unescaped names would match as patterns
+LOOKALIKES = [
+    "reportf.csv",
+    "aXbY.txt",
+    "x.md",
+    "y.md",
+    "backslash.txt",
+    "runs1/outa.txt",
+    "anything",
+    "deep/nested/file",
+]
+
+
+class TestEscapePattern(unittest.TestCase):
+    """Test that escaped names match themselves and nothing else."""
+
+    def test_round_trip(self):
+        """Each escaped name matches exactly that name."""
+        everything = TRICKY_NAMES + LOOKALIKES
+        for name in TRICKY_NAMES:
+            pattern = escape_pattern(name)
+            for path in everything:
+                self.assertEqual(match(pattern, path), path == name, (pattern, path))
+                self.assertEqual(PatternMatcher(pattern).match_path(path), path == name)
+
+    def test_round_trip_with_windows_paths(self):
+        """Escapes survive where paths are normalized as Windows paths."""
+        clear_pattern_cache()
+        names = [name for name in TRICKY_NAMES if "\\" not in name]
+        with patch.object(path_matching, "PurePath", PureWindowsPath):
+            for name in names:
+                pattern = escape_pattern(name)
+                for path in names + LOOKALIKES:
+                    self.assertEqual(match(pattern, path), path == name, (pattern, path))
+                    self.assertEqual(PatternMatcher(pattern).match_path(path), path == name)
+            self.assertTrue(match("data/*.csv", "data\\a.csv"))
+
+    def test_metacharacters_escaped(self):
+        """Every metacharacter gets a backslash; separators and plain text don't."""
+        self.assertEqual(escape_pattern("a[1]/b*{c}?.txt"), "a\\[1\\]/b\\*\\{c\\}\\?.txt")
+        self.assertEqual(escape_pattern("plain/file.txt"), "plain/file.txt")
+
+    def test_leading_bang_is_not_an_exclusion(self):
+        """A file named with a leading ! yields a granting rule."""
+        rule = Rule.for_path("!important.txt", {AccessLevel.READ: ["*"]})
+        self.assertFalse(rule.is_exclusion)
+        self.assertEqual(rule.level_for("alice@example.com"), AccessLevel.READ)
+
+    def test_exact_path_rule_in_resolver(self):
+        """Rules built from literal paths don't spill onto similarly named files."""
+        perm_file = PermissionFile(
+            rules=[Rule.for_path("data[1].csv", {AccessLevel.WRITE: ["alice@example.com"]})]
+        )
+        perm_file = parse_permission_file(perm_file.to_yaml())
+        resolver = Resolver("/nonexistent", stat_func=None, permission_files={"": perm_file})
+        self.assertEqual(resolver.resolve("data[1].csv", "alice@example.com"), AccessLevel.WRITE)
+        self.assertEqual(resolver.resolve("data1.csv", "alice@example.com"), AccessLevel.NONE)
+
+
+if __name__ == "__main__":
+    unittest.main()
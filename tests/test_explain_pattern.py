@@ -0,0 +1,93 @@
+"""Tests for describing in words what a pattern matches."""
+
+import sys
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import explain_pattern  # noqa: E402
+
+
+class TestExplainPattern(unittest.TestCase):
+    """Test the plain-English descriptions of representative patterns."""
+
+    def _assert_explains(self, cases):
+        for pattern, expected in cases:
+            with self.subTest(pattern=pattern):
+                self.assertEqual(explain_pattern(pattern), expected)
+
+    def test_single_star(self):
+        """``*`` stays within one folder, which the description says."""
+        self._assert_explains(
+            [
+                ("data/*.csv", "any .csv file directly inside data/ (not in subfolders)"),
+                ("*", "any file directly inside this folder (not in subfolders)"),
+                ("data/*/x.csv", "the file x.csv directly inside a folder matching data/*/"),
+            ]
+        )
+
+    def test_double_star(self):
+        """``**`` reaches any depth, alone, as a prefix or as a suffix."""
+        self._assert_explains(
+            [
+                ("src/**", "any file at any depth under src/"),
+                ("**", "any file at any depth"),
+                ("**/*.csv", "any .csv file at any depth"),
+                ("data/**/**/*.csv", "any .csv file at any depth under data/"),
+            ]
+        )
+
+    def test_several_double_stars(self):
+        """Each ``**`` adds a level found at any depth under the one before it."""
+        self._assert_explains(
+            [
+                (
+                    "a/**/b/*.py",
+                    "any .py file directly inside a b/ folder (not in subfolders), "
+                    "at any depth under a/",
+                ),
+                (
+                    "a/**/b/**/c/*.py",
+                    "any .py file directly inside a c/ folder (not in subfolders), "
+                    "at any depth under a b/ folder, at any depth under a/",
+                ),
+                ("a/**/b/**", "any file at any depth under a b/ folder, at any depth under a/"),
+                ("**/tmp/**", "any file at any depth under a tmp/ folder, at any depth"),
+            ]
+        )
+
+    def test_names(self):
+        """The last segment's constraint on the name is spelled out."""
+        self._assert_explains(
+            [
+                ("reports/q1.pdf", "the file q1.pdf directly inside reports/"),
+                (
+                    "logs/app-*",
+                    "any file whose name starts with app- directly inside logs/ "
+                    "(not in subfolders)",
+                ),
+                (
+                    "*_backup",
+                    "any file whose name ends with _backup directly inside this folder "
+                    "(not in subfolders)",
+                ),
+                ("x/[ab].txt", "any file matching [ab].txt directly inside x/ (not in subfolders)"),
+                ("weird\\*name", "the file weird*name directly inside this folder"),
+            ]
+        )
+
+    def test_markers(self):
+        """Directory-only, root-anchored and exclusion patterns say so."""
+        self._assert_explains(
+            [
+                ("**/cache/", "the folder cache at any depth"),
+                ("/data/*.csv", "any .csv file directly inside /data/ (not in subfolders)"),
+                ("/**", "any file at any depth under the datasite root"),
+                ("!**/*.key", "excludes any .key file at any depth"),
+            ]
+        )
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,98 @@
+"""Tests for combining the access a user has in several datasites."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    CombineStrategy,
+    MemoryFileSystem,
+    federated_resolve,
+    load_datasite,
+)
+
+BOB = "bob@example.com"
+
+
+def _grant(level, pattern="**", user=BOB):
+    return f'rules:\n- pattern: "{pattern}"\n  access:\n    {level}: [{user}]\n'
+
+
+class TestFederatedResolve(unittest.TestCase):
+    """Test both strategies over two datasites with differing grants."""
+
+    def setUp(self):
+        """Create two temporary datasites."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        write_file(self.test_dir, "mirror/syft.pub.yaml", _grant("read"))
+        write_file(self.test_dir, "mirror/shared/report.csv", "x")
+        write_file(self.test_dir, "origin/syft.pub.yaml", _grant("write", "shared/**"))
+        write_file(self.test_dir, "origin/shared/report.csv", "x")
+        write_file(self.test_dir, "origin/shared/draft.csv", "x")
+        self.datasites = [
+            load_datasite(self.test_dir / "mirror"),
+            load_datasite(self.test_dir / "origin"),
+        ]
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def _resolve(self, path, combine, user=BOB):
+        return federated_resolve(self.datasites, path, user, combine)
+
+    def test_most_permissive(self):
+        """The highest level any datasite grants wins; it is also the default."""
+        self.assertEqual(
+            self._resolve("shared/report.csv", CombineStrategy.MOST_PERMISSIVE), AccessLevel.WRITE
+        )
+        self.assertEqual(
+            federated_resolve(self.datasites, "shared/report.csv", BOB), AccessLevel.WRITE
+        )
+
+    def test_most_restrictive(self):
+        """The lowest level any datasite grants wins."""
+        self.assertEqual(
+            self._resolve("shared/report.csv", CombineStrategy.MOST_RESTRICTIVE), AccessLevel.READ
+        )
+        write_file(self.test_dir, "mirror/syft.pub.yaml", _grant("read", "other/**"))
+        self.datasites[0] = load_datasite(self.test_dir / "mirror")
+        self.assertEqual(
+            self._resolve("shared/report.csv", CombineStrategy.MOST_RESTRICTIVE), AccessLevel.NONE
+        )
+
+    def test_path_in_some_sources(self):
+        """Only datasites holding the path count, so a missing copy can't veto it."""
+        for combine in CombineStrategy:
+            with self.subTest(combine=combine):
+                self.assertEqual(self._resolve("shared/draft.csv", combine), AccessLevel.WRITE)
+
+    def test_path_in_no_source(self):
+        """A path none of them hold yet is resolved in every datasite."""
+        for combine, level in (
+            (CombineStrategy.MOST_PERMISSIVE, AccessLevel.WRITE),
+            (CombineStrategy.MOST_RESTRICTIVE, AccessLevel.READ),
+        ):
+            with self.subTest(combine=combine):
+                self.assertEqual(self._resolve("shared/new.csv", combine), level)
+
+    def test_options_and_filesystems(self):
+        """Resolver options reach every datasite, which may live on any filesystem."""
+        memory = MemoryFileSystem(
+            {"syft.pub.yaml": _grant("admin", user="{owner}"), "shared/report.csv": "x"}
+        )
+        datasites = [load_datasite("/remote", filesystem=memory), self.datasites[1]]
+        self.assertEqual(
+            federated_resolve(datasites, "shared/report.csv", BOB, owner=BOB), AccessLevel.ADMIN
+        )
+        self.assertEqual(federated_resolve([], "a.txt", BOB), AccessLevel.NONE)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,313 @@
+"""Tests for resolving permissions from in-memory and archived filesystems."""
+
+import io
+import shutil
+import sys
+import tarfile
+import tempfile
+import threading
+import unittest
+import zipfile
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    MemoryFileSystem,
+    OSFileSystem,
+    PermissionStore,
+    Resolver,
+    TarFileSystem,
+    MatchOptions,
+    ZipFileSystem,
+    find_entry,
+    load_datasite,
+)
+
+ROOT_RULES = """rules:
+- pattern: "**"
+  access:
+    read: ["*"]
+"""
+
+VAULT_RULES = """terminal: true
+rules:
+- pattern: "*.pem"
+  access:
+    admin: [alice@example.com]
+"""
+
+SIZE_LIMITED = """rules:
+- pattern: "**"
+  access:
+    write: ["*"]
+  limits:
+    max_file_size: 10
+"""
+
+CSV_RULES = """rules:
+- pattern: "data/*.csv"
+  access:
+    read: ["*"]
+  limits:
+    max_file_size: 10
+"""
+
+FILES = {
+    "syft.pub.yaml": ROOT_RULES,
+    "readme.md": "hello",
+    "docs/guide.md": "guide",
+    "vault/syft.pub.yaml": VAULT_RULES,
+    "vault/keys.pem": "secret",
+    ".hidden/notes.txt": "skip me",
+}
+
+
+def _zip(files):
+    buffer = io.BytesIO()
+    with zipfile.ZipFile(buffer, "w") as archive:
+        for path, content in files.items():
+            archive.writestr(path, content)
+    return zipfile.ZipFile(buffer)
+
+
+def _tar_gz(files, prefix=""):
+    """A gzipped tar of the files, as the bytes it would be downloaded as."""
+    buffer = io.BytesIO()
+    with tarfile.open(fileobj=buffer, mode="w:gz") as archive:
+        for path, content in files.items():
+            data = content.encode("utf-8")
+            info = tarfile.TarInfo(prefix + path)
+            info.size = len(data)
+            archive.addfile(info, io.BytesIO(data))
+    return buffer.getvalue()
+
+
+class TestMemoryFileSystem(unittest.TestCase):
+    """Test the in-memory filesystem on its own."""
+
+    def setUp(self):
+        """Create an in-memory datasite."""
+        self.fs = MemoryFileSystem(FILES)
+
+    def test_directories_are_implied(self):
+        """Parents of every file exist as directories."""
+        self.assertTrue(self.fs.is_dir(""))
+        self.assertTrue(self.fs.is_dir("vault"))
+        self.assertFalse(self.fs.is_dir("readme.md"))
+        self.assertTrue(self.fs.is_file("vault/keys.pem"))
+        self.assertFalse(self.fs.is_file("vault"))
+
+    def test_walk_is_top_down_and_prunable(self):
+        """Walking visits directories in order and skips pruned subdirectories."""
+        visited = []
+        for directory, dirnames, filenames in self.fs.walk(""):
+            visited.append((directory, list(dirnames), filenames))
+            dirnames[:] = [name for name in dirnames if name != "vault"]
+        self.assertEqual(
+            visited,
+            [
+                ("", [".hidden", "docs", "vault"], ["readme.md", "syft.pub.yaml"]),
+                (".hidden", [], ["notes.txt"]),
+                ("docs", [], ["guide.md"]),
+            ],
+        )
+        self.assertEqual(list(self.fs.walk("missing")), [])
+
+    def test_stat_and_read(self):
+        """Stat reports file sizes and missing paths raise like the OS would."""
+        self.assertEqual(self.fs.stat("vault/keys.pem").st_size, 6)
+        self.assertEqual(self.fs.read_text("docs/guide.md"), "guide")
+        with self.assertRaises(FileNotFoundError):
+            self.fs.stat("nope.txt")
+        with self.assertRaises(FileNotFoundError):
+            self.fs.read_text("docs")
+
+    def test_sub_filesystem(self):
+        """A sub filesystem sees paths relative to its directory."""
+        vault = self.fs.sub("vault")
+        self.assertTrue(vault.is_file("keys.pem"))
+        self.assertEqual(list(vault.walk("")), [("", [], ["keys.pem", "syft.pub.yaml"])])
+
+
+class TestTarFileSystem(unittest.TestCase):
+    """Test reading a datasite straight from a .tar.gz archive."""
+
+    def test_resolve_inside_archive(self):
+        """Permission files in the archive are found and resolved as on disk."""
+        data = _tar_gz(FILES, prefix="./alice@example.com/")
+        fs = TarFileSystem(io.BytesIO(data)).sub("alice@example.com")
+        datasite = load_datasite("/datasites/alice@example.com", filesystem=fs)
+        self.assertEqual(datasite.directories, ["", "vault"])
+        resolver = datasite.resolver()
+        self.assertEqual(resolver.resolve("docs/guide.md", "bob@example.com"), AccessLevel.READ)
+        self.assertEqual(resolver.resolve("vault/keys.pem", "bob@example.com"), AccessLevel.NONE)
+        self.assertEqual(resolver.resolve("vault/keys.pem", "alice@example.com"), AccessLevel.ADMIN)
+
+    def test_open_from_path(self):
+        """An archive can also be opened by path, uncompressed or not."""
+        test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        self.addCleanup(shutil.rmtree, test_dir, ignore_errors=True)
+        path = test_dir / "site.tar.gz"
+        path.write_bytes(_tar_gz(FILES))
+        fs = TarFileSystem(path)
+        self.assertEqual(fs.read_text("vault/syft.pub.yaml"), VAULT_RULES)
+        self.assertEqual(fs.stat("vault/keys.pem").st_size, 6)
+
+    def test_links_left_out(self):
+        """Symlinks in the archive are not files the resolver can see."""
+        buffer = io.BytesIO()
+        with tarfile.open(fileobj=buffer, mode="w") as archive:
+            link = tarfile.TarInfo("syft.pub.yaml")
+            link.type = tarfile.SYMTYPE
+            link.linkname = "/etc/passwd"
+            archive.addfile(link)
+        buffer.seek(0)
+        fs = TarFileSystem(buffer)
+        self.assertFalse(fs.is_file("syft.pub.yaml"))
+        self.assertEqual(list(fs.walk("")), [("", [], [])])
+
+
+class TestResolverFileSystem(unittest.TestCase):
+    """Test that resolving from a virtual filesystem matches resolving from disk."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        for rel_path, content in FILES.items():
+            write_file(self.test_dir, rel_path, content)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def _resolvers(self):
+        return {
+            "disk": Resolver(self.test_dir),
+            "os": Resolver(self.test_dir, filesystem=OSFileSystem(self.test_dir)),
+            "memory": Resolver("/nonexistent", filesystem=MemoryFileSystem(FILES)),
+            "zip": Resolver("/nonexistent", filesystem=ZipFileSystem(_zip(FILES))),
+            "tar": Resolver("/nonexistent", filesystem=TarFileSystem(io.BytesIO(_tar_gz(FILES)))),
+        }
+
+    def test_all_filesystems_agree(self):
+        """Every backend resolves and walks the same tree identically."""
+        paths = ["readme.md", "docs/guide.md", "vault/keys.pem", "vault/other.txt"]
+        users = ["alice@example.com", "bob@example.com"]
+        expected = None
+        for name, resolver in self._resolvers().items():
+            with self.subTest(filesystem=name):
+                result = (
+                    [resolver.resolve(path, user) for path in paths for user in users],
+                    list(resolver.walk("bob@example.com", prune_no_access=True)),
+                )
+                if expected is None:
+                    expected = result
+                self.assertEqual(result, expected)
+        self.assertEqual(
+            expected[1], [("readme.md", AccessLevel.READ), ("docs/guide.md", AccessLevel.READ)]
+        )
+
+    def test_limits_stat_through_filesystem(self):
+        """Size limits use the sizes the filesystem reports."""
+        fs = MemoryFileSystem({"syft.pub.yaml": SIZE_LIMITED, "small": "x", "big": "x" * 11})
+        resolver = Resolver("/nonexistent", filesystem=fs)
+        self.assertEqual(resolver.resolve("small", "bob@example.com"), AccessLevel.WRITE)
+        self.assertEqual(resolver.resolve("big", "bob@example.com"), AccessLevel.NONE)
+        self.assertEqual(resolver.resolve("new.txt", "bob@example.com"), AccessLevel.WRITE)
+
+        unlimited = Resolver("/nonexistent", stat_func=None, filesystem=fs)
+        self.assertEqual(unlimited.resolve("big", "bob@example.com"), AccessLevel.WRITE)
+
+    def test_malformed_file_names_its_path(self):
+        """Parse errors mention the permission file's path inside the filesystem."""
+        fs = MemoryFileSystem({"docs/syft.pub.yaml": "rules: [ nope"})
+        with self.assertRaisesRegex(ValueError, "docs/syft.pub.yaml"):
+            Resolver("/nonexistent", filesystem=fs).resolve("docs/a.txt", "bob@example.com")
+
+    def test_real_path_resolution_needs_disk(self):
+        """Symlink resolution is rejected for virtual filesystems."""
+        with self.assertRaises(ValueError):
+            Resolver(".", resolve_real_path=True, filesystem=MemoryFileSystem({}))
+
+
+class TestCaseFoldedFileSystem(unittest.TestCase):
+    """Test that case-insensitive matching also finds entries stored in another case."""
+
+    FOLD = MatchOptions(case_insensitive=True)
+
+    def setUp(self):
+        """Create an archive whose names differ in case from the rules."""
+        files = {
+            "syft.pub.yaml": CSV_RULES,
+            "Data/File.CSV": "1,2",
+            "Data/Big.csv": "x" * 11,
+            "Vault/SYFT.PUB.YAML": VAULT_RULES,
+        }
+        self.fs = ZipFileSystem(_zip(files))
+
+    def test_archive_entry_matched_in_fold_mode(self):
+        """``data/*.csv`` covers the stored ``Data/File.CSV`` only when folding."""
+        folded = Resolver("/nonexistent", match_options=self.FOLD, filesystem=self.fs)
+        exact = Resolver("/nonexistent", filesystem=self.fs)
+        self.assertEqual(folded.resolve("Data/File.CSV", "bob@example.com"), AccessLevel.READ)
+        self.assertEqual(exact.resolve("Data/File.CSV", "bob@example.com"), AccessLevel.NONE)
+
+    def test_limits_stat_stored_entry(self):
+        """Limits stat the stored entry however the path is spelled."""
+        resolver = Resolver("/nonexistent", match_options=self.FOLD, filesystem=self.fs)
+        self.assertEqual(resolver.resolve("data/file.csv", "bob@example.com"), AccessLevel.READ)
+        self.assertEqual(resolver.resolve("data/big.CSV", "bob@example.com"), AccessLevel.NONE)
+
+    def test_permission_file_found(self):
+        """A permission file stored in another case is loaded, as on a folding disk."""
+        folded = Resolver("/nonexistent", match_options=self.FOLD, filesystem=self.fs)
+        exact = Resolver("/nonexistent", filesystem=self.fs)
+        self.assertEqual(folded.resolve("vault/a.pem", "alice@example.com"), AccessLevel.ADMIN)
+        self.assertEqual(exact.resolve("vault/a.pem", "alice@example.com"), AccessLevel.NONE)
+
+    def test_find_entry(self):
+        """Entries are found as stored only in fold mode, exact spellings first."""
+        self.assertEqual(find_entry(self.fs, "data/file.csv", self.FOLD), "Data/File.CSV")
+        self.assertEqual(find_entry(self.fs, "DATA", self.FOLD), "Data")
+        self.assertIsNone(find_entry(self.fs, "data/file.csv"))
+        self.assertIsNone(find_entry(self.fs, "data/missing.csv", self.FOLD))
+        self.assertIsNone(find_entry(self.fs, "data/file.csv/x", self.FOLD))
+        self.assertEqual(find_entry(self.fs, "Data/File.CSV"), "Data/File.CSV")
+
+        both = MemoryFileSystem({"a/X": "", "a/x": "", "a/Y": ""})
+        self.assertEqual(find_entry(both, "a/x", self.FOLD), "a/x")
+        self.assertEqual(find_entry(both, "A/y", self.FOLD), "a/Y")
+
+
+class TestStoreFileSystem(unittest.TestCase):
+    """Test loading a permission store from a virtual filesystem."""
+
+    def test_reload_from_zip(self):
+        """Datasites are the top-level directories of the archive."""
+        files = {f"alice@example.com/{path}": content for path, content in FILES.items()}
+        files["bob@example.com/data.csv"] = "1,2"
+        files[".tmp/x"] = ""
+        store = PermissionStore("/nonexistent", filesystem=ZipFileSystem(_zip(files)))
+        store.reload_all()
+
+        self.assertEqual(store.datasites, ["alice@example.com", "bob@example.com"])
+        alice = store.get("alice@example.com")
+        self.assertEqual(alice.resolve("vault/keys.pem", "alice@example.com"), AccessLevel.ADMIN)
+        self.assertEqual(alice.resolve("readme.md", "bob@example.com"), AccessLevel.READ)
+        self.assertEqual(store.get("bob@example.com").resolve("data.csv", "x"), AccessLevel.NONE)
+
+        store.reload("carol@example.com")
+        self.assertIsNone(store.get("carol@example.com"))
+
+    def test_virtual_store_cannot_be_watched(self):
+        """Watching needs real filesystem events."""
+        store = PermissionStore(".", filesystem=MemoryFileSystem({}))
+        with self.assertRaises(ValueError):
+            store.watch(threading.Event())
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,176 @@
+"""Tests for stable fingerprints of permission files and effective rulesets."""
+
+import shutil
+import subprocess
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import (  # noqa: E402
+    load_permission_file,
+    merge_rule_chain,
+    parse_permission_file,
+)
+
+BASE = """rules:
+- pattern: "*.txt"
+  access:
+    read: [bob@example.com, alice@example.com]
+    write: [carol@example.com]
+- pattern: "data/**"
+  terminal: true
+  limits:
+    max_file_size: 100
+    allowed_extensions: [.csv]
+"""
+
+
+class TestPermissionFileFingerprint(unittest.TestCase):
+    """Test what PermissionFile.fingerprint does and doesn't depend on."""
+
+    def _fingerprint(self, content):
+        return parse_permission_file(content).fingerprint()
+
+    def test_deterministic(self):
+        """The same content hashes the same, including in a separate process."""
+        fingerprint = self._fingerprint(BASE)
+        self.assertEqual(self._fingerprint(BASE), fingerprint)
+        self.assertRegex(fingerprint, "^[0-9a-f]{64}$")
+        script = (
+            "import sys; sys.path.insert(0, sys.argv[1]); "
+            "from syft_perm.core import parse_permission_file; "
+            "print(parse_permission_file(sys.stdin.read()).fingerprint())"
+        )
+        src = str(Path(__file__).parent.parent / "src")
+        output = subprocess.run(
+            [sys.executable, "-c", script, src],
+            input=BASE,
+            capture_output=True,
+            text=True,
+            check=True,
+            env={"PYTHONHASHSEED": "123"},
+        ).stdout
+        self.assertEqual(output.strip(), fingerprint)
+
+    def test_key_and_user_order_ignored(self):
+        """Key order in the yaml, user list order and duplicates don't matter."""
+        reordered = """rules:
+- access:
+    write: [carol@example.com]
+    read: [alice@example.com, bob@example.com, alice@example.com]
+  pattern: "./*.txt"
+- limits:
+    allowed_extensions: [.csv]
+    max_file_size: 100
+  terminal: true
+  pattern: "data//**"
+"""
+        self.assertEqual(self._fingerprint(reordered), self._fingerprint(BASE))
+
+    def test_location_ignored(self):
+        """Where the file was loaded from isn't part of its fingerprint."""
+        first = parse_permission_file(BASE, Path("a/syft.pub.yaml"))
+        second = parse_permission_file("\n" + BASE, Path("b/syft.pub.yaml"))
+        self.assertEqual(first.fingerprint(), second.fingerprint())
+
+    def test_changes_detected(self):
+        """Changing a level, user, flag, limit or window changes the fingerprint."""
+        fingerprint = self._fingerprint(BASE)
+        changes = {
+            "level": BASE.replace("write: [carol", "admin: [carol"),
+            "user": BASE.replace("carol@", "dave@"),
+            "rule terminal": BASE.replace("terminal: true", "terminal: false"),
+            "file terminal": "terminal: true\n" + BASE,
+            "limit": BASE.replace("100", "101"),
+            "pattern": BASE.replace("*.txt", "*.md"),
+            "window": BASE.replace("  terminal: true", "  not_after: 2030-01-01T00:00:00Z"),
+        }
+        for name, content in changes.items():
+            with self.subTest(change=name):
+                self.assertNotEqual(self._fingerprint(content), fingerprint)
+
+    def test_same_instant_in_other_offset(self):
+        """A window written with another UTC offset for the same instant hashes alike."""
+        template = 'rules:\n- pattern: "*"\n  not_after: "{}"\n'
+        self.assertEqual(
+            self._fingerprint(template.format("2030-01-01T00:00:00Z")),
+            self._fingerprint(template.format("2030-01-01T02:00:00+02:00")),
+        )
+
+    def test_declaration_order_with_distinct_precedence(self):
+        """Rules tried in a fixed order regardless of declaration keep the fingerprint."""
+        first, second = BASE.split("- pattern:")[1:]
+        swapped = f"rules:\n- pattern:{second}- pattern:{first}"
+        self.assertEqual(self._fingerprint(swapped), self._fingerprint(BASE))
+
+    def test_declaration_order_with_equal_precedence(self):
+        """Swapping rules that only declaration order separates changes the fingerprint."""
+        content = """rules:
+- pattern: "*.txt"
+  access:
+    read: [bob@example.com]
+- pattern: "*.csv"
+  access:
+    write: [bob@example.com]
+"""
+        first, second = content.split("- pattern:")[1:]
+        swapped = f"rules:\n- pattern:{second}- pattern:{first}"
+        self.assertNotEqual(self._fingerprint(swapped), self._fingerprint(content))
+
+
+class TestEffectiveRulesetFingerprint(unittest.TestCase):
+    """Test fingerprints of rules merged from a chain of files."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def _ruleset(self, root):
+        return merge_rule_chain(
+            [
+                load_permission_file(root / "syft.pub.yaml"),
+                load_permission_file(root / "data/syft.pub.yaml"),
+            ]
+        )
+
+    def test_relative_to_root(self):
+        """Copies of a datasite in different places match when hashed against their root."""
+        for copy in ("one", "two"):
+            write_file(self.test_dir, f"{copy}/syft.pub.yaml", BASE)
+            write_file(self.test_dir, f"{copy}/data/syft.pub.yaml", 'rules:\n- pattern: "*"\n')
+        one = self._ruleset(self.test_dir / "one")
+        two = self._ruleset(self.test_dir / "two")
+        self.assertEqual(
+            one.fingerprint(self.test_dir / "one"), two.fingerprint(self.test_dir / "two")
+        )
+        self.assertNotEqual(one.fingerprint(), two.fingerprint())
+
+    def test_source_matters(self):
+        """The same rule coming from a different file is a different ruleset."""
+        write_file(self.test_dir, "syft.pub.yaml", BASE)
+        write_file(self.test_dir, "data/syft.pub.yaml", 'rules:\n- pattern: "*"\n')
+        before = self._ruleset(self.test_dir).fingerprint(self.test_dir)
+        write_file(self.test_dir, "syft.pub.yaml", 'rules:\n- pattern: "*"\n')
+        write_file(self.test_dir, "data/syft.pub.yaml", BASE)
+        self.assertNotEqual(self._ruleset(self.test_dir).fingerprint(self.test_dir), before)
+
+    def test_rule_index_ignored(self):
+        """Reordering a file without changing precedence keeps the ruleset's fingerprint."""
+        write_file(self.test_dir, "syft.pub.yaml", BASE)
+        write_file(self.test_dir, "data/syft.pub.yaml", 'rules:\n- pattern: "*"\n')
+        before = self._ruleset(self.test_dir).fingerprint(self.test_dir)
+        first, second = BASE.split("- pattern:")[1:]
+        write_file(self.test_dir, "syft.pub.yaml", f"rules:\n- pattern:{second}- pattern:{first}")
+        self.assertEqual(self._ruleset(self.test_dir).fingerprint(self.test_dir), before)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,107 @@
+"""Tests for finding the single rule responsible for a user's access."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import AccessLevel, ResolutionStrategy, Resolver  # noqa: E402
+
+ROOT_RULES = """rules:
+- pattern: "**"
+  access:
+    read: ["*"]
+- pattern: "data/**/*.csv"
+  access:
+    write: [bob@example.com]
+- pattern: "!data/secret/**"
+- pattern: "data/frozen/**"
+  revoke:
+    write: [bob@example.com]
+- pattern: "data/capped/**"
+  revoke:
+    admin: [bob@example.com]
+"""
+
+NESTED_RULES = """rules:
+- pattern: "*.txt"
+  access:
+    admin: [carol@example.com]
+"""
+
+BOB = "bob@example.com"
+
+
+class TestGrantingRule(unittest.TestCase):
+    """Test which rule is named as deciding a path."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        write_file(self.test_dir, "syft.pub.yaml", ROOT_RULES)
+        write_file(self.test_dir, "docs/syft.pub.yaml", NESTED_RULES)
+        self.resolver = Resolver(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def _pattern(self, path, user=BOB, resolver=None):
+        found = (resolver or self.resolver).granting_rule(path, user)
+        return found.rule.pattern if found is not None else None
+
+    def test_narrowest_grant(self):
+        """The most specific matching rule is named, with its file and line."""
+        found = self.resolver.granting_rule("data/x/a.csv", BOB)
+        self.assertEqual(found.rule.pattern, "data/**/*.csv")
+        self.assertEqual(found.rule_index, 1)
+        self.assertEqual(found.source, self.test_dir / "syft.pub.yaml")
+        self.assertEqual(found.rule.position.line, 5)
+        self.assertEqual(self._pattern("notes.md"), "**")
+
+    def test_nested_file(self):
+        """A rule in a nearer permission file is named with that file as its source."""
+        found = self.resolver.granting_rule("docs/a.txt", "carol@example.com")
+        self.assertEqual(found.rule.pattern, "*.txt")
+        self.assertEqual(found.source, self.test_dir / "docs" / "syft.pub.yaml")
+
+    def test_deciding_rule_not_listing_user(self):
+        """A nearer rule that doesn't list the user is what left them without access."""
+        self.assertEqual(self.resolver.resolve("docs/a.txt", BOB), AccessLevel.NONE)
+        self.assertEqual(self._pattern("docs/a.txt"), "*.txt")
+
+    def test_none(self):
+        """Without any deciding rule there is nothing to name."""
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            'rules:\n- pattern: "*.csv"\n  access:\n    read: ["*"]\n',
+        )
+        self.assertIsNone(self.resolver.granting_rule("a.txt", BOB))
+        self.assertIsNone(Resolver(self.test_dir / "empty").granting_rule("a.txt", BOB))
+
+    def test_exclusion(self):
+        """An exclusion that took access away is named over the rule granting it."""
+        self.assertEqual(self.resolver.resolve("data/secret/a.csv", BOB), AccessLevel.NONE)
+        self.assertEqual(self._pattern("data/secret/a.csv"), "!data/secret/**")
+
+    def test_revoke(self):
+        """A revoke is named only when it lowered the level."""
+        self.assertEqual(self.resolver.resolve("data/frozen/a.csv", BOB), AccessLevel.CREATE)
+        self.assertEqual(self._pattern("data/frozen/a.csv"), "data/frozen/**")
+        self.assertEqual(self.resolver.resolve("data/capped/a.csv", BOB), AccessLevel.WRITE)
+        self.assertEqual(self._pattern("data/capped/a.csv"), "data/**/*.csv")
+
+    def test_most_permissive(self):
+        """Combining all matches, the narrowest rule granting the final level is named."""
+        resolver = Resolver(self.test_dir, strategy=ResolutionStrategy.MOST_PERMISSIVE)
+        self.assertEqual(self._pattern("data/x/a.csv", resolver=resolver), "data/**/*.csv")
+        self.assertEqual(self._pattern("docs/a.txt", resolver=resolver), "**")
+
+
+if __name__ == "__main__":
+    unittest.main()
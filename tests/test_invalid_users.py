@@ -0,0 +1,64 @@
+"""Tests for reporting user entries that aren't well-formed emails."""
+
+import sys
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import InvalidUser, parse_permission_file  # noqa: E402
+
+
+def _rule(users, key="access", level="read"):
+    entries = ", ".join(f'"{user}"' for user in users)
+    return f'rules:\n- pattern: "**"\n  {key}:\n    {level}: [{entries}]\n'
+
+
+class TestInvalidUsers(unittest.TestCase):
+    """Test that malformed entries are reported and everything else passes."""
+
+    def _invalid(self, content):
+        return [
+            finding.user
+            for finding in parse_permission_file(content).validate()
+            if isinstance(finding, InvalidUser)
+        ]
+
+    def test_valid_emails(self):
+        """Ordinary, tagged and subdomain addresses are accepted."""
+        users = ["alice@example.com", "x.y+tag@sub.example.org", "o'brien@example.ie"]
+        self.assertEqual(self._invalid(_rule(users)), [])
+
+    def test_invalid_entries(self):
+        """Typos and bare usernames are reported, in the order they are listed."""
+        users = ["alice@@org.com", "bob", "carol@", "@example.com", "dave smith@example.com"]
+        self.assertEqual(self._invalid(_rule(users)), users)
+
+    def test_wildcards_and_placeholders_exempt(self):
+        """``*``, ``*@domain`` and ``{owner}`` aren't emails but are fine."""
+        self.assertEqual(self._invalid(_rule(["*", "*@example.com", "{owner}"])), [])
+        self.assertEqual(self._invalid(_rule(["public"])), [])
+
+    def test_revokes_and_verbs_checked(self):
+        """Revoke and verb lists are checked like allow lists."""
+        self.assertEqual(self._invalid(_rule(["bob"], key="revoke")), ["bob"])
+        self.assertEqual(self._invalid(_rule(["carol"], key="verbs", level="create")), ["carol"])
+
+    def test_location(self):
+        """The finding names the file, line and entry, and comes first."""
+        content = _rule(["alice@@org.com", "bob@example.com"]) + (
+            '- pattern: "*.txt"\n  access:\n    write: [bob@example.com]\n'
+        )
+        findings = parse_permission_file(content, Path("data/syft.pub.yaml")).validate()
+        self.assertEqual(
+            [type(finding).__name__ for finding in findings], ["InvalidUser", "RuleConflict"]
+        )
+        self.assertEqual(findings[0].index, 0)
+        self.assertEqual(
+            str(findings[0]),
+            "rule 0 ('**') at data/syft.pub.yaml:2:3 lists 'alice@@org.com', which is not an email",
+        )
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,143 @@
+"""Tests for loading every permission file of a datasite at once."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    MemoryFileSystem,
+    UnknownAccessLevelError,
+    load_datasite,
+)
+
+
+def _grant(level, pattern="**", user="bob@example.com"):
+    return f'rules:\n- pattern: "{pattern}"\n  access:\n    {level}: [{user}]\n'
+
+
+class TestLoadDatasite(unittest.TestCase):
+    """Test discovering, indexing and resolving against a whole tree."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_discovers_files(self):
+        """Every permission file is found and indexed by its directory."""
+        write_file(self.test_dir, "syft.pub.yaml", _grant("read"))
+        write_file(self.test_dir, "data/syft.pub.yaml", _grant("write"))
+        write_file(self.test_dir, "data/deep/er/syft.pub.yaml", _grant("admin"))
+        write_file(self.test_dir, "data/deep/file.txt", "x")
+        write_file(self.test_dir, ".hidden/syft.pub.yaml", _grant("admin"))
+
+        datasite = load_datasite(self.test_dir)
+        self.assertEqual(datasite.directories, ["", "data", "data/deep/er"])
+        self.assertEqual(len(datasite), 3)
+        self.assertEqual(datasite.files["data"].path, self.test_dir / "data/syft.pub.yaml")
+        self.assertEqual(datasite.errors, {})
+
+    def test_chain(self):
+        """A path's chain lists the files above it, root first, skipping gaps."""
+        write_file(self.test_dir, "syft.pub.yaml", _grant("read"))
+        write_file(self.test_dir, "data/deep/syft.pub.yaml", _grant("write"))
+        datasite = load_datasite(self.test_dir)
+        chain = datasite.chain("data/deep/x/file.txt")
+        self.assertEqual([directory for directory, _ in chain], ["", "data/deep"])
+        self.assertEqual([d for d, _ in datasite.chain("data/deep")], [""])
+        self.assertEqual([d for d, _ in datasite.chain("top.txt")], [""])
+
+    def test_resolver_never_rereads(self):
+        """The resolver built from a datasite keeps resolving the files as loaded."""
+        write_file(self.test_dir, "syft.pub.yaml", _grant("read"))
+        write_file(self.test_dir, "data/syft.pub.yaml", _grant("write", "*.csv"))
+        resolver = load_datasite(self.test_dir).resolver(owner="alice@example.com")
+        (self.test_dir / "data" / "syft.pub.yaml").unlink()
+        self.assertEqual(resolver.resolve("data/a.csv", "bob@example.com"), AccessLevel.WRITE)
+        self.assertEqual(resolver.resolve("data/a.txt", "bob@example.com"), AccessLevel.READ)
+        self.assertEqual(resolver.owner, "alice@example.com")
+
+    def test_malformed_file_names_its_path(self):
+        """A broken file aborts loading with its path in the message."""
+        write_file(self.test_dir, "syft.pub.yaml", _grant("read"))
+        write_file(self.test_dir, "data/syft.pub.yaml", "rules: [")
+        with self.assertRaises(ValueError) as raised:
+            load_datasite(self.test_dir)
+        self.assertIn(str(self.test_dir / "data" / "syft.pub.yaml"), str(raised.exception))
+
+    def test_skip_invalid_files(self):
+        """Skipped files are collected and can only take access away."""
+        write_file(self.test_dir, "syft.pub.yaml", _grant("read"))
+        write_file(self.test_dir, "data/syft.pub.yaml", _grant("superuser"))
+        write_file(self.test_dir, "other/syft.pub.yaml", "rules: [")
+        with self.assertLogs("syft_perm.core.datasite", level="WARNING"):
+            datasite = load_datasite(self.test_dir, skip_invalid_files=True)
+        self.assertEqual(sorted(datasite.errors), ["data", "other"])
+        self.assertIsInstance(datasite.errors["data"], UnknownAccessLevelError)
+        self.assertTrue(datasite.files["data"].terminal)
+        resolver = datasite.resolver()
+        self.assertEqual(resolver.resolve("data/a.txt", "bob@example.com"), AccessLevel.NONE)
+        self.assertEqual(resolver.resolve("a.txt", "bob@example.com"), AccessLevel.READ)
+
+    def test_max_depth(self):
+        """Deep directories are listed as unvisited and their files aren't loaded."""
+        write_file(self.test_dir, "syft.pub.yaml", _grant("read"))
+        write_file(self.test_dir, "a/syft.pub.yaml", _grant("write"))
+        write_file(self.test_dir, "a/b/syft.pub.yaml", _grant("admin"))
+        write_file(self.test_dir, "a/b/c/syft.pub.yaml", _grant("admin"))
+        datasite = load_datasite(self.test_dir, max_depth=1)
+        self.assertEqual(datasite.directories, ["", "a"])
+        self.assertEqual(datasite.unvisited, ["a/b"])
+        self.assertEqual(load_datasite(self.test_dir).unvisited, [])
+
+    def test_filesystem(self):
+        """A datasite can be loaded from any filesystem, not just the local disk."""
+        filesystem = MemoryFileSystem(
+            {"syft.pub.yaml": _grant("read"), "data/syft.pub.yaml": _grant("write")}
+        )
+        datasite = load_datasite("/datasites/alice", filesystem=filesystem)
+        self.assertEqual(datasite.directories, ["", "data"])
+        self.assertEqual(
+            datasite.resolver().resolve("data/a.txt", "bob@example.com"), AccessLevel.WRITE
+        )
+
+    def test_reload_file(self):
+        """Reloading one file swaps only it in, with the options the datasite was loaded with."""
+        write_file(self.test_dir, "syft.pub.yaml", _grant("read"))
+        write_file(self.test_dir, "data/syft.pub.yaml", _grant("write", user='"${who}"'))
+        datasite = load_datasite(self.test_dir, variables={"who": "bob@example.com"})
+        before, root_file = datasite.resolver(), datasite.files[""]
+
+        write_file(self.test_dir, "data/syft.pub.yaml", _grant("admin", user='"${who}"'))
+        datasite.reload_file("data/")
+        self.assertIs(datasite.files[""], root_file)
+        self.assertEqual(
+            datasite.resolver().resolve("data/a.txt", "bob@example.com"), AccessLevel.ADMIN
+        )
+        self.assertEqual(before.resolve("data/a.txt", "bob@example.com"), AccessLevel.WRITE)
+
+        (self.test_dir / "data" / "syft.pub.yaml").unlink()
+        datasite.reload_file("data")
+        self.assertEqual(datasite.directories, [""])
+
+    def test_reload_malformed_file(self):
+        """A malformed file raises and the loaded one stays in place."""
+        write_file(self.test_dir, "syft.pub.yaml", _grant("read"))
+        datasite = load_datasite(self.test_dir)
+        write_file(self.test_dir, "syft.pub.yaml", "rules: [")
+        with self.assertRaises(ValueError):
+            datasite.reload_file("")
+        self.assertEqual(datasite.resolver().resolve("a.txt", "bob@example.com"), AccessLevel.READ)
+
+
+if __name__ == "__main__":
+    unittest.main()
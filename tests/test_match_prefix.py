@@ -0,0 +1,97 @@
+"""Tests for could-match checks of partly typed patterns."""
+
+import sys
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import MatchOptions, match, match_prefix  # noqa: E402
+
+
+class TestMatchPrefix(unittest.TestCase):
+    """Test which paths a pattern prefix could still reach."""
+
+    def _check(self, prefix, reached, missed):
+        for path in reached:
+            with self.subTest(prefix=prefix, path=path):
+                self.assertTrue(match_prefix(prefix, path))
+        for path in missed:
+            with self.subTest(prefix=prefix, path=path):
+                self.assertFalse(match_prefix(prefix, path))
+
+    def test_mid_segment(self):
+        """Text after the last slash only has to start the next segment."""
+        self._check(
+            "data/re",
+            ["data/reports/x.csv", "data/readme.md", "data/re"],
+            ["data/archive/reports.csv", "other/reports/x.csv", "data"],
+        )
+        self._check("data/*.c", ["data/x.csv", "data/y.c"], ["data/sub/x.csv", "data/x.txt"])
+
+    def test_at_separator(self):
+        """A prefix ending in a slash needs the path to go at least one segment deeper."""
+        self._check("data/", ["data/x.csv", "data/a/b/c"], ["data", "database/x.csv"])
+
+    def test_complete_segments(self):
+        """Segments before the last slash are matched in full, wildcards included."""
+        self._check(
+            "data/*/x",
+            ["data/a/x.csv", "data/b/xy/z"],
+            ["data/x.csv", "data/a/b/x.csv", "data/a/y.csv"],
+        )
+
+    def test_doublestar(self):
+        """A ``**`` segment spans any depth, and a trailing one reaches everything below."""
+        self._check("data/**", ["data/x", "data/a/b/c.csv"], ["data", "other/x"])
+        self._check(
+            "**/reports/",
+            ["reports/x.csv", "a/b/reports/x.csv"],
+            ["a/reports", "a/report/x"],
+        )
+        self._check("data/**/*.cs", ["data/x.csv", "data/a/b/x.csv"], ["data/a/b/x.txt"])
+
+    def test_empty_prefix(self):
+        """Nothing typed yet could become a pattern matching any path."""
+        self._check("", ["a", "a/b/c.txt"], [""])
+
+    def test_unfinished_constructs(self):
+        """Open brace groups, character classes and escapes are completed generously."""
+        self._check("data/{re,ar", ["data/reports/x", "data/zzz/x"], ["other/x"])
+        self._check("{data,logs}/re", ["data/reports", "logs/readme"], ["tmp/reports"])
+        self._check("data/file[0-", ["data/file1.csv", "data/filex"], ["data/file"])
+        self._check("data/a\\", ["data/a*", "data/ab"], ["data/a"])
+
+    def test_consistent_with_match(self):
+        """Every path a full pattern matches is reached by each of its prefixes."""
+        cases = {
+            "data/**/*.csv": "data/a/b/x.csv",
+            "{docs,data}/[ab]?/*.txt": "data/b1/notes.txt",
+            "**/.env": "a/b/.env",
+        }
+        for pattern, path in cases.items():
+            self.assertTrue(match(pattern, path))
+            for end in range(len(pattern) + 1):
+                with self.subTest(prefix=pattern[:end]):
+                    self.assertTrue(match_prefix(pattern[:end], path))
+
+    def test_markers_ignored(self):
+        """Leading ``!`` and ``/`` don't change what the rest of the prefix can reach."""
+        self.assertTrue(match_prefix("!data/re", "data/reports/x"))
+        self.assertTrue(match_prefix("/data/re", "data/reports/x"))
+
+    def test_options(self):
+        """Case folding and dotfile matching apply to prefixes as they do to patterns."""
+        self.assertFalse(match_prefix("Data/Re", "data/reports/x"))
+        self.assertTrue(
+            match_prefix("Data/Re", "data/reports/x", MatchOptions(case_insensitive=True))
+        )
+        hidden = MatchOptions(match_dotfiles=False)
+        self.assertTrue(match_prefix("data/*", "data/.env"))
+        self.assertFalse(match_prefix("data/*", "data/.env", hidden))
+        self.assertTrue(match_prefix("data/", "data/.env", hidden))
+        self.assertTrue(match_prefix("data/**", "data/a/.env", hidden))
+
+
+if __name__ == "__main__":
+    unittest.main()
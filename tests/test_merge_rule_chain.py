@@ -0,0 +1,113 @@
+"""Tests for merging permission file chains into an effective ruleset."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import Resolver, merge_rule_chain, parse_permission_file  # noqa: E402
+
+
+class TestMergeRuleChain(unittest.TestCase):
+    """Test the ordering, de-duplication and truncation of merged rulesets."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_root_first_and_annotated(self):
+        """Rules come root-first by file and most specific first within a file."""
+        root = parse_permission_file(
+            """rules:
+- pattern: "**"
+  access:
+    read: ["*"]
+- pattern: "docs/*.md"
+  access:
+    write: [alice@example.com]
+""",
+            Path("ds/syft.pub.yaml"),
+        )
+        child = parse_permission_file(
+            'rules:\n- pattern: "*.csv"\n  access:\n    admin: [bob@example.com]\n',
+            Path("ds/data/syft.pub.yaml"),
+        )
+
+        ruleset = merge_rule_chain([root, child])
+        self.assertEqual(
+            [(str(entry.source), entry.rule.pattern, entry.rule_index) for entry in ruleset],
+            [
+                ("ds/syft.pub.yaml", "docs/*.md", 1),
+                ("ds/syft.pub.yaml", "**", 0),
+                ("ds/data/syft.pub.yaml", "*.csv", 0),
+            ],
+        )
+        self.assertEqual(ruleset.sources, [root.path, child.path])
+        self.assertIsNone(ruleset.terminal_source)
+
+    def test_duplicates_removed(self):
+        """A pattern repeated in a file, or a file passed twice, is listed once."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "*.txt"
+  access:
+    read: [alice@example.com]
+- pattern: "*.txt"
+  access:
+    write: [alice@example.com]
+""",
+            Path("ds/syft.pub.yaml"),
+        )
+
+        ruleset = merge_rule_chain([perm_file, perm_file])
+        self.assertEqual(len(ruleset), 1)
+        # The first declared rule is the one that applies
+        self.assertEqual(ruleset.rules[0].rule_index, 0)
+
+    def test_terminal_file_truncates_chain(self):
+        """The terminal file nearest the root is the only one left."""
+        write_file(
+            self.test_dir, "syft.pub.yaml", 'rules:\n- pattern: "**"\n  access:\n    read: ["*"]\n'
+        )
+        write_file(
+            self.test_dir,
+            "vault/syft.pub.yaml",
+            'terminal: true\nrules:\n- pattern: "*.key"\n  access:\n    read: [a@b.c]\n',
+        )
+        write_file(
+            self.test_dir,
+            "vault/inner/syft.pub.yaml",
+            'rules:\n- pattern: "**"\n  access:\n    admin: [bob@example.com]\n',
+        )
+
+        ruleset = Resolver(self.test_dir).ruleset_for("vault/inner/file.key")
+        terminal_path = self.test_dir / "vault" / "syft.pub.yaml"
+        self.assertEqual(ruleset.terminal_source, terminal_path)
+        self.assertEqual(ruleset.sources, [terminal_path])
+        self.assertEqual([entry.rule.pattern for entry in ruleset], ["*.key"])
+
+    def test_terminal_rules_keep_flag(self):
+        """Rule-level terminals don't truncate but are marked in the ruleset."""
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            'rules:\n- pattern: "private/**"\n  terminal: true\n  access:\n    read: [a@b.c]\n',
+        )
+        write_file(self.test_dir, "private/syft.pub.yaml", 'rules:\n- pattern: "*"\n')
+
+        ruleset = Resolver(self.test_dir).ruleset_for("private/x.txt")
+        self.assertEqual(len(ruleset.sources), 2)
+        self.assertTrue(ruleset.rules[0].rule.terminal)
+        self.assertIsNone(ruleset.terminal_source)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,107 @@
+"""Tests for the resolution metrics hooks."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    Metrics,
+    PermissionStore,
+    Resolver,
+    clear_pattern_cache,
+)
+
+
+class RecordingMetrics(Metrics):
+    """Keep every event for inspection."""
+
+    def __init__(self):
+        self.resolves = []
+        self.batches = []
+        self.cache = []
+        self.compiled = []
+
+    def on_resolve(self, duration, depth):
+        self.resolves.append((duration, depth))
+
+    def on_batch(self, duration, paths):
+        self.batches.append((duration, paths))
+
+    def on_cache(self, hit):
+        self.cache.append(hit)
+
+    def on_pattern_compile(self, pattern):
+        self.compiled.append(pattern)
+
+
+class TestMetrics(unittest.TestCase):
+    """Test that each hook fires once per event."""
+
+    def setUp(self):
+        """Create a temporary datasites directory with one datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        self.site = self.test_dir / "alice@example.com"
+        (self.site / "docs").mkdir(parents=True)
+        (self.site / "syft.pub.yaml").write_text(
+            """rules:
+- pattern: "metrics-test/**"
+  access:
+    read: ["*"]
+- pattern: "**/*.metrics"
+  access:
+    write: ["*"]
+"""
+        )
+        clear_pattern_cache()
+        self.metrics = RecordingMetrics()
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+        clear_pattern_cache()
+
+    def test_resolver_hooks(self):
+        """Single resolutions report their depth; batches report their size."""
+        resolver = Resolver(self.site, metrics=self.metrics)
+        self.assertEqual(resolver.resolve("metrics-test/a.txt", "bob"), AccessLevel.READ)
+        self.assertEqual(resolver.resolve("docs/deep/x.metrics", "bob"), AccessLevel.WRITE)
+        self.assertTrue(resolver.check_access("a.txt", "bob", AccessLevel.NONE))
+        self.assertEqual([depth for _, depth in self.metrics.resolves], [2, 3, 1])
+        self.assertTrue(all(duration >= 0 for duration, _ in self.metrics.resolves))
+
+        resolver.resolve_batch(["a.txt", "b.txt", "a.txt", "docs/c.txt"], "bob")
+        self.assertEqual([paths for _, paths in self.metrics.batches], [3])
+        self.assertEqual(len(self.metrics.resolves), 3)
+
+    def test_pattern_compiles_counted_once(self):
+        """Patterns are reported when compiled, not when found in the shared cache."""
+        resolver = Resolver(self.site, metrics=self.metrics)
+        for _ in range(3):
+            resolver.resolve("metrics-test/x.metrics", "bob")
+        self.assertEqual(sorted(self.metrics.compiled), ["**/*.metrics", "metrics-test/**"])
+
+    def test_store_cache_hits_and_misses(self):
+        """resolve_cached reports a miss, then hits, and resolves only on misses."""
+        store = PermissionStore(self.test_dir, metrics=self.metrics)
+        store.reload_all()
+        for _ in range(3):
+            store.resolve_cached("alice@example.com", "metrics-test/a.txt", "bob")
+        store.resolve_cached("alice@example.com", "metrics-test/a.txt", "carol")
+        self.assertEqual(self.metrics.cache, [False, True, True, False])
+        self.assertEqual(len(self.metrics.resolves), 2)
+
+    def test_without_metrics(self):
+        """Resolvers work the same with no metrics set."""
+        resolver = Resolver(self.site)
+        self.assertIsNone(resolver.metrics)
+        self.assertEqual(resolver.resolve("metrics-test/a.txt", "bob"), AccessLevel.READ)
+        self.assertEqual(resolver.resolve_batch(["a.txt"], "bob"), {"a.txt": AccessLevel.NONE})
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,253 @@
+"""Tests for removing redundant rules from a permission file."""
+
+import random
+import sys
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    MatchOptions,
+    MemoryFileSystem,
+    ResolutionStrategy,
+    Resolver,
+    parse_permission_file,
+)
+
+ROOT_RULES = """rules:
+- pattern: "**"
+  access:
+    read: ["*"]
+- pattern: "docs/*.md"
+  access:
+    write: [carol@example.com]
+- pattern: "docs/*.md"
+  access:
+    read: [carol@example.com]
+- pattern: "!secret/**"
+  priority: 1
+- pattern: "secret/keys/*"
+  access:
+    admin: [alice@example.com]
+- pattern: "reports/*.pdf"
+  access:
+    read: [bob@example.com]
+- pattern: "reports/**"
+  access:
+    admin: [bob@example.com]
+- pattern: "logs/*.log"
+  revoke:
+    read: [dave@example.com]
+- pattern: "logs/**"
+  revoke:
+    read: [dave@example.com]
+- pattern: "tmp/x.txt"
+  priority: 2
+  access:
+    write: ["*"]
+- pattern: "tmp/**"
+  priority: 2
+  terminal: true
+  access:
+    write: ["*"]
+- pattern: "cache/x"
+  terminal: true
+  access:
+    read: ["*"]
+- pattern: "cache/**"
+  access:
+    read: ["*"]
+- pattern: "big/*.bin"
+  access:
+    write: [bob@example.com]
+- pattern: "big/**"
+  access:
+    write: [bob@example.com]
+  limits:
+    max_file_size: 5
+- pattern: "users/{user}/**"
+  access:
+    write: ["*"]
+- pattern: "users/{user}/**"
+  access:
+    write: ["*"]
+"""
+
+DATA_RULES = """rules:
+- pattern: "/data/keep/*"
+  access:
+    read: [erin@example.com]
+- pattern: "keep/*"
+  access:
+    read: [erin@example.com]
+"""
+
+PATHS = [
+    "readme.md",
+    "docs/a.md",
+    "docs/deep/a.md",
+    "secret/keys/a.pem",
+    "secret/b.txt",
+    "reports/q1.pdf",
+    "reports/deep/q2.pdf",
+    "logs/a.log",
+    "logs/deep/b.log",
+    "tmp/x.txt",
+    "tmp/y.txt",
+    "cache/x",
+    "cache/y",
+    "big/small.bin",
+    "big/huge.bin",
+    "big/huge.txt",
+    "users/bob@example.com/a.txt",
+    "users/carol@example.com/a.txt",
+    "data/a.csv",
+    "data/keep/a.csv",
+    "data/other.csv",
+]
+
+FILES = {path: "x" * 10 if "huge" in path else "x" for path in PATHS}
+
+USERS = [
+    "alice@example.com",
+    "bob@example.com",
+    "carol@example.com",
+    "dave@example.com",
+    "erin@example.com",
+]
+
+
+def _resolutions(permission_files):
+    """Every path and user resolved under both strategies."""
+    filesystem = MemoryFileSystem(FILES)
+    return {
+        strategy: [
+            Resolver(
+                "/nonexistent",
+                permission_files=permission_files,
+                filesystem=filesystem,
+                strategy=strategy,
+            ).resolve(path, user)
+            for path in PATHS
+            for user in USERS
+        ]
+        for strategy in ResolutionStrategy
+    }
+
+
+class TestMinimize(unittest.TestCase):
+    """Test which rules are dropped and that access never changes."""
+
+    def setUp(self):
+        """Parse the permission files."""
+        self.root = parse_permission_file(ROOT_RULES)
+        self.data = parse_permission_file(DATA_RULES)
+
+    def test_redundant_rules_removed(self):
+        """Covered and duplicate rules go; revokes and differing grants stay."""
+        minimized = self.root.minimize()
+        self.assertEqual(
+            [rule.pattern for rule in minimized.rules],
+            [
+                "**",
+                "docs/*.md",
+                "!secret/**",
+                "reports/*.pdf",
+                "reports/**",
+                "logs/*.log",
+                "logs/**",
+                "tmp/**",
+                "cache/x",
+                "cache/**",
+                "big/*.bin",
+                "big/**",
+                "users/{user}/**",
+            ],
+        )
+        self.assertEqual(minimized.rules[1].access, self.root.rules[1].access)
+        self.assertEqual(len(self.root.rules), 17)
+
+    def test_anchored_patterns_compared_from_directory(self):
+        """A root-anchored pattern naming the same paths as a relative one is a duplicate."""
+        self.assertEqual(len(self.data.minimize("data").rules), 1)
+        self.assertEqual(len(self.data.minimize("other").rules), 2)
+
+    def test_wildcards_not_taken_literally(self):
+        """``*`` doesn't cover ``**``, though the name ``**`` would match it."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "*"
+  access:
+    admin: [bob@example.com]
+- pattern: "**"
+  access:
+    read: [bob@example.com]
+- pattern: "data/*.csv"
+  priority: 1
+  access:
+    read: [bob@example.com]
+- pattern: "data/*"
+  priority: 1
+  access:
+    read: [bob@example.com]
+"""
+        )
+        self.assertEqual(
+            [rule.pattern for rule in perm_file.minimize().rules], ["*", "**", "data/*"]
+        )
+
+    def test_dotfiles_kept(self):
+        """A rule naming a dotfile, even escaped, stays: ``*`` may not match it."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "*"
+  access:
+    read: [bob@example.com]
+- pattern: "\\\\.env"
+  access:
+    read: [bob@example.com]
+"""
+        )
+        minimized = perm_file.minimize()
+        self.assertEqual(len(minimized.rules), 2)
+        resolver = Resolver(
+            "/nonexistent",
+            permission_files={"": minimized},
+            filesystem=MemoryFileSystem({".env": "x"}),
+            match_options=MatchOptions(match_dotfiles=False),
+        )
+        self.assertEqual(resolver.resolve(".env", "bob@example.com"), AccessLevel.READ)
+
+    def test_resolves_identically(self):
+        """The minimized files give every user the same level on every path."""
+        original = {"": self.root, "data": self.data}
+        minimized = {"": self.root.minimize(), "data": self.data.minimize("data")}
+        self.assertEqual(_resolutions(minimized), _resolutions(original))
+
+    def test_random_rulesets_resolve_identically(self):
+        """Randomly generated files keep their resolution under both strategies."""
+        rng = random.Random(3)
+        patterns = ["**", "data/**", "data/*.csv", "data/a.csv", "!data/**", "docs/*", "*"]
+        levels = ["read", "write", "admin"]
+        for _ in range(40):
+            lines = ["rules:"]
+            for _ in range(rng.randint(2, 7)):
+                lines.append(f'- pattern: "{rng.choice(patterns)}"')
+                if rng.random() < 0.2:
+                    lines.append("  priority: 1")
+                if rng.random() < 0.2:
+                    lines.append("  terminal: true")
+                users = rng.sample(USERS[:3] + ['"*"'], rng.randint(1, 2))
+                lines.append(f"  access:\n    {rng.choice(levels)}: [{', '.join(users)}]")
+            content = "\n".join(lines) + "\n"
+            perm_file = parse_permission_file(content)
+            with self.subTest(content=content):
+                self.assertEqual(
+                    _resolutions({"": perm_file.minimize()}), _resolutions({"": perm_file})
+                )
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,124 @@
+"""Tests for ! exclusion patterns in syft.pub.yaml rules."""
+
+import os
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+import yaml
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+import syft_perm  # noqa: E402
+from syft_perm._impl import clear_permission_cache  # noqa: E402
+
+
+class TestNegationPatterns(unittest.TestCase):
+    """Test that rules prefixed with ! carve exceptions out of broader grants."""
+
+    def setUp(self):
+        """Create a temporary directory for test files."""
+        self.test_dir = tempfile.mkdtemp(prefix="syft_perm_test_")
+        clear_permission_cache()
+
+    def tearDown(self):
+        """Clean up test directory."""
+        if os.path.exists(self.test_dir):
+            shutil.rmtree(self.test_dir)
+        clear_permission_cache()
+
+    def _create_files(self, files):
+        for file_path in files:
+            full_path = Path(self.test_dir) / file_path
+            full_path.parent.mkdir(parents=True, exist_ok=True)
+            full_path.write_text(f"content of {file_path}")
+
+    def _write_rules(self, directory, rules):
+        yaml_file = Path(self.test_dir) / directory / "syft.pub.yaml"
+        yaml_file.parent.mkdir(parents=True, exist_ok=True)
+        with open(yaml_file, "w") as f:
+            yaml.dump({"rules": rules}, f)
+
+    def test_exclusion_denies_secrets_within_grant(self):
+        """**/*.py grants read, !**/secret_*.py carves out the secrets."""
+        self._create_files(["main.py", "src/utils.py", "secret_keys.py", "src/secret_token.py"])
+        self._write_rules(
+            "",
+            [
+                {"pattern": "**/*.py", "access": {"read": ["alice@example.com"]}},
+                {"pattern": "!**/secret_*.py"},
+            ],
+        )
+
+        for allowed in ["main.py", "src/utils.py"]:
+            syft_file = syft_perm.open(Path(self.test_dir) / allowed)
+            self.assertTrue(
+                syft_file.has_read_access("alice@example.com"), f"{allowed} should be readable"
+            )
+
+        for denied in ["secret_keys.py", "src/secret_token.py"]:
+            syft_file = syft_perm.open(Path(self.test_dir) / denied)
+            self.assertFalse(
+                syft_file.has_read_access("alice@example.com"), f"{denied} should be excluded"
+            )
+
+    def test_more_specific_include_beats_broader_exclusion(self):
+        """An include more specific than the exclusion still grants access."""
+        self._create_files(["public/api.py", "private/api.py"])
+        self._write_rules(
+            "",
+            [
+                {"pattern": "!**/*.py"},
+                {"pattern": "public/api.py", "access": {"read": ["alice@example.com"]}},
+                {"pattern": "**", "access": {"read": ["alice@example.com"]}},
+            ],
+        )
+
+        syft_public = syft_perm.open(Path(self.test_dir) / "public/api.py")
+        self.assertTrue(syft_public.has_read_access("alice@example.com"))
+
+        syft_private = syft_perm.open(Path(self.test_dir) / "private/api.py")
+        self.assertFalse(syft_private.has_read_access("alice@example.com"))
+
+    def test_exclusion_wins_over_equally_specific_include(self):
+        """Exclusions are evaluated after includes of the same specificity."""
+        self._create_files(["data.csv"])
+        self._write_rules(
+            "",
+            [
+                {"pattern": "*.csv", "access": {"write": ["alice@example.com"]}},
+                {"pattern": "!*.csv"},
+            ],
+        )
+
+        syft_file = syft_perm.open(Path(self.test_dir) / "data.csv")
+        self.assertFalse(syft_file.has_read_access("alice@example.com"))
+
+    def test_exclusion_only_results_in_no_access(self):
+        """A path matched only by an exclusion has no access and raises no error."""
+        self._create_files(["notes/todo.txt"])
+        self._write_rules("", [{"pattern": "!**/*.txt"}])
+
+        syft_file = syft_perm.open(Path(self.test_dir) / "notes/todo.txt")
+        self.assertFalse(syft_file.has_read_access("alice@example.com"))
+        has_read, reasons = syft_file._check_permission_with_reasons("alice@example.com", "read")
+        self.assertFalse(has_read)
+        self.assertTrue(reasons)
+
+    def test_child_exclusion_carves_out_parent_grant(self):
+        """An exclusion in a nested file is the nearest match and blocks the parent grant."""
+        self._create_files(["project/report.csv", "project/secret.csv"])
+        self._write_rules("", [{"pattern": "**", "access": {"read": ["*"]}}])
+        self._write_rules("project", [{"pattern": "!secret.csv"}])
+
+        syft_report = syft_perm.open(Path(self.test_dir) / "project/report.csv")
+        self.assertTrue(syft_report.has_read_access("bob@example.com"))
+
+        syft_secret = syft_perm.open(Path(self.test_dir) / "project/secret.csv")
+        self.assertFalse(syft_secret.has_read_access("bob@example.com"))
+
+
+if __name__ == "__main__":
+    unittest.main()
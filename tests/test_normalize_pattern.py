@@ -0,0 +1,99 @@
+"""Tests for canonicalizing how patterns are written before comparing them."""
+
+import sys
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    clear_pattern_cache,
+    compile_pattern,
+    get_pattern_cache_stats,
+    merge_rule_chain,
+    normalize_pattern,
+    parse_permission_file,
+)
+
+
+class TestNormalizePattern(unittest.TestCase):
+    """Test that spellings of one pattern share a canonical form."""
+
+    def test_equivalent_spellings(self):
+        """Redundant separators, ./ prefixes and . segments are dropped."""
+        for pattern in ("data/x", "data//x", "./data/x", "data/./x"):
+            with self.subTest(pattern=pattern):
+                self.assertEqual(normalize_pattern(pattern), "data/x")
+
+    def test_doublestar_kept(self):
+        """``**`` is never collapsed into ``*``, even next to redundant separators."""
+        self.assertEqual(normalize_pattern("data//**"), "data/**")
+        self.assertEqual(normalize_pattern("./**//*.csv"), "**/*.csv")
+        self.assertEqual(normalize_pattern("**/**"), "**/**")
+        self.assertNotEqual(normalize_pattern("data/**"), normalize_pattern("data/*"))
+
+    def test_root_anchor_kept(self):
+        """A leading ``/`` anchors the pattern, so it stays, collapsed to one."""
+        self.assertEqual(normalize_pattern("//data/./x"), "/data/x")
+        self.assertEqual(normalize_pattern("!/data//x"), "!/data/x")
+        self.assertNotEqual(normalize_pattern("/data/x"), normalize_pattern("data/x"))
+
+    def test_trailing_slash_kept(self):
+        """A trailing ``/`` limits a rule to directories, so it stays, collapsed to one."""
+        self.assertEqual(normalize_pattern("data//x//"), "data/x/")
+        self.assertEqual(normalize_pattern("./data/**/"), "data/**/")
+        self.assertNotEqual(normalize_pattern("data/x/"), normalize_pattern("data/x"))
+
+    def test_exclusion_marker_kept(self):
+        """A leading ``!`` survives and the rest is normalized."""
+        self.assertEqual(normalize_pattern("!./secret//*.key"), "!secret/*.key")
+
+    def test_already_canonical(self):
+        """Canonical patterns, including escapes and braces, are returned unchanged."""
+        for pattern in ("*.txt", "a/{b,c}/d", "file\\*.txt", "[ab]?/x"):
+            with self.subTest(pattern=pattern):
+                self.assertEqual(normalize_pattern(pattern), pattern)
+
+    def test_shared_cache_entry(self):
+        """Equivalent spellings compile to one cached matcher."""
+        clear_pattern_cache()
+        matcher = compile_pattern("data/x")
+        self.assertIs(compile_pattern("./data//x"), matcher)
+        self.assertEqual(get_pattern_cache_stats()["size"], 1)
+        self.assertIsNot(compile_pattern("data/**"), compile_pattern("data/*"))
+
+    def test_validate_flags_duplicates(self):
+        """Differently written copies of a pattern are reported as identical."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "data/x"
+  access:
+    read: [alice@example.com]
+- pattern: "./data//x"
+  access:
+    write: [alice@example.com]
+"""
+        )
+        self.assertEqual([c.overlap for c in perm_file.validate()], ["identical"])
+
+    def test_merge_dedupes(self):
+        """A merged ruleset lists differently written copies of a pattern once."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "data/x"
+  access:
+    read: [alice@example.com]
+- pattern: "data//x"
+  access:
+    write: [alice@example.com]
+- pattern: "data/**"
+  access:
+    write: [alice@example.com]
+"""
+        )
+        ruleset = merge_rule_chain([perm_file])
+        self.assertEqual([effective.rule.pattern for effective in ruleset], ["data/x", "data/**"])
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,92 @@
+"""Tests for the {owner} placeholder in rule user lists."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    PermissionStore,
+    Resolver,
+    parse_permission_file,
+)
+
+TEMPLATE = """rules:
+- pattern: "**"
+  access:
+    write: [{owner}]
+    read: ["*@example.com"]
+"""
+
+
+class TestOwnerPlaceholder(unittest.TestCase):
+    """Test that {owner} resolves to the datasite owner given to the resolver."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_placeholder_parsed(self):
+        """Both the unquoted yaml form and the quoted string load as the placeholder."""
+        quoted = parse_permission_file(TEMPLATE.replace("[{owner}]", '["{owner}"]'))
+        unquoted = parse_permission_file(TEMPLATE)
+        self.assertEqual(unquoted.rules[0].users_for(AccessLevel.WRITE), ["{owner}"])
+        self.assertEqual(unquoted, quoted)
+        self.assertEqual(parse_permission_file(unquoted.to_yaml()), unquoted)
+
+    def test_owner_granted(self):
+        """The owner gets what {owner} grants; nobody does when no owner is set."""
+        (self.test_dir / "syft.pub.yaml").write_text(TEMPLATE)
+        resolver = Resolver(self.test_dir, owner="alice@example.com")
+        self.assertEqual(resolver.resolve("a.txt", "alice@example.com"), AccessLevel.WRITE)
+        self.assertEqual(resolver.resolve("a.txt", "bob@example.com"), AccessLevel.READ)
+
+        anonymous = Resolver(self.test_dir)
+        self.assertEqual(anonymous.resolve("a.txt", "alice@example.com"), AccessLevel.READ)
+        self.assertEqual(anonymous.resolve("a.txt", "{owner}"), AccessLevel.NONE)
+
+    def test_store_uses_datasite_name(self):
+        """Templates loaded into the store grant each datasite's own owner."""
+        for owner in ("alice@example.com", "bob@example.com"):
+            (self.test_dir / owner).mkdir()
+            (self.test_dir / owner / "syft.pub.yaml").write_text(TEMPLATE)
+        store = PermissionStore(self.test_dir)
+        store.reload_all()
+
+        alice = store.get("alice@example.com")
+        self.assertEqual(alice.resolve("a.txt", "alice@example.com"), AccessLevel.WRITE)
+        self.assertEqual(alice.resolve("a.txt", "bob@example.com"), AccessLevel.READ)
+        bob = store.get("bob@example.com")
+        self.assertEqual(bob.resolve("a.txt", "bob@example.com"), AccessLevel.WRITE)
+
+    def test_conflicts_between_owner_entries(self):
+        """Rules giving {owner} different levels conflict; other users are unaffected."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "**"
+  access:
+    read: [{owner}]
+- pattern: "**"
+  access:
+    admin: [{owner}]
+"""
+        )
+        self.assertEqual([c.user for c in perm_file.validate()], ["{owner}"])
+
+    def test_unknown_placeholder_rejected(self):
+        """Any other {name} entry is a load-time error."""
+        for users in ["[{user}]", '["{datasite}"]']:
+            with self.assertRaisesRegex(ValueError, "unknown placeholder"):
+                parse_permission_file(TEMPLATE.replace("[{owner}]", users))
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,145 @@
+"""Tests for compiled pattern matchers and the shared pattern cache."""
+
+import sys
+import threading
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    InvalidPatternError,
+    MatchOptions,
+    PatternMatcher,
+    clear_pattern_cache,
+    compile_pattern,
+    get_pattern_cache_stats,
+    match,
+    match_any,
+    parse_permission_file,
+    warm_pattern_cache,
+)
+
+
+class TestPatternMatcher(unittest.TestCase):
+    """Test that compiled patterns behave like the matcher they replace."""
+
+    def setUp(self):
+        clear_pattern_cache()
+
+    def tearDown(self):
+        clear_pattern_cache()
+
+    def test_agrees_with_match(self):
+        """Compiled patterns give the same answers as match()."""
+        patterns = [
+            "**",
+            "*.txt",
+            "docs/**",
+            "**/*.py",
+            "src/{a,b}/*.go",
+            "data/file[0-9].csv",
+            "a/**/b/*.md",
+            "exact/path.txt",
+            "./readme.md",
+        ]
+        paths = [
+            "readme.md",
+            "notes.txt",
+            "docs/a/b.txt",
+            "src/a/main.go",
+            "src/c/main.go",
+            "x/y/z.py",
+            "data/file7.csv",
+            "data/fileX.csv",
+            "a/b/c.md",
+            "a/x/y/b/c.md",
+            "exact/path.txt",
+        ]
+        for pattern in patterns:
+            matcher = PatternMatcher(pattern)
+            for path in paths:
+                self.assertEqual(matcher.match_path(path), match(pattern, path), (pattern, path))
+
+    def test_case_insensitive_option(self):
+        """Options are applied when the pattern is compiled."""
+        matcher = PatternMatcher("Docs/*.PDF", MatchOptions(case_insensitive=True))
+        self.assertTrue(matcher.match_path("docs/report.pdf"))
+        self.assertFalse(PatternMatcher("Docs/*.PDF").match_path("docs/report.pdf"))
+
+    def test_match_any_returns_first_match(self):
+        """The index of the first matching pattern, in the order given, is returned."""
+        patterns = ["docs/*.md", "**/*.md", "**"]
+        self.assertEqual(match_any(patterns, "docs/readme.md"), 0)
+        self.assertEqual(match_any(patterns, "src/notes.md"), 1)
+        self.assertEqual(match_any(patterns, "src/main.py"), 2)
+        self.assertEqual(match_any(list(reversed(patterns)), "docs/readme.md"), 0)
+        self.assertIsNone(match_any(["*.txt", "docs/**"], "src/main.py"))
+        self.assertIsNone(match_any([], "a.txt"))
+        self.assertEqual(match_any(["DOCS/*"], "docs/a.md", MatchOptions(case_insensitive=True)), 0)
+
+    def test_match_any_validates_every_pattern(self):
+        """A malformed pattern is reported even after an earlier one matches."""
+        with self.assertRaises(InvalidPatternError) as ctx:
+            match_any(["**", "data/[abc"], "a.txt")
+        self.assertEqual(ctx.exception.pattern, "data/[abc")
+
+    def test_invalid_patterns_rejected(self):
+        """Syntax errors surface when compiling instead of silently never matching."""
+        for pattern in ["", "data/[abc", "trailing\\"]:
+            with self.assertRaises(ValueError, msg=pattern):
+                PatternMatcher(pattern)
+
+    def test_cache_reuses_compiled_patterns(self):
+        """The same pattern and options return the same compiled matcher."""
+        first = compile_pattern("**/*.csv")
+        self.assertIs(compile_pattern("**/*.csv"), first)
+        folded = compile_pattern("**/*.csv", MatchOptions(case_insensitive=True))
+        self.assertIsNot(folded, first)
+        self.assertEqual(get_pattern_cache_stats()["size"], 2)
+
+    def test_warm_from_permission_files(self):
+        """Pre-warming compiles every rule pattern that doesn't depend on the user."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "*.csv"
+  access:
+    read: ["*"]
+- pattern: "!secret/**"
+- pattern: "users/{user}/**"
+  access:
+    write: ["*"]
+"""
+        )
+        self.assertEqual(warm_pattern_cache([perm_file]), 2)
+        self.assertEqual(get_pattern_cache_stats()["size"], 2)
+        # Exclusion markers are stripped before compiling
+        self.assertTrue(compile_pattern("secret/**").match_path("secret/a"))
+        self.assertEqual(get_pattern_cache_stats()["size"], 2)
+
+    def test_concurrent_use(self):
+        """Many threads compiling and matching the same patterns agree with match()."""
+        patterns = [f"dir{i}/**/*.txt" for i in range(20)]
+        errors = []
+
+        def worker():
+            try:
+                for _ in range(50):
+                    for i, pattern in enumerate(patterns):
+                        if not compile_pattern(pattern).match_path(f"dir{i}/a/b.txt"):
+                            errors.append(pattern)
+            except Exception as e:  # pragma: no cover - surfaced via assertion below
+                errors.append(e)
+
+        threads = [threading.Thread(target=worker) for _ in range(8)]
+        for thread in threads:
+            thread.start()
+        for thread in threads:
+            thread.join()
+
+        self.assertEqual(errors, [])
+        self.assertEqual(get_pattern_cache_stats()["size"], len(patterns))
+
+
+if __name__ == "__main__":
+    unittest.main()
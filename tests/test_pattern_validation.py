@@ -0,0 +1,125 @@
+"""Tests for load-time validation of rule patterns."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    DEFAULT_MAX_WILDCARDS,
+    AccessLevel,
+    InvalidPatternError,
+    PatternSyntaxError,
+    Resolver,
+    load_permission_file,
+    parse_permission_file,
+)
+
+PATHOLOGICAL = """rules:
+- pattern: "{}/*.txt"
+  access:
+    read: ["*"]
+""".format("/".join(["**"] * 30))
+
+
+class TestPatternValidation(unittest.TestCase):
+    """Test that malformed patterns are rejected when a permission file is loaded."""
+
+    def setUp(self):
+        """Create a temporary directory."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_all_bad_patterns_reported(self):
+        """Every malformed pattern is listed with its file and rule index."""
+        yaml_path = self.test_dir / "syft.pub.yaml"
+        yaml_path.write_text(
+            """rules:
+- pattern: "[unclosed"
+  access:
+    read: ["*"]
+- pattern: "*.txt"
+  access:
+    read: ["*"]
+- pattern: "!"
+- pattern: "data/\\\\"
+"""
+        )
+
+        with self.assertRaises(PatternSyntaxError) as ctx:
+            load_permission_file(yaml_path)
+
+        error = ctx.exception
+        self.assertEqual(error.source, str(yaml_path))
+        self.assertEqual([index for index, _, _ in error.errors], [0, 2, 3])
+        self.assertEqual(error.errors[0][1], "[unclosed")
+        message = str(error)
+        self.assertIn(str(yaml_path), message)
+        self.assertIn("rule 0 ('[unclosed')", message)
+        self.assertIn("unterminated character class", message)
+        self.assertIn("rule 3", message)
+        self.assertNotIn("rule 1", message)
+
+    def test_is_a_value_error(self):
+        """Callers catching ValueError for bad files keep working."""
+        with self.assertRaises(ValueError):
+            parse_permission_file('rules:\n- pattern: "a/[b"\n')
+
+    def test_valid_file_loads_unchanged(self):
+        """Valid patterns, including escapes and character classes, load as before."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "file[0-9].csv"
+- pattern: "literal\\\\*star"
+- pattern: "{a,b}/**"
+- pattern: "!secret/**"
+"""
+        )
+        self.assertEqual(
+            [rule.pattern for rule in perm_file.rules],
+            ["file[0-9].csv", "literal\\*star", "{a,b}/**", "!secret/**"],
+        )
+
+
+    def test_too_many_wildcards_rejected(self):
+        """A pattern with dozens of ** segments is rejected when the file is loaded."""
+        with self.assertRaises(InvalidPatternError) as ctx:
+            parse_permission_file(PATHOLOGICAL)
+        self.assertIn(f"more than the limit of {DEFAULT_MAX_WILDCARDS}", str(ctx.exception))
+
+        (self.test_dir / "syft.pub.yaml").write_text(PATHOLOGICAL)
+        with self.assertRaises(PatternSyntaxError):
+            Resolver(self.test_dir).resolve("a.txt", "bob@example.com")
+
+    def test_normal_wildcard_patterns_pass(self):
+        """Runs of stars count once and escaped or bracketed stars don't count."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "**/data/**/*.csv"
+- pattern: "a/**/b/**/c/**/d/**/*.??"
+- pattern: '[*]\\*[?]\\?/**'
+"""
+        )
+        self.assertEqual(len(perm_file.rules), 3)
+        with self.assertRaises(PatternSyntaxError):
+            parse_permission_file('rules:\n- pattern: "**/*/**/*"\n', max_wildcards=3)
+
+    def test_wildcard_limit_configurable(self):
+        """The limit can be raised or turned off for trusted files."""
+        self.assertEqual(len(parse_permission_file(PATHOLOGICAL, max_wildcards=None).rules), 1)
+        (self.test_dir / "syft.pub.yaml").write_text(PATHOLOGICAL)
+        self.assertEqual(
+            len(load_permission_file(self.test_dir / "syft.pub.yaml", max_wildcards=40).rules), 1
+        )
+        resolver = Resolver(self.test_dir, max_wildcards=None)
+        self.assertEqual(resolver.resolve("a/b.txt", "bob@example.com"), AccessLevel.READ)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,408 @@
+"""Tests for the in-memory permission store and concurrent reloads."""
+
+import importlib.util
+import queue
+import shutil
+import sys
+import tempfile
+import threading
+import time
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import AccessLevel, PermissionStore  # noqa: E402
+from syft_perm.core.store import _PendingReloads  # noqa: E402
+
+
+def _grant(level):
+    return f"""rules:
+- pattern: "**"
+  access:
+    {level}: ["*"]
+"""
+
+
+class TestPermissionStore(unittest.TestCase):
+    """Test loading, reloading and dropping datasite snapshots."""
+
+    def setUp(self):
+        """Create a temporary datasites directory."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        self.store = PermissionStore(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_reload_all_loads_every_datasite(self):
+        """Each datasite directory gets a snapshot; hidden ones are ignored."""
+        write_file(self.test_dir, "alice@example.com/syft.pub.yaml", _grant("read"))
+        write_file(self.test_dir, "bob@example.com/docs/syft.pub.yaml", _grant("write"))
+        (self.test_dir / ".cache").mkdir()
+        self.store.reload_all()
+
+        self.assertEqual(self.store.datasites, ["alice@example.com", "bob@example.com"])
+        alice = self.store.get("alice@example.com")
+        self.assertEqual(alice.resolve("a.txt", "x@example.com"), AccessLevel.READ)
+        bob = self.store.get("bob@example.com")
+        self.assertEqual(bob.resolve("docs/a.txt", "x@example.com"), AccessLevel.WRITE)
+        self.assertEqual(bob.resolve("a.txt", "x@example.com"), AccessLevel.NONE)
+        self.assertIsNone(self.store.get("carol@example.com"))
+
+    def test_snapshot_ignores_disk_until_reload(self):
+        """Edits on disk are only seen after reloading that datasite."""
+        write_file(self.test_dir, "alice@example.com/syft.pub.yaml", _grant("read"))
+        self.store.reload_all()
+        before = self.store.get("alice@example.com")
+
+        write_file(self.test_dir, "alice@example.com/syft.pub.yaml", _grant("admin"))
+        self.assertEqual(before.resolve("a.txt", "x@example.com"), AccessLevel.READ)
+        self.store.reload("alice@example.com")
+        after = self.store.get("alice@example.com")
+        self.assertEqual(after.resolve("a.txt", "x@example.com"), AccessLevel.ADMIN)
+        # Snapshots already handed out are never modified
+        self.assertEqual(before.resolve("a.txt", "x@example.com"), AccessLevel.READ)
+
+    def test_failed_reload_keeps_previous_snapshot(self):
+        """A malformed file raises and leaves the loaded snapshot in place."""
+        write_file(self.test_dir, "alice@example.com/syft.pub.yaml", _grant("read"))
+        self.store.reload_all()
+        write_file(self.test_dir, "alice@example.com/syft.pub.yaml", "rules: [")
+
+        with self.assertRaises(ValueError):
+            self.store.reload("alice@example.com")
+        with self.assertRaises(ValueError):
+            self.store.reload_all()
+        snapshot = self.store.get("alice@example.com")
+        self.assertEqual(snapshot.resolve("a.txt", "x@example.com"), AccessLevel.READ)
+
+    def test_removed_datasite_is_dropped(self):
+        """Reloading a datasite whose directory is gone removes it."""
+        write_file(self.test_dir, "alice@example.com/syft.pub.yaml", _grant("read"))
+        self.store.reload_all()
+        shutil.rmtree(self.test_dir / "alice@example.com")
+        self.store.reload("alice@example.com")
+        self.assertIsNone(self.store.get("alice@example.com"))
+
+    def test_concurrent_get_during_reload(self):
+        """Readers racing a reloader only ever see a fully loaded snapshot."""
+        # Both files change together, so a torn snapshot would give mismatched levels
+        for level in ("read", "write"):
+            write_file(self.test_dir, f".versions/{level}/syft.pub.yaml", _grant(level))
+            write_file(self.test_dir, f".versions/{level}/deep/syft.pub.yaml", _grant(level))
+        write_file(self.test_dir, "alice@example.com/syft.pub.yaml", _grant("read"))
+        write_file(self.test_dir, "alice@example.com/deep/syft.pub.yaml", _grant("read"))
+        self.store.reload_all()
+
+        stop = threading.Event()
+        errors = []
+
+        def reader():
+            while not stop.is_set():
+                snapshot = self.store.get("alice@example.com")
+                top = snapshot.resolve("a.txt", "x@example.com")
+                deep = snapshot.resolve("deep/a.txt", "x@example.com")
+                if top != deep:
+                    errors.append((top, deep))
+
+        def reloader():
+            for i in range(50):
+                level = ("read", "write")[i % 2]
+                for rel_dir in ("", "deep/"):
+                    shutil.copy(
+                        self.test_dir / ".versions" / level / rel_dir / "syft.pub.yaml",
+                        self.test_dir / "alice@example.com" / rel_dir / "syft.pub.yaml",
+                    )
+                self.store.reload("alice@example.com")
+
+        readers = [threading.Thread(target=reader) for _ in range(4)]
+        for thread in readers:
+            thread.start()
+        reloader()
+        stop.set()
+        for thread in readers:
+            thread.join()
+
+        self.assertEqual(errors, [])
+
+
+class TestResolveCached(unittest.TestCase):
+    """Test the per-user level cache in front of the snapshots."""
+
+    def setUp(self):
+        """Create a temporary datasites directory."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        for datasite in ("alice@example.com", "bob@example.com"):
+            path = self.test_dir / datasite / "syft.pub.yaml"
+            path.parent.mkdir(parents=True)
+            path.write_text(_grant("read"))
+        self.store = PermissionStore(self.test_dir, cached_users=2)
+        self.store.reload_all()
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_cached_levels_match_snapshot(self):
+        """Cached answers are what the snapshot resolves, however the user is spelled."""
+        resolve = self.store.resolve_cached
+        self.assertEqual(resolve("alice@example.com", "a.txt", "x@example.com"), AccessLevel.READ)
+        self.assertEqual(resolve("alice@example.com", "a.txt", "X@Example.com"), AccessLevel.READ)
+        self.assertEqual(len(self.store._user_views), 1)
+        with self.assertRaises(KeyError):
+            resolve("carol@example.com", "a.txt", "x@example.com")
+
+    def test_reload_invalidates_stale_entries(self):
+        """After a reload the new rules are answered, and only that datasite is dropped."""
+        self.store.resolve_cached("alice@example.com", "a.txt", "x@example.com")
+        self.store.resolve_cached("bob@example.com", "a.txt", "x@example.com")
+        (self.test_dir / "alice@example.com" / "syft.pub.yaml").write_text(_grant("admin"))
+        self.store.reload("alice@example.com")
+
+        self.assertEqual(len(self.store._user_views), 1)
+        self.assertEqual(
+            self.store.resolve_cached("alice@example.com", "a.txt", "x@example.com"),
+            AccessLevel.ADMIN,
+        )
+
+        self.store.reload_all()
+        self.assertEqual(len(self.store._user_views), 0)
+
+    def test_tree_changes_seen_after_reload(self):
+        """A level that depended on a file's size is kept until the next reload."""
+        datasite = self.test_dir / "alice@example.com"
+        (datasite / "syft.pub.yaml").write_text(
+            _grant("read") + "  limits:\n    max_file_size: 5\n"
+        )
+        (datasite / "a.txt").write_text("x")
+        self.store.reload("alice@example.com")
+        resolve = self.store.resolve_cached
+        self.assertEqual(resolve("alice@example.com", "a.txt", "x@example.com"), AccessLevel.READ)
+
+        (datasite / "a.txt").write_text("too large")
+        snapshot = self.store.get("alice@example.com")
+        self.assertEqual(snapshot.resolve("a.txt", "x@example.com"), AccessLevel.NONE)
+        self.assertEqual(resolve("alice@example.com", "a.txt", "x@example.com"), AccessLevel.READ)
+
+        self.store.reload("alice@example.com")
+        self.assertEqual(resolve("alice@example.com", "a.txt", "x@example.com"), AccessLevel.NONE)
+
+    def test_least_recently_used_user_evicted(self):
+        """Only the most recently active users keep their cached levels."""
+        for user in ("a@example.com", "b@example.com", "a@example.com", "c@example.com"):
+            self.store.resolve_cached("alice@example.com", "x.txt", user)
+        keys = list(self.store._user_views._views)
+        self.assertEqual(
+            keys, [("alice@example.com", "a@example.com"), ("alice@example.com", "c@example.com")]
+        )
+
+    def test_concurrent_resolution(self):
+        """Readers racing reloads always get a level from a loaded snapshot."""
+        seen = set()
+        stop = threading.Event()
+
+        def reader(user):
+            while not stop.is_set():
+                seen.add(self.store.resolve_cached("alice@example.com", "a.txt", user))
+
+        readers = [threading.Thread(target=reader, args=(f"u{i}@example.com",)) for i in range(4)]
+        for thread in readers:
+            thread.start()
+        for level in ("write", "read", "admin"):
+            (self.test_dir / "alice@example.com" / "syft.pub.yaml").write_text(_grant(level))
+            self.store.reload("alice@example.com")
+        stop.set()
+        for thread in readers:
+            thread.join()
+
+        self.assertLessEqual(seen, {AccessLevel.READ, AccessLevel.WRITE, AccessLevel.ADMIN})
+        self.assertEqual(
+            self.store.resolve_cached("alice@example.com", "a.txt", "u0@example.com"),
+            AccessLevel.ADMIN,
+        )
+
+
+class TestReloadFile(unittest.TestCase):
+    """Test swapping a single permission file into a loaded snapshot."""
+
+    def setUp(self):
+        """Create a temporary datasites directory."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        self.site = self.test_dir / "alice@example.com"
+        for rel_dir in ("", "docs/"):
+            path = self.site / rel_dir / "syft.pub.yaml"
+            path.parent.mkdir(parents=True, exist_ok=True)
+            path.write_text(_grant("read"))
+        self.store = PermissionStore(self.test_dir)
+        self.store.reload_all()
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_only_that_file_is_read(self):
+        """The new file is answered while the others are carried over as loaded."""
+        (self.site / "syft.pub.yaml").write_text(_grant("admin"))
+        (self.site / "docs" / "syft.pub.yaml").write_text(_grant("write"))
+        self.store.reload_file("alice@example.com", "docs")
+
+        snapshot = self.store.get("alice@example.com")
+        self.assertEqual(snapshot.resolve("docs/a.txt", "x@example.com"), AccessLevel.WRITE)
+        self.assertEqual(snapshot.resolve("a.txt", "x@example.com"), AccessLevel.READ)
+        with self.assertRaises(KeyError):
+            self.store.reload_file("carol@example.com", "")
+
+    def test_failed_reload_keeps_snapshot(self):
+        """A malformed file raises and the loaded snapshot stays in place."""
+        before = self.store.get("alice@example.com")
+        (self.site / "docs" / "syft.pub.yaml").write_text("rules: [")
+        with self.assertRaises(ValueError):
+            self.store.reload_file("alice@example.com", "docs")
+        self.assertIs(self.store.get("alice@example.com"), before)
+
+    def test_cached_levels_under_directory_dropped(self):
+        """resolve_cached forgets the levels beneath the file and keeps the rest."""
+        resolve = self.store.resolve_cached
+        for path in ("a.txt", "docs/a.txt"):
+            resolve("alice@example.com", path, "x@example.com")
+        (self.site / "docs" / "syft.pub.yaml").write_text(_grant("admin"))
+        self.store.reload_file("alice@example.com", "docs")
+
+        view = self.store._user_views._views["alice@example.com", "x@example.com"]
+        self.assertIs(view.snapshot, self.store.get("alice@example.com"))
+        self.assertEqual(view.levels, {"a.txt": AccessLevel.READ})
+        self.assertEqual(
+            resolve("alice@example.com", "docs/a.txt", "x@example.com"), AccessLevel.ADMIN
+        )
+
+    def test_concurrent_resolution_during_reload(self):
+        """Readers racing file reloads see the old file or the new one, never a mix."""
+        # Both paths fall under the reloaded file, so a torn state would split them
+        stop = threading.Event()
+        errors = []
+
+        def reader(user):
+            while not stop.is_set():
+                snapshot = self.store.get("alice@example.com")
+                levels = snapshot.resolve_pairs([("docs/a.txt", user), ("docs/sub/b.txt", user)])
+                cached = self.store.resolve_cached("alice@example.com", "docs/a.txt", user)
+                if levels[0] != levels[1] or cached not in (AccessLevel.READ, AccessLevel.WRITE):
+                    errors.append((levels, cached))
+                top = self.store.resolve_cached("alice@example.com", "a.txt", user)
+                if top != AccessLevel.READ:
+                    errors.append(("root file changed", top))
+
+        readers = [threading.Thread(target=reader, args=(f"u{i}@example.com",)) for i in range(4)]
+        for thread in readers:
+            thread.start()
+        for i in range(50):
+            (self.site / "docs" / "syft.pub.yaml").write_text(_grant(("write", "read")[i % 2]))
+            self.store.reload_file("alice@example.com", "docs")
+        stop.set()
+        for thread in readers:
+            thread.join()
+
+        self.assertEqual(errors, [])
+        self.assertEqual(
+            self.store.resolve_cached("alice@example.com", "docs/a.txt", "u0@example.com"),
+            AccessLevel.READ,
+        )
+
+
+class TestWatchEvents(unittest.TestCase):
+    """Test which filesystem events trigger reloads and how they are debounced."""
+
+    def setUp(self):
+        self.store = PermissionStore("/datasites")
+
+    def test_debounce_coalesces_rapid_changes(self):
+        """A datasite is due only after its changes have been quiet for the window."""
+        pending = _PendingReloads(0.2)
+        pending.add("alice@example.com", now=10.0)
+        pending.add("alice@example.com", now=10.1)
+        pending.add("bob@example.com", now=10.05)
+        self.assertEqual(pending.pop_due(now=10.2), [])
+        self.assertAlmostEqual(pending.wait_time(now=10.2), 0.05)
+        self.assertEqual(pending.pop_due(now=10.26), ["bob@example.com"])
+        self.assertEqual(pending.pop_due(now=10.3), ["alice@example.com"])
+        self.assertEqual(pending.pop_due(now=11.0), [])
+        self.assertEqual(pending.wait_time(now=11.0), 0.2)
+
+    def test_changed_datasite(self):
+        """Only permission file changes and directory additions or removals count."""
+        alice = "/datasites/alice@example.com"
+        cases = [
+            (f"{alice}/syft.pub.yaml", "modified", False, "alice@example.com"),
+            (f"{alice}/a/b/syft.pub.yaml", "deleted", False, "alice@example.com"),
+            (f"{alice}/data.csv", "modified", False, None),
+            (f"{alice}/syft.pub.yaml", "opened", False, None),
+            (f"{alice}/docs", "deleted", True, "alice@example.com"),
+            (f"{alice}/docs", "modified", True, None),
+            ("/datasites/.cache/syft.pub.yaml", "modified", False, None),
+            ("/elsewhere/syft.pub.yaml", "modified", False, None),
+            ("", "moved", False, None),
+        ]
+        for path, event_type, is_directory, expected in cases:
+            self.assertEqual(
+                self.store._changed_datasite(path, event_type, is_directory), expected, path
+            )
+
+
+@unittest.skipUnless(importlib.util.find_spec("watchdog"), "watchdog not installed")
+class TestWatch(unittest.TestCase):
+    """Test automatic reloads driven by a real filesystem watcher."""
+
+    def setUp(self):
+        """Create a temporary datasites directory."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        self.store = PermissionStore(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def _wait_for(self, condition, timeout=5.0):
+        deadline = time.monotonic() + timeout
+        while time.monotonic() < deadline:
+            if condition():
+                return True
+            time.sleep(0.02)
+        return False
+
+    def test_watch_reloads_and_reports_errors(self):
+        """Edits reload the datasite, bad files are reported, deletions drop rules."""
+        yaml_path = self.test_dir / "alice@example.com" / "syft.pub.yaml"
+        yaml_path.parent.mkdir()
+        yaml_path.write_text(_grant("read"))
+        self.store.reload_all()
+
+        stop = threading.Event()
+        errors = queue.Queue()
+        watcher = threading.Thread(target=self.store.watch, args=(stop, 0.05, errors))
+        watcher.start()
+        try:
+            time.sleep(0.2)
+
+            def level():
+                return self.store.get("alice@example.com").resolve("a.txt", "x@example.com")
+
+            yaml_path.write_text(_grant("write"))
+            self.assertTrue(self._wait_for(lambda: level() == AccessLevel.WRITE))
+
+            yaml_path.write_text("rules: [")
+            self.assertIsInstance(errors.get(timeout=5.0), ValueError)
+            self.assertEqual(level(), AccessLevel.WRITE)
+
+            yaml_path.unlink()
+            self.assertTrue(self._wait_for(lambda: level() == AccessLevel.NONE))
+        finally:
+            stop.set()
+            watcher.join(timeout=5.0)
+        self.assertFalse(watcher.is_alive())
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,90 @@
+"""Tests for the top-level public list of world-readable patterns."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    Resolver,
+    Rule,
+    parse_permission_file,
+)
+
+PUBLIC = """public:
+- public/**
+- "!public/drafts/**"
+rules:
+- pattern: "public/team/**"
+  access:
+    write: [bob@example.com]
+"""
+
+
+class TestPublicShortcut(unittest.TestCase):
+    """Test desugaring public entries into ordinary rules."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        (self.test_dir / "syft.pub.yaml").write_text(PUBLIC)
+        self.resolver = Resolver(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_world_readable(self):
+        """Any user can read a public path, and nothing more."""
+        for user in ("stranger@nowhere.org", "*", "bob@example.com"):
+            with self.subTest(user=user):
+                self.assertEqual(self.resolver.resolve("public/a.txt", user), AccessLevel.READ)
+        self.assertEqual(self.resolver.resolve("private/a.txt", "x@y.org"), AccessLevel.NONE)
+
+    def test_negation(self):
+        """A ``!`` entry carves paths out of the public ones."""
+        self.assertEqual(self.resolver.resolve("public/drafts/a.txt", "x@y.org"), AccessLevel.NONE)
+
+    def test_other_rules_still_apply(self):
+        """More specific rules of the file decide as usual."""
+        resolve = self.resolver.resolve
+        self.assertEqual(resolve("public/team/a.txt", "bob@example.com"), AccessLevel.WRITE)
+        self.assertEqual(resolve("public/team/a.txt", "x@y.org"), AccessLevel.NONE)
+
+    def test_desugared_model(self):
+        """Public entries become rules after the file's own, with their position."""
+        perm_file = parse_permission_file(PUBLIC)
+        self.assertEqual(
+            perm_file.rules[1:],
+            [Rule("public/**", {AccessLevel.READ: ["*"]}), Rule("!public/drafts/**")],
+        )
+        self.assertEqual(perm_file.rules[1].position.line, 2)
+
+    def test_round_trip(self):
+        """to_yaml writes the desugared rules, which read back to the same model."""
+        perm_file = parse_permission_file(PUBLIC)
+        yaml_text = perm_file.to_yaml()
+        self.assertNotIn("public:", yaml_text)
+        self.assertIn("- pattern: public/**\n  access:\n    read:\n    - '*'\n", yaml_text)
+        self.assertEqual(parse_permission_file(yaml_text), perm_file)
+        self.assertEqual(parse_permission_file(yaml_text).to_yaml(), yaml_text)
+
+    def test_single_pattern_and_variables(self):
+        """One pattern may be given as a string, and variables are expanded in it."""
+        perm_file = parse_permission_file("public: ${site}/**\n", variables={"site": "www"})
+        self.assertEqual(perm_file.rules, [Rule("www/**", {AccessLevel.READ: ["*"]})])
+
+    def test_invalid(self):
+        """Entries must be non-empty patterns."""
+        for value in ("[1]", "['']", "{a: b}", "['a/[b']"):
+            with self.subTest(value=value):
+                with self.assertRaises(ValueError):
+                    parse_permission_file(f"public: {value}\n")
+
+
+if __name__ == "__main__":
+    unittest.main()
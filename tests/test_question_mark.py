@@ -0,0 +1,75 @@
+"""Tests for ? matching exactly one character within a path segment."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import AccessLevel, Resolver, compile_pattern, match, match_fold  # noqa: E402
+
+CASES = [
+    ("log?.txt", "log1.txt", True),
+    ("log?.txt", "log.txt", False),
+    ("log?.txt", "log12.txt", False),
+    ("log?.txt", "log/x.txt", False),
+    ("a?b", "a/b", False),
+    ("*?x", "ab/x", False),
+    ("**/log?.txt", "a/b/log1.txt", True),
+    ("**/log?.txt", "a/log/.txt", False),
+    ("a/?/c", "a/b/c", True),
+    ("a[!x]b", "a/b", False),
+    ("log?.txt", "logé.txt", True),
+    ("log?.txt", "log日.txt", True),
+    ("log??.txt", "log日本.txt", True),
+    ("log?.txt", "log日本.txt", False),
+    ("????", "данные", False),
+    ("??????", "данные", True),
+]
+
+
+class TestQuestionMark(unittest.TestCase):
+    """Test ? against every matching entry point."""
+
+    def test_match_functions(self):
+        """Plain, compiled and case-folded matching agree on every case."""
+        for pattern, path, expected in CASES:
+            with self.subTest(pattern=pattern, path=path):
+                self.assertEqual(match(pattern, path), expected)
+                self.assertEqual(compile_pattern(pattern).match_path(path), expected)
+                self.assertEqual(match_fold(pattern, path), expected)
+
+
+class TestQuestionMarkResolution(unittest.TestCase):
+    """Test ? in rules resolving files with multibyte names."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        (self.test_dir / "syft.pub.yaml").write_text(
+            """rules:
+- pattern: "rapport-?.pdf"
+  access:
+    read: [bob@example.com]
+""",
+            encoding="utf-8",
+        )
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_utf8_filename(self):
+        """A multibyte character is one character, and / is never one."""
+        resolver = Resolver(self.test_dir)
+        self.assertEqual(resolver.resolve("rapport-é.pdf", "bob@example.com"), AccessLevel.READ)
+        # Counted byte by byte, é would be two characters
+        as_bytes = "rapport-é.pdf".encode("utf-8").decode("latin-1")
+        self.assertEqual(resolver.resolve(as_bytes, "bob@example.com"), AccessLevel.NONE)
+        self.assertEqual(resolver.resolve("rapport-/.pdf", "bob@example.com"), AccessLevel.NONE)
+
+
+if __name__ == "__main__":
+    unittest.main()
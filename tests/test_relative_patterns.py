@@ -0,0 +1,76 @@
+"""Tests that rule patterns are relative to the directory of their permission file."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import AccessLevel, Resolver  # noqa: E402
+
+
+def _grant(level, user):
+    return f"""rules:
+- pattern: "*.csv"
+  access:
+    {level}: [{user}]
+"""
+
+
+class TestRelativePatterns(unittest.TestCase):
+    """Test that the same pattern in different directories matches different files."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        self.resolver = Resolver(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_same_pattern_in_nested_files(self):
+        """Each file's *.csv only covers CSVs directly inside its own directory."""
+        write_file(self.test_dir, "syft.pub.yaml", _grant("read", "alice@example.com"))
+        write_file(
+            self.test_dir, "data/projectA/syft.pub.yaml", _grant("write", "alice@example.com")
+        )
+        write_file(self.test_dir, "data/projectB/syft.pub.yaml", _grant("admin", "bob@example.com"))
+
+        expected = {
+            "x.csv": (AccessLevel.READ, AccessLevel.NONE),
+            "data/x.csv": (AccessLevel.NONE, AccessLevel.NONE),
+            "data/projectA/x.csv": (AccessLevel.WRITE, AccessLevel.NONE),
+            "data/projectA/sub/x.csv": (AccessLevel.NONE, AccessLevel.NONE),
+            "data/projectB/x.csv": (AccessLevel.NONE, AccessLevel.ADMIN),
+        }
+        for path, (alice, bob) in expected.items():
+            self.assertEqual(self.resolver.resolve(path, "alice@example.com"), alice, path)
+            self.assertEqual(self.resolver.resolve(path, "bob@example.com"), bob, path)
+
+    def test_trace_reports_file_directory(self):
+        """The rule that decided access is attributed to its own directory."""
+        write_file(self.test_dir, "syft.pub.yaml", _grant("read", "alice@example.com"))
+        write_file(
+            self.test_dir, "data/projectA/syft.pub.yaml", _grant("write", "alice@example.com")
+        )
+        level, trace = self.resolver.resolve_with_trace("data/projectA/x.csv", "alice@example.com")
+        self.assertEqual(level, AccessLevel.WRITE)
+        self.assertEqual([m.directory for m in trace if m.applied], ["data/projectA"])
+
+    def test_glob_characters_in_directory_names(self):
+        """Directory names are never treated as patterns."""
+        write_file(self.test_dir, "runs[1]/syft.pub.yaml", _grant("read", "alice@example.com"))
+        self.assertEqual(
+            self.resolver.resolve("runs[1]/out.csv", "alice@example.com"), AccessLevel.READ
+        )
+        self.assertEqual(
+            self.resolver.resolve("runs1/out.csv", "alice@example.com"), AccessLevel.NONE
+        )
+
+
+if __name__ == "__main__":
+    unittest.main()
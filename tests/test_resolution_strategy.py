@@ -0,0 +1,103 @@
+"""Tests for choosing how matching rules combine."""
+
+import sys
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    ResolutionStrategy,
+    Resolver,
+    TraceReason,
+    parse_permission_file,
+)
+
+ROOT = parse_permission_file(
+    """rules:
+- pattern: "**"
+  access:
+    write: [alice@example.com]
+    read: ["*"]
+"""
+)
+
+DATA = parse_permission_file(
+    """rules:
+- pattern: "*.csv"
+  access:
+    read: [alice@example.com]
+- pattern: "**"
+  access:
+    admin: [bob@example.com]
+- pattern: "!secret.csv"
+"""
+)
+
+
+def _resolver(strategy=ResolutionStrategy.MOST_SPECIFIC):
+    return Resolver(
+        ".", stat_func=None, permission_files={"": ROOT, "data": DATA}, strategy=strategy
+    )
+
+
+class TestResolutionStrategy(unittest.TestCase):
+    """Test the same ruleset under each strategy."""
+
+    def test_most_specific_is_default(self):
+        """Without a strategy the first matching rule of the nearest file decides."""
+        self.assertIs(Resolver(".").strategy, ResolutionStrategy.MOST_SPECIFIC)
+        resolver = _resolver()
+        self.assertEqual(resolver.resolve("data/x.csv", "alice@example.com"), AccessLevel.READ)
+        self.assertEqual(resolver.resolve("data/x.csv", "bob@example.com"), AccessLevel.NONE)
+        self.assertEqual(resolver.resolve("data/x.csv", "carol@example.com"), AccessLevel.NONE)
+
+    def test_most_permissive_takes_the_union(self):
+        """Every matching rule across the chain counts, and the highest level wins."""
+        resolver = _resolver(ResolutionStrategy.MOST_PERMISSIVE)
+        self.assertEqual(resolver.resolve("data/x.csv", "alice@example.com"), AccessLevel.WRITE)
+        self.assertEqual(resolver.resolve("data/x.csv", "bob@example.com"), AccessLevel.ADMIN)
+        self.assertEqual(resolver.resolve("data/x.csv", "carol@example.com"), AccessLevel.READ)
+        self.assertEqual(
+            resolver.users_with_access("data/x.csv", AccessLevel.WRITE),
+            (["alice@example.com", "bob@example.com"], False),
+        )
+
+        _, trace = resolver.resolve_with_trace("data/x.csv", "alice@example.com")
+        self.assertEqual(
+            [m.reason for m in trace if m.applied],
+            [TraceReason.APPLIED, TraceReason.USER_NOT_LISTED, TraceReason.APPLIED],
+        )
+
+    def test_exclusions_still_deny(self):
+        """A matching exclusion denies the path under both strategies."""
+        for strategy in ResolutionStrategy:
+            with self.subTest(strategy=strategy):
+                resolver = _resolver(strategy)
+                level = resolver.resolve("data/secret.csv", "bob@example.com")
+                self.assertEqual(level, AccessLevel.NONE)
+                self.assertEqual(resolver.users_with_access("data/secret.csv"), ([], False))
+
+    def test_terminal_limits_the_union(self):
+        """Files above a terminal file are not part of the union."""
+        vault = parse_permission_file(
+            """terminal: true
+rules:
+- pattern: "**"
+  access:
+    read: [bob@example.com]
+"""
+        )
+        resolver = Resolver(
+            ".",
+            stat_func=None,
+            permission_files={"": ROOT, "vault": vault},
+            strategy=ResolutionStrategy.MOST_PERMISSIVE,
+        )
+        self.assertEqual(resolver.resolve("vault/k.pem", "alice@example.com"), AccessLevel.NONE)
+        self.assertEqual(resolver.resolve("vault/k.pem", "bob@example.com"), AccessLevel.READ)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,82 @@
+"""Tests for Resolver.resolve_batch."""
+
+import random
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+from unittest.mock import patch
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import build_datasite  # noqa: E402
+from syft_perm.core import AccessLevel, Resolver  # noqa: E402
+from syft_perm.core import resolver as resolver_module  # noqa: E402
+
+
+class TestResolveBatch(unittest.TestCase):
+    """Test batch resolution results and work sharing."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        build_datasite(self.test_dir)
+        self.resolver = Resolver(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_batch_matches_single_resolution(self):
+        """Every batch result equals resolving that path on its own."""
+        paths = [
+            "readme.md",
+            "data/a.csv",
+            "data/a.json",
+            "data/private/keys.pem",
+            "data/private/deep/x.csv",
+        ]
+        for user in ["alice@example.com", "bob@example.com", "carol@example.com"]:
+            results = self.resolver.resolve_batch(paths, user)
+            self.assertEqual(set(results), set(paths))
+            for path in paths:
+                self.assertEqual(results[path], self.resolver.resolve(path, user), path)
+
+        results = self.resolver.resolve_batch(paths, "alice@example.com")
+        self.assertEqual(results["data/a.csv"], AccessLevel.WRITE)
+        self.assertEqual(results["data/private/keys.pem"], AccessLevel.NONE)
+
+    def test_order_independent_and_duplicates_collapse(self):
+        """Shuffled input gives the same mapping and duplicates appear once."""
+        paths = ["data/a.csv", "readme.md", "data/a.csv", "data/private/x"]
+        forward = self.resolver.resolve_batch(paths, "alice@example.com")
+        backward = self.resolver.resolve_batch(list(reversed(paths)), "alice@example.com")
+        self.assertEqual(forward, backward)
+        self.assertEqual(len(forward), 3)
+
+    def test_large_batch_matches_loop(self):
+        """Resolving 10k paths in one batch gives what looping over resolve() does."""
+        rng = random.Random(0)
+        directories = ["", "data/", "data/private/", "docs/", "data/private/deep/"]
+        paths = [
+            f"{rng.choice(directories)}file{i}.{rng.choice(['csv', 'txt'])}" for i in range(10000)
+        ]
+        loop_results = {path: self.resolver.resolve(path, "alice@example.com") for path in paths}
+        self.assertEqual(self.resolver.resolve_batch(paths, "alice@example.com"), loop_results)
+
+    def test_permission_files_loaded_once(self):
+        """Each permission file on the way to the root is loaded once per batch."""
+        paths = [f"data/file{i}.csv" for i in range(50)]
+        paths += [f"data/private/file{i}.txt" for i in range(50)]
+
+        real_load = resolver_module.load_permission_file
+        with patch.object(
+            resolver_module, "load_permission_file", side_effect=real_load
+        ) as load_mock:
+            self.resolver.resolve_batch(paths, "alice@example.com")
+        self.assertEqual(load_mock.call_count, 3)
+
+
+if __name__ == "__main__":
+    unittest.main()
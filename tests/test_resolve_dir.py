@@ -0,0 +1,87 @@
+"""Tests for summarizing access to a directory from its own rule and children."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import AccessLevel, MemoryFileSystem, Resolver  # noqa: E402
+
+
+class TestResolveDir(unittest.TestCase):
+    """Test the level and the mixed flag returned for directories."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "shared"
+  access:
+    write: [bob@example.com]
+- pattern: "mixed/*.csv"
+  access:
+    write: [bob@example.com]
+- pattern: "**"
+  access:
+    read: [bob@example.com]
+""",
+        )
+        write_file(self.test_dir, "uniform/a.txt", "a")
+        write_file(self.test_dir, "uniform/b.txt", "b")
+        write_file(self.test_dir, "uniform/.hidden", "not counted")
+        write_file(self.test_dir, "mixed/a.csv", "1")
+        write_file(self.test_dir, "mixed/b.txt", "b")
+        write_file(self.test_dir, "shared/a.txt", "a")
+        (self.test_dir / "empty").mkdir()
+        self.resolver = Resolver(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_uniform_directory(self):
+        """Children with the same level make an unmixed directory."""
+        result = self.resolver.resolve_dir("uniform", "bob@example.com")
+        self.assertEqual(result, (AccessLevel.READ, False))
+
+    def test_mixed_directory_reports_least_access(self):
+        """Without a rule of its own, a directory shows its least accessible child."""
+        result = self.resolver.resolve_dir("mixed", "bob@example.com")
+        self.assertEqual(result, (AccessLevel.READ, True))
+
+    def test_own_rule_decides(self):
+        """A rule matching the directory itself sets its level."""
+        result = self.resolver.resolve_dir("shared", "bob@example.com")
+        self.assertEqual(result, (AccessLevel.WRITE, True))
+
+    def test_root_and_empty_directories(self):
+        """The datasite root aggregates its children; empty directories use the default."""
+        self.assertEqual(self.resolver.resolve_dir("", "bob@example.com"), (AccessLevel.READ, True))
+        self.assertEqual(
+            self.resolver.resolve_dir("empty", "carol@example.com"), (AccessLevel.NONE, False)
+        )
+        self.assertEqual(
+            self.resolver.resolve_dir("missing", "carol@example.com"), (AccessLevel.NONE, False)
+        )
+
+    def test_virtual_filesystem(self):
+        """Children are listed through the resolver's filesystem."""
+        rules = """rules:
+- pattern: "docs/public.md"
+  access:
+    read: ["*"]
+"""
+        fs = MemoryFileSystem({"syft.pub.yaml": rules, "docs/public.md": "", "docs/private.md": ""})
+        resolver = Resolver("/nonexistent", filesystem=fs)
+        self.assertEqual(resolver.resolve_dir("docs", "x@example.com"), (AccessLevel.NONE, True))
+
+
+if __name__ == "__main__":
+    unittest.main()
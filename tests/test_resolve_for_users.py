@@ -0,0 +1,87 @@
+"""Tests for Resolver.resolve_for_users."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+from unittest.mock import patch
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import build_datasite  # noqa: E402
+from syft_perm.core import AccessLevel, ResolutionStrategy, Resolver  # noqa: E402
+
+USERS = [
+    "alice@example.com",
+    "bob@example.com",
+    "carol@other.org",
+    "dan@example.com",
+    "nobody@nowhere.net",
+]
+
+
+class TestResolveForUsers(unittest.TestCase):
+    """Test that resolving for many users agrees with resolving each one."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        build_datasite(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_matches_resolve(self):
+        """Every user gets the level resolve gives them, under both strategies."""
+        paths = ["readme.md", "data/a.csv", "data/notes.txt", "data/private/x.csv"]
+        for strategy in ResolutionStrategy:
+            resolver = Resolver(self.test_dir, strategy=strategy)
+            for path in paths:
+                with self.subTest(strategy=strategy, path=path):
+                    expected = {user: resolver.resolve(path, user) for user in USERS}
+                    self.assertEqual(resolver.resolve_for_users(path, USERS), expected)
+
+    def test_levels(self):
+        """Grants, revokes and terminals are applied per user."""
+        levels = Resolver(self.test_dir).resolve_for_users("data/a.csv", USERS)
+        self.assertEqual(
+            levels,
+            {
+                "alice@example.com": AccessLevel.WRITE,
+                "bob@example.com": AccessLevel.WRITE,
+                "carol@other.org": AccessLevel.READ,
+                "dan@example.com": AccessLevel.NONE,
+                "nobody@nowhere.net": AccessLevel.READ,
+            },
+        )
+
+    def test_duplicates_and_empty(self):
+        """Repeated users collapse into one entry; no users gives an empty map."""
+        resolver = Resolver(self.test_dir)
+        levels = resolver.resolve_for_users("readme.md", ["bob@example.com"] * 3)
+        self.assertEqual(levels, {"bob@example.com": AccessLevel.READ})
+        self.assertEqual(resolver.resolve_for_users("readme.md", []), {})
+
+    def test_patterns_matched_once(self):
+        """Each rule is matched against the path once, however many users there are."""
+        resolver = Resolver(self.test_dir)
+        real_matches = resolver._matches
+        with patch.object(resolver, "_matches", side_effect=real_matches) as matches_mock:
+            resolver.resolve("data/a.csv", USERS[0])
+        single = matches_mock.call_count
+        with patch.object(resolver, "_matches", side_effect=real_matches) as matches_mock:
+            resolver.resolve_for_users("data/a.csv", USERS)
+        self.assertEqual(matches_mock.call_count, single)
+
+    def test_many_users_match_loop(self):
+        """Resolving a path for 1k users at once gives what looping over resolve() does."""
+        resolver = Resolver(self.test_dir)
+        users = [f"user{i}@example.com" for i in range(1000)] + USERS
+        loop_results = {user: resolver.resolve("data/a.csv", user) for user in users}
+        self.assertEqual(resolver.resolve_for_users("data/a.csv", users), loop_results)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,102 @@
+"""Tests for Resolver.resolve_pairs."""
+
+import random
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+from unittest.mock import patch
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import build_datasite  # noqa: E402
+from syft_perm.core import AccessLevel, Cancellation, ResolutionCancelled, Resolver  # noqa: E402
+from syft_perm.core import resolver as resolver_module  # noqa: E402
+
+USERS = ["alice@example.com", "bob@example.com", "carol@example.com"]
+
+
+class TestResolvePairs(unittest.TestCase):
+    """Test pair resolution results, order and work sharing."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        build_datasite(self.test_dir)
+        self.resolver = Resolver(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_matches_single_resolution_in_order(self):
+        """Each result equals resolving its pair alone, at the pair's position."""
+        pairs = [
+            ("data/private/keys.pem", "bob@example.com"),
+            ("data/a.csv", "alice@example.com"),
+            ("readme.md", "carol@example.com"),
+            ("data/a.csv", "bob@example.com"),
+            ("users/dave@example.com/x.txt", "dave@example.com"),
+            ("users/dave@example.com/x.txt", "erin@example.com"),
+            ("data/deep/b.json", "carol@example.com"),
+            ("data/private/keys.pem", "alice@example.com"),
+        ]
+        results = self.resolver.resolve_pairs(pairs)
+        self.assertEqual(results, [self.resolver.resolve(path, user) for path, user in pairs])
+        self.assertEqual(
+            results,
+            [
+                AccessLevel.ADMIN,
+                AccessLevel.WRITE,
+                AccessLevel.READ,
+                AccessLevel.WRITE,
+                AccessLevel.WRITE,
+                AccessLevel.READ,
+                AccessLevel.ADMIN,
+                AccessLevel.NONE,
+            ],
+        )
+
+    def test_duplicates_kept(self):
+        """Repeated pairs each get their own result, and an empty batch gives no results."""
+        pairs = [("data/a.csv", "alice@example.com")] * 3
+        self.assertEqual(self.resolver.resolve_pairs(pairs), [AccessLevel.WRITE] * 3)
+        self.assertEqual(self.resolver.resolve_pairs([]), [])
+
+    def test_permission_files_loaded_once(self):
+        """Each permission file on the way to the root is loaded once per batch."""
+        pairs = [(f"data/file{i}.csv", USERS[i % 3]) for i in range(50)]
+        pairs += [(f"data/private/file{i}.txt", USERS[i % 3]) for i in range(50)]
+
+        real_load = resolver_module.load_permission_file
+        with patch.object(
+            resolver_module, "load_permission_file", side_effect=real_load
+        ) as load_mock:
+            self.resolver.resolve_pairs(pairs)
+        self.assertEqual(load_mock.call_count, 3)
+
+    def test_cancel(self):
+        """The batch can be cancelled."""
+        cancel = Cancellation()
+        cancel.cancel()
+        with self.assertRaises(ResolutionCancelled):
+            self.resolver.resolve_pairs([("data/a.csv", "alice@example.com")], cancel=cancel)
+
+    def test_access_log_matches_loop(self):
+        """Replaying 5k access-log pairs at once gives what looping over resolve() does."""
+        rng = random.Random(0)
+        directories = ["", "data/", "data/private/", "docs/", "data/deep/", "users/user3/"]
+        # Like an access log: a working set of hot paths, each read by several users
+        paths = [
+            f"{rng.choice(directories)}file{i}.{rng.choice(['csv', 'json', 'txt'])}"
+            for i in range(500)
+        ]
+        users = [f"user{i}" for i in range(50)] + USERS
+        pairs = [(rng.choice(paths), rng.choice(users)) for _ in range(5000)]
+        loop_results = [self.resolver.resolve(path, user) for path, user in pairs]
+        self.assertEqual(self.resolver.resolve_pairs(pairs), loop_results)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,82 @@
+"""Tests for the resolver's symlink-aware resolve_real_path option."""
+
+import os
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import AccessLevel, PathEscapesRootError, Resolver  # noqa: E402
+
+
+@unittest.skipUnless(hasattr(os, "symlink"), "symlinks not supported")
+class TestResolveRealPath(unittest.TestCase):
+    """Test that symlinks are resolved only when asked and can't escape the root."""
+
+    def setUp(self):
+        """Create a datasite and a directory outside it."""
+        self.base_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        self.root = self.base_dir / "datasite"
+        self.outside = self.base_dir / "outside"
+        (self.root / "data").mkdir(parents=True)
+        (self.root / "private").mkdir()
+        self.outside.mkdir()
+        (self.outside / "passwd").write_text("x")
+        (self.root / "private" / "secret.txt").write_text("x")
+
+        (self.root / "syft.pub.yaml").write_text(
+            """rules:
+- pattern: "data/**"
+  access:
+    read: ["*"]
+- pattern: "private/**"
+  access:
+    read: [alice@example.com]
+"""
+        )
+        os.symlink(self.outside, self.root / "data" / "escape")
+        os.symlink(self.root / "private" / "secret.txt", self.root / "data" / "inside.txt")
+
+    def tearDown(self):
+        """Clean up test directories."""
+        shutil.rmtree(self.base_dir, ignore_errors=True)
+
+    def test_lexical_by_default(self):
+        """Without the option symlinks are matched where they appear."""
+        resolver = Resolver(self.root)
+        self.assertEqual(
+            resolver.resolve("data/escape/passwd", "bob@example.com"), AccessLevel.READ
+        )
+        self.assertEqual(resolver.resolve("data/inside.txt", "bob@example.com"), AccessLevel.READ)
+
+    def test_link_outside_root_rejected(self):
+        """A path leaving the root through a symlink raises instead of matching data/**."""
+        resolver = Resolver(self.root, resolve_real_path=True)
+        with self.assertRaises(PathEscapesRootError):
+            resolver.resolve("data/escape/passwd", "bob@example.com")
+        with self.assertRaises(ValueError):
+            resolver.can_read(self.root / "data" / "escape" / "passwd", "bob@example.com")
+
+    def test_link_inside_root_uses_target_rules(self):
+        """A symlink staying inside the root is matched at its target."""
+        resolver = Resolver(self.root, resolve_real_path=True)
+        self.assertEqual(resolver.resolve("data/inside.txt", "bob@example.com"), AccessLevel.NONE)
+        self.assertEqual(resolver.resolve("data/inside.txt", "alice@example.com"), AccessLevel.READ)
+        _, trace = resolver.resolve_with_trace("data/inside.txt", "alice@example.com")
+        self.assertEqual([m.pattern for m in trace if m.applied], ["private/**"])
+
+    def test_plain_paths_unchanged(self):
+        """Paths without symlinks, including missing ones, resolve as before."""
+        resolver = Resolver(self.root, resolve_real_path=True)
+        self.assertEqual(resolver.resolve("data/new/file.csv", "bob@example.com"), AccessLevel.READ)
+        self.assertEqual(
+            resolver.resolve_batch(["data/a.csv"], "bob@example.com"),
+            {"data/a.csv": AccessLevel.READ},
+        )
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,390 @@
+"""Tests for Resolver.resolve_with_trace decision traces."""
+
+import json
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+import syft_perm  # noqa: E402
+from helpers import write_file  # noqa: E402
+from syft_perm._impl import clear_permission_cache  # noqa: E402
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    MatchKind,
+    Resolver,
+    RuleMatch,
+    Trace,
+    TraceReason,
+)
+
+GOLDEN_RULES = """rules:
+- pattern: "**"
+  access:
+    read: ["*"]
+- pattern: "a/*.txt"
+  access:
+    write: [alice@example.com]
+"""
+
+
+class TestResolveWithTrace(unittest.TestCase):
+    """Test the resolver's access levels and explanation traces."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        self.resolver = Resolver(self.test_dir)
+        clear_permission_cache()
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+        clear_permission_cache()
+
+    def test_most_specific_rule_applies(self):
+        """The most specific matching rule decides and others are marked shadowed."""
+        write_file(self.test_dir, "src/main.py", "print()")
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  access:
+    read: [alice@example.com]
+- pattern: "src/*.py"
+  access:
+    write: [alice@example.com]
+- pattern: "docs/**"
+  access:
+    admin: [alice@example.com]
+""",
+        )
+
+        level, trace = self.resolver.resolve_with_trace("src/main.py", "alice@example.com")
+        self.assertEqual(level, AccessLevel.WRITE)
+
+        self.assertEqual([m.pattern for m in trace], ["src/*.py", "docs/**", "**"])
+        applied = [m for m in trace if m.applied]
+        self.assertEqual(len(applied), 1)
+        self.assertEqual(applied[0].pattern, "src/*.py")
+        self.assertEqual(applied[0].rule_index, 1)
+        self.assertEqual(applied[0].reason, TraceReason.APPLIED)
+
+        by_pattern = {m.pattern: m for m in trace}
+        self.assertEqual(by_pattern["docs/**"].reason, TraceReason.PATTERN_MISMATCH)
+        self.assertEqual(by_pattern["**"].reason, TraceReason.SHADOWED_BY_SPECIFIC)
+        self.assertTrue(by_pattern["**"].matched)
+
+    def test_user_not_in_allow_list(self):
+        """A matching rule that doesn't list the user is reported as such."""
+        write_file(self.test_dir, "data.csv", "a,b")
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "*.csv"
+  access:
+    read: [alice@example.com]
+""",
+        )
+
+        level, trace = self.resolver.resolve_with_trace("data.csv", "bob@example.com")
+        self.assertEqual(level, AccessLevel.NONE)
+        self.assertEqual(trace[0].reason, TraceReason.USER_NOT_LISTED)
+        self.assertTrue(trace[0].applied)
+
+    def test_nearer_file_shadows_parent(self):
+        """The nearest file with a match wins and parent rules are marked as shadowed."""
+        write_file(self.test_dir, "project/report.csv", "a,b")
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  access:
+    admin: [alice@example.com]
+""",
+        )
+        write_file(
+            self.test_dir,
+            "project/syft.pub.yaml",
+            """rules:
+- pattern: "*.csv"
+  access:
+    read: [alice@example.com]
+""",
+        )
+
+        level, trace = self.resolver.resolve_with_trace("project/report.csv", "alice@example.com")
+        self.assertEqual(level, AccessLevel.READ)
+        self.assertEqual(trace[0].directory, "project")
+        self.assertEqual(trace[0].reason, TraceReason.APPLIED)
+        self.assertEqual(trace[1].directory, "")
+        self.assertEqual(trace[1].reason, TraceReason.SHADOWED_BY_NEARER)
+
+    def test_terminal_overrides_nested_file(self):
+        """Rules below a terminal file are reported as overridden by the terminal."""
+        write_file(self.test_dir, "chat/inner/convo.txt", "hi")
+        write_file(
+            self.test_dir,
+            "chat/syft.pub.yaml",
+            """terminal: true
+rules:
+- pattern: "**"
+  access:
+    read: [alice@example.com]
+""",
+        )
+        write_file(
+            self.test_dir,
+            "chat/inner/syft.pub.yaml",
+            """rules:
+- pattern: "*.txt"
+  access:
+    admin: [alice@example.com]
+""",
+        )
+
+        level, trace = self.resolver.resolve_with_trace("chat/inner/convo.txt", "alice@example.com")
+        self.assertEqual(level, AccessLevel.READ)
+        by_dir = {m.directory: m for m in trace}
+        self.assertEqual(by_dir["chat/inner"].reason, TraceReason.OVERRIDDEN_BY_TERMINAL)
+        self.assertEqual(by_dir["chat"].reason, TraceReason.APPLIED)
+
+    def test_exclusion_reported(self):
+        """A decisive exclusion is reported with the exclusion reason."""
+        write_file(self.test_dir, "secret_key.py", "x")
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "**/*.py"
+  access:
+    read: ["*"]
+- pattern: "!**/secret_*.py"
+""",
+        )
+
+        level, trace = self.resolver.resolve_with_trace("secret_key.py", "alice@example.com")
+        self.assertEqual(level, AccessLevel.NONE)
+        self.assertEqual(trace[0].pattern, "!**/secret_*.py")
+        self.assertEqual(trace[0].reason, TraceReason.EXCLUDED)
+
+    def test_file_limits_skip_rule(self):
+        """A rule whose limits reject the file is skipped, not applied."""
+        write_file(self.test_dir, "big.txt", "x" * 2000)
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "*.txt"
+  access:
+    read: [alice@example.com]
+  limits:
+    max_file_size: 1024
+- pattern: "**"
+  access:
+    create: [alice@example.com]
+""",
+        )
+
+        level, trace = self.resolver.resolve_with_trace("big.txt", "alice@example.com")
+        self.assertEqual(level, AccessLevel.CREATE)
+        self.assertEqual(trace[0].reason, TraceReason.LIMIT_EXCEEDED)
+        self.assertEqual(trace[1].reason, TraceReason.APPLIED)
+
+    def test_trace_is_serializable_and_stable(self):
+        """Traces serialize to json and are identical across resolutions."""
+        write_file(self.test_dir, "a/b.txt", "x")
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "a/*.txt"
+  access:
+    read: [alice@example.com]
+- pattern: "**"
+  access:
+    write: [bob@example.com]
+""",
+        )
+
+        _, first = self.resolver.resolve_with_trace("a/b.txt", "alice@example.com")
+        _, second = self.resolver.resolve_with_trace("a/b.txt", "alice@example.com")
+        self.assertEqual(first, second)
+
+        encoded = json.dumps([m.to_dict() for m in first])
+        decoded = json.loads(encoded)
+        self.assertEqual(decoded[0]["reason"], "applied")
+        self.assertEqual(decoded[0]["level"], "read")
+        self.assertEqual(decoded[0]["match_kind"], "wildcard")
+
+    def test_match_kind(self):
+        """Grants from literal patterns are exact, any glob syntax makes a wildcard."""
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "reports/q1.csv"
+  access:
+    write: [alice@example.com]
+- pattern: '/reports/q\\*.csv'
+  access:
+    write: [alice@example.com]
+- pattern: "reports/*.csv"
+  access:
+    read: [alice@example.com]
+""",
+        )
+        _, trace = self.resolver.resolve_with_trace("reports/q1.csv", "alice@example.com")
+        granting = next(m for m in trace if m.applied)
+        self.assertEqual(granting.pattern, "reports/q1.csv")
+        self.assertEqual(granting.match_kind, MatchKind.EXACT)
+        _, trace = self.resolver.resolve_with_trace("reports/q*.csv", "alice@example.com")
+        granting = next(m for m in trace if m.applied)
+        self.assertEqual(granting.pattern, "/reports/q\\*.csv")
+        self.assertEqual(granting.match_kind, MatchKind.EXACT)
+        _, trace = self.resolver.resolve_with_trace("reports/q2.csv", "alice@example.com")
+        granting = next(m for m in trace if m.applied)
+        self.assertEqual(granting.match_kind, MatchKind.WILDCARD)
+
+        kinds = {
+            "**": MatchKind.WILDCARD,
+            "a/?.txt": MatchKind.WILDCARD,
+            "[ab].txt": MatchKind.WILDCARD,
+            "{a,b}.txt": MatchKind.WILDCARD,
+            "!data/**": MatchKind.WILDCARD,
+            "a.txt": MatchKind.EXACT,
+            "!a/b.txt": MatchKind.EXACT,
+            "/a/b": MatchKind.EXACT,
+            "docs/": MatchKind.EXACT,
+            "a\\[1\\].txt": MatchKind.EXACT,
+        }
+        for pattern, kind in kinds.items():
+            with self.subTest(pattern=pattern):
+                match = RuleMatch("", 0, pattern, True, True, TraceReason.APPLIED)
+                self.assertEqual(match.match_kind, kind)
+
+    def test_absolute_paths_inside_root(self):
+        """Absolute paths under the root resolve like relative ones."""
+        write_file(self.test_dir, "x.txt", "x")
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            'rules:\n- pattern: "*.txt"\n  access:\n    read: ["*"]\n',
+        )
+
+        self.assertEqual(
+            self.resolver.resolve(self.test_dir / "x.txt", "bob@example.com"), AccessLevel.READ
+        )
+        with self.assertRaises(ValueError):
+            self.resolver.resolve(Path("/somewhere/else.txt"), "bob@example.com")
+
+    def test_matches_file_api(self):
+        """Resolver levels agree with SyftFile permission checks."""
+        write_file(self.test_dir, "docs/guide.md", "x")
+        write_file(self.test_dir, "docs/api/ref.md", "x")
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "docs/**"
+  access:
+    read: ["*"]
+    write: [bob@example.com]
+""",
+        )
+        write_file(
+            self.test_dir,
+            "docs/api/syft.pub.yaml",
+            """rules:
+- pattern: "*.md"
+  access:
+    admin: [carol@example.com]
+""",
+        )
+
+        checks = {
+            AccessLevel.READ: "has_read_access",
+            AccessLevel.CREATE: "has_create_access",
+            AccessLevel.WRITE: "has_write_access",
+            AccessLevel.ADMIN: "has_admin_access",
+        }
+        for rel_path in ["docs/guide.md", "docs/api/ref.md"]:
+            syft_file = syft_perm.open(self.test_dir / rel_path)
+            for user in ["alice@example.com", "bob@example.com", "carol@example.com"]:
+                level = self.resolver.resolve(rel_path, user)
+                for required, method in checks.items():
+                    self.assertEqual(
+                        level >= required,
+                        getattr(syft_file, method)(user),
+                        f"{rel_path} {user} {required}",
+                    )
+
+
+
+class TestTraceOutput(unittest.TestCase):
+    """Test the canonical json and text forms of a trace."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        (self.test_dir / "syft.pub.yaml").write_text(GOLDEN_RULES)
+        (self.test_dir / "a").mkdir()
+        (self.test_dir / "a" / "syft.pub.yaml").write_text(
+            'rules:\n- pattern: "*.md"\n  access:\n    admin: [bob@example.com]\n'
+        )
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def _trace(self):
+        return Resolver(self.test_dir).resolve_with_trace("a/b.txt", "alice@example.com")[1]
+
+    def test_runs_are_byte_identical(self):
+        """Separate resolvers over the same files produce the same json."""
+        first, second = self._trace(), self._trace()
+        self.assertIsInstance(first, Trace)
+        self.assertEqual(first.to_json(), second.to_json())
+        self.assertEqual(first.to_json(indent=2), second.to_json(indent=2))
+
+    def test_golden_json(self):
+        """Entries keep trace order, keys are sorted and nothing depends on the clock."""
+        self.assertEqual(
+            self._trace().to_json(),
+            '[{"applied":false,"directory":"a","level":"none","match_kind":"wildcard",'
+            '"matched":false,"pattern":"*.md","reason":"pattern did not match","rule_index":0},'
+            '{"applied":true,"directory":"","level":"write","match_kind":"wildcard",'
+            '"matched":true,"pattern":"a/*.txt","reason":"applied","rule_index":1},'
+            '{"applied":false,"directory":"","level":"none","match_kind":"wildcard",'
+            '"matched":true,"pattern":"**","reason":"a more specific rule matched first",'
+            '"rule_index":0}]',
+        )
+        indented = self._trace().to_json(indent=2)
+        self.assertEqual(json.loads(indented), json.loads(self._trace().to_json()))
+
+    def test_str(self):
+        """The text form has one line per entry, applied ones starred."""
+        self.assertEqual(
+            str(self._trace()).split("\n"),
+            [
+                "  a/syft.pub.yaml rule 0 ('*.md'): pattern did not match",
+                "* syft.pub.yaml rule 1 ('a/*.txt'): applied -> write",
+                "  syft.pub.yaml rule 0 ('**'): a more specific rule matched first",
+            ],
+        )
+
+    def test_still_a_list(self):
+        """A trace compares, indexes and iterates like the list it used to be."""
+        trace = self._trace()
+        self.assertEqual(trace, list(trace))
+        self.assertEqual(trace[1].pattern, "a/*.txt")
+
+
+if __name__ == "__main__":
+    unittest.main()
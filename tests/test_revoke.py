@@ -0,0 +1,304 @@
+"""Tests for revoke sections lowering inherited access."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    PermissionFileBuilder,
+    Resolver,
+    TraceReason,
+    export_acl_cache,
+    parse_permission_file,
+)
+
+
+class TestRevoke(unittest.TestCase):
+    """Test that revokes cap the level computed from inherited grants."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  access:
+    admin: [bob@example.com, carol@example.com]
+    read: ["*"]
+""",
+        )
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_inherited_admin_revoked_to_read(self):
+        """Revoking create from an inherited admin leaves read."""
+        write_file(
+            self.test_dir,
+            "reports/syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  revoke:
+    create: [bob@example.com]
+""",
+        )
+        resolver = Resolver(self.test_dir)
+        self.assertEqual(resolver.resolve("reports/q1.csv", "bob@example.com"), AccessLevel.READ)
+        self.assertEqual(resolver.resolve("reports/q1.csv", "carol@example.com"), AccessLevel.ADMIN)
+        self.assertEqual(resolver.resolve("notes.txt", "bob@example.com"), AccessLevel.ADMIN)
+
+    def test_revoke_in_granting_rule(self):
+        """A rule can grant and revoke at once; the revoke wins for the users it names."""
+        write_file(
+            self.test_dir,
+            "shared/syft.pub.yaml",
+            """rules:
+- pattern: "*.csv"
+  access:
+    write: ["*@example.com"]
+  revoke:
+    write: [bob@example.com]
+""",
+        )
+        resolver = Resolver(self.test_dir)
+        self.assertEqual(resolver.resolve("shared/a.csv", "bob@example.com"), AccessLevel.CREATE)
+        self.assertEqual(resolver.resolve("shared/a.csv", "dan@example.com"), AccessLevel.WRITE)
+
+    def test_revoke_only_rule_does_not_decide(self):
+        """A rule with only a revoke leaves the decision to the grants around it."""
+        write_file(
+            self.test_dir,
+            "shared/syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  revoke:
+    admin: [bob@example.com]
+""",
+        )
+        resolver = Resolver(self.test_dir)
+        self.assertEqual(resolver.resolve("shared/a.txt", "bob@example.com"), AccessLevel.WRITE)
+        self.assertEqual(resolver.resolve("shared/a.txt", "dan@example.com"), AccessLevel.READ)
+
+    def test_revoke_everything(self):
+        """Revoking read leaves no access at all."""
+        write_file(
+            self.test_dir,
+            "shared/syft.pub.yaml",
+            """rules:
+- pattern: "secret.txt"
+  revoke:
+    read: ["*"]
+""",
+        )
+        resolver = Resolver(self.test_dir)
+        self.assertEqual(resolver.resolve("shared/secret.txt", "bob@example.com"), AccessLevel.NONE)
+        self.assertEqual(resolver.resolve("shared/other.txt", "bob@example.com"), AccessLevel.ADMIN)
+
+    def test_revoke_in_terminal_file(self):
+        """Revokes in a terminal file apply to what it grants."""
+        write_file(
+            self.test_dir,
+            "team/syft.pub.yaml",
+            """terminal: true
+rules:
+- pattern: "**"
+  access:
+    admin: [bob@example.com]
+  revoke:
+    write: [bob@example.com]
+""",
+        )
+        resolver = Resolver(self.test_dir)
+        self.assertEqual(resolver.resolve("team/plan.md", "bob@example.com"), AccessLevel.CREATE)
+
+    def test_revoke_below_terminal_ignored(self):
+        """Revokes in files a terminal overrides don't apply."""
+        write_file(
+            self.test_dir,
+            "team/syft.pub.yaml",
+            """terminal: true
+rules:
+- pattern: "**"
+  access:
+    admin: [bob@example.com]
+""",
+        )
+        write_file(
+            self.test_dir,
+            "team/sub/syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  revoke:
+    read: [bob@example.com]
+""",
+        )
+        resolver = Resolver(self.test_dir)
+        self.assertEqual(resolver.resolve("team/sub/plan.md", "bob@example.com"), AccessLevel.ADMIN)
+
+    def test_revoke_above_terminal_ignored(self):
+        """A terminal file also stops revokes in its parents from reaching below it."""
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  revoke:
+    read: [bob@example.com]
+""",
+        )
+        write_file(
+            self.test_dir,
+            "team/syft.pub.yaml",
+            """terminal: true
+rules:
+- pattern: "**"
+  access:
+    write: [bob@example.com]
+""",
+        )
+        resolver = Resolver(self.test_dir)
+        self.assertEqual(resolver.resolve("team/plan.md", "bob@example.com"), AccessLevel.WRITE)
+        self.assertEqual(resolver.resolve("plan.md", "bob@example.com"), AccessLevel.NONE)
+
+    def test_trace(self):
+        """The trace records the revoke and the level it leaves."""
+        write_file(
+            self.test_dir,
+            "reports/syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  revoke:
+    create: [bob@example.com]
+""",
+        )
+        level, trace = Resolver(self.test_dir).resolve_with_trace(
+            "reports/q1.csv", "bob@example.com"
+        )
+        self.assertEqual(level, AccessLevel.READ)
+        revoked = [match for match in trace if match.reason is TraceReason.REVOKED]
+        self.assertEqual(len(revoked), 1)
+        self.assertEqual(revoked[0].directory, "reports")
+        self.assertEqual(revoked[0].level, AccessLevel.READ)
+
+    def test_users_with_access(self):
+        """Revoked users are left out of the users holding a level."""
+        write_file(
+            self.test_dir,
+            "reports/syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  revoke:
+    create: [bob@example.com]
+""",
+        )
+        resolver = Resolver(self.test_dir)
+        self.assertEqual(
+            resolver.users_with_access("reports/q1.csv", AccessLevel.ADMIN),
+            (["carol@example.com"], False),
+        )
+        self.assertEqual(
+            resolver.users_with_access("reports/q1.csv", AccessLevel.READ),
+            (["bob@example.com", "carol@example.com"], True),
+        )
+
+    def test_users_with_access_domain_revoke(self):
+        """A ``*@domain`` revoke leaves out every listed user at that domain."""
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  access:
+    read: [alice@org.com, "*@org.com", bob@x.com]
+  revoke:
+    read: ["*@org.com"]
+""",
+        )
+        resolver = Resolver(self.test_dir)
+        self.assertEqual(resolver.resolve("a.txt", "alice@org.com"), AccessLevel.NONE)
+        self.assertEqual(resolver.users_with_access("a.txt"), (["bob@x.com"], False))
+
+    def test_acl_cache(self):
+        """Users named only in a revoke get their own ACL cache entry."""
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  access:
+    write: ["*"]
+""",
+        )
+        write_file(
+            self.test_dir,
+            "reports/syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  revoke:
+    write: [bob@example.com]
+""",
+        )
+        write_file(self.test_dir, "reports/q1.csv", "1,2")
+        cache = export_acl_cache(self.test_dir)
+        self.assertEqual(cache["reports/q1.csv"]["bob@example.com"], AccessLevel.CREATE)
+        self.assertEqual(cache["reports/q1.csv"]["*"], AccessLevel.WRITE)
+
+
+class TestRevokeParsing(unittest.TestCase):
+    """Test reading, writing and building revoke sections."""
+
+    def test_parse_and_round_trip(self):
+        """A revoke section parses to levels and is written back by to_dict."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "**"
+  revoke:
+    write: [Bob@Example.com]
+"""
+        )
+        rule = perm_file.rules[0]
+        self.assertEqual(rule.revoke, {AccessLevel.WRITE: ["bob@example.com"]})
+        self.assertTrue(rule.is_revoke_only)
+        self.assertEqual(rule.to_dict()["revoke"], {"write": ["bob@example.com"]})
+
+    def test_no_revoke_not_written(self):
+        """Rules without a revoke keep their old serialized form."""
+        perm_file = parse_permission_file('rules:\n- pattern: "*"\n  access:\n    read: ["*"]\n')
+        self.assertNotIn("revoke", perm_file.rules[0].to_dict())
+
+    def test_revoke_none_rejected(self):
+        """Revoking 'none' is an error."""
+        with self.assertRaisesRegex(ValueError, "cannot revoke 'none'"):
+            parse_permission_file(
+                'rules:\n- pattern: "*"\n  revoke:\n    none: [bob@example.com]\n'
+            )
+
+    def test_revoke_not_mapping_rejected(self):
+        """A revoke that isn't a mapping is an error."""
+        with self.assertRaisesRegex(ValueError, "revoke must be a mapping"):
+            parse_permission_file('rules:\n- pattern: "*"\n  revoke: [bob@example.com]\n')
+
+    def test_builder(self):
+        """The builder adds revokes to the current rule."""
+        perm_file = (
+            PermissionFileBuilder()
+            .add_rule("**")
+            .grant("admin", "bob@example.com")
+            .revoke("write", "bob@example.com")
+            .build()
+        )
+        self.assertEqual(perm_file.rules[0].revoke, {AccessLevel.WRITE: ["bob@example.com"]})
+        self.assertEqual(perm_file.rules[0].revoked_for("bob@example.com"), AccessLevel.WRITE)
+
+
+if __name__ == "__main__":
+    unittest.main()
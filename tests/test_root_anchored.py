@@ -0,0 +1,148 @@
+"""Tests for patterns anchored to the datasite root with a leading slash."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import AccessLevel, Resolver, parse_permission_file  # noqa: E402
+
+NESTED = "projects/alpha/reports/2024"
+
+
+class TestRootAnchoredPatterns(unittest.TestCase):
+    """Test that anchored patterns see the whole path and relative ones don't."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        self.resolver = Resolver(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def _grant(self, directory, pattern, user="bob@example.com", level="read"):
+        write_file(
+            self.test_dir,
+            f"{directory}/syft.pub.yaml",
+            f"""rules:
+- pattern: "{pattern}"
+  access:
+    {level}: [{user}]
+""",
+        )
+
+    def test_anchored_rule_in_nested_file(self):
+        """A deeply nested file can name its paths by their full datasite path."""
+        self._grant(NESTED, "/projects/*/reports/**/*.csv")
+        self.assertEqual(
+            self.resolver.resolve(f"{NESTED}/q1.csv", "bob@example.com"), AccessLevel.READ
+        )
+        self.assertEqual(
+            self.resolver.resolve(f"{NESTED}/deep/q2.csv", "bob@example.com"), AccessLevel.READ
+        )
+        self.assertEqual(
+            self.resolver.resolve(f"{NESTED}/q1.txt", "bob@example.com"), AccessLevel.NONE
+        )
+
+    def test_relative_rule_not_matched_from_root(self):
+        """Without the slash the same pattern is relative to the file's directory."""
+        self._grant(NESTED, "projects/*/reports/**/*.csv")
+        self.assertEqual(
+            self.resolver.resolve(f"{NESTED}/q1.csv", "bob@example.com"), AccessLevel.NONE
+        )
+
+    def test_sibling_tree_not_reached(self):
+        """An anchored pattern naming a sibling tree grants nothing there."""
+        self._grant(NESTED, "/projects/beta/**")
+        write_file(self.test_dir, "projects/beta/plan.md", "secret")
+        self.assertEqual(
+            self.resolver.resolve("projects/beta/plan.md", "bob@example.com"), AccessLevel.NONE
+        )
+
+    def test_sibling_trees_from_shared_ancestor(self):
+        """A file above both trees can cover matching paths in each of them."""
+        self._grant("projects", "/projects/*/shared/**")
+        for path in ("projects/alpha/shared/a.txt", "projects/beta/shared/x/b.txt"):
+            with self.subTest(path=path):
+                self.assertEqual(self.resolver.resolve(path, "bob@example.com"), AccessLevel.READ)
+        self.assertEqual(
+            self.resolver.resolve("projects/alpha/private/a.txt", "bob@example.com"),
+            AccessLevel.NONE,
+        )
+
+    def test_anchored_exclusion_and_terminal(self):
+        """Exclusions and terminal rules can be anchored too."""
+        write_file(
+            self.test_dir,
+            "projects/syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  access:
+    read: [bob@example.com]
+- pattern: "!/projects/alpha/secret/**"
+""",
+        )
+        write_file(
+            self.test_dir,
+            "projects/beta/syft.pub.yaml",
+            """rules:
+- pattern: "/projects/beta/locked/**"
+  terminal: true
+  access:
+    admin: [carol@example.com]
+""",
+        )
+        resolve = self.resolver.resolve
+        self.assertEqual(
+            resolve("projects/alpha/secret/k.txt", "bob@example.com"), AccessLevel.NONE
+        )
+        self.assertEqual(resolve("projects/alpha/open.txt", "bob@example.com"), AccessLevel.READ)
+        self.assertEqual(resolve("projects/beta/locked/a.txt", "bob@example.com"), AccessLevel.NONE)
+        self.assertEqual(
+            resolve("projects/beta/locked/a.txt", "carol@example.com"), AccessLevel.ADMIN
+        )
+
+    def test_depth_counted_from_root(self):
+        """An anchored rule's depth range counts segments of the whole path."""
+        write_file(
+            self.test_dir,
+            "projects/syft.pub.yaml",
+            """rules:
+- pattern: "/projects/**"
+  max_depth: 3
+  access:
+    read: [bob@example.com]
+""",
+        )
+        self.assertEqual(
+            self.resolver.resolve("projects/alpha/a.txt", "bob@example.com"), AccessLevel.READ
+        )
+        self.assertEqual(
+            self.resolver.resolve("projects/alpha/x/a.txt", "bob@example.com"), AccessLevel.NONE
+        )
+
+    def test_walk_prunes_with_anchored_rules(self):
+        """Pruning a walk follows anchored patterns to the directories they reach."""
+        self._grant("projects", "/projects/alpha/**")
+        write_file(self.test_dir, "projects/alpha/a.txt", "a")
+        write_file(self.test_dir, "projects/beta/b.txt", "b")
+        listed = dict(self.resolver.walk("bob@example.com", prune_no_access=True))
+        self.assertEqual(listed.get("projects/alpha/a.txt"), AccessLevel.READ)
+        self.assertNotIn("projects/beta/b.txt", listed)
+
+    def test_rule_property(self):
+        """Rules report whether their pattern is anchored, with or without ``!``."""
+        perm_file = parse_permission_file(
+            'rules:\n- pattern: "/a/**"\n- pattern: "!/a/b"\n- pattern: "a/**"\n'
+        )
+        self.assertEqual([r.is_root_anchored for r in perm_file.rules], [True, True, False])
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,132 @@
+"""Tests for keeping per-rule comments when a permission file is rewritten."""
+
+import sys
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    PermissionFile,
+    PermissionFileBuilder,
+    Rule,
+    parse_permission_file,
+)
+
+COMMENTED = """terminal: true
+rules:
+# Published results are public.
+# Ask the data team before narrowing this.
+- pattern: '**/*.csv'  # see the sharing policy
+  access:
+    read:
+    - '*'
+#
+# Bob maintains the docs.
+- pattern: docs/**
+  access:
+    write:
+    - bob@example.com
+- pattern: private/**  # owner only
+  terminal: true
+"""
+
+
+class TestRuleComments(unittest.TestCase):
+    """Test that head and line comments survive a load and to_yaml round trip."""
+
+    def test_round_trip_unchanged(self):
+        """A canonical file with comments is written back byte for byte."""
+        self.assertEqual(parse_permission_file(COMMENTED).to_yaml(), COMMENTED)
+
+    def test_comments_captured(self):
+        """Comments are read into the rule they belong to, without their markers."""
+        rules = parse_permission_file(COMMENTED).rules
+        self.assertEqual(
+            rules[0].head_comment,
+            "Published results are public.\nAsk the data team before narrowing this.",
+        )
+        self.assertEqual(rules[0].line_comment, "see the sharing policy")
+        self.assertEqual(rules[1].head_comment, "\nBob maintains the docs.")
+        self.assertIsNone(rules[1].line_comment)
+        self.assertIsNone(rules[2].head_comment)
+        self.assertEqual(rules[2].line_comment, "owner only")
+
+    def test_reformatted_file_keeps_comments(self):
+        """Comments come along when a hand-written file is rewritten canonically."""
+        perm_file = parse_permission_file(
+            """rules:
+  # Anyone can read
+  - pattern: "*.txt"   # text only
+    access: {read: ["*"]}
+  - pattern: "a # b"  # hash in the pattern
+    access:
+      # not kept: inside the rule body
+      write: [bob@example.com]
+"""
+        )
+        self.assertEqual(
+            perm_file.to_yaml(),
+            """rules:
+# Anyone can read
+- pattern: '*.txt'  # text only
+  access:
+    read:
+    - '*'
+- pattern: 'a # b'  # hash in the pattern
+  access:
+    write:
+    - bob@example.com
+""",
+        )
+
+    def test_body_comment_not_given_to_next_rule(self):
+        """An indented comment at the end of a rule stays out of the next rule's head."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: a
+  access:
+    read: ["*"]
+  # about a's access
+- pattern: b
+"""
+        )
+        self.assertIsNone(perm_file.rules[1].head_comment)
+
+    def test_programmatic_rules_emit_none(self):
+        """Rules built in code have no comments and write none."""
+        perm_file = PermissionFileBuilder().add_rule("**").grant("read", "*").build()
+        self.assertNotIn("#", perm_file.to_yaml())
+        rule = perm_file.rules[0]
+        self.assertIsNone(rule.head_comment)
+        self.assertIsNone(rule.line_comment)
+
+    def test_comments_set_in_code(self):
+        """Comments set on a rule in code are written like loaded ones."""
+        rule = Rule(
+            "*.txt",
+            {AccessLevel.READ: ["*"]},
+            head_comment="Shared notes",
+            line_comment="temporary",
+        )
+        text = PermissionFile(rules=[rule]).to_yaml()
+        self.assertEqual(
+            text.splitlines()[:3],
+            ["rules:", "# Shared notes", "- pattern: '*.txt'  # temporary"],
+        )
+        self.assertEqual(parse_permission_file(text).rules[0].line_comment, "temporary")
+
+    def test_not_part_of_equality(self):
+        """Comments don't change what a rule means, so they don't affect comparison."""
+        commented = parse_permission_file(COMMENTED)
+        lines = [line.split("  #")[0] for line in COMMENTED.splitlines()]
+        plain = parse_permission_file("\n".join(line for line in lines if not line.startswith("#")))
+        self.assertIsNone(plain.rules[0].head_comment)
+        self.assertEqual(commented, plain)
+        self.assertEqual(commented.fingerprint(), plain.fingerprint())
+        self.assertNotIn("comment", str(commented.to_dict()))
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,122 @@
+"""Tests for PermissionFile.validate conflict warnings."""
+
+import sys
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import AccessLevel, parse_permission_file  # noqa: E402
+
+
+class TestRuleConflicts(unittest.TestCase):
+    """Test detection of overlapping rules that disagree about a user."""
+
+    def test_subset_pattern_conflict(self):
+        """A narrower pattern giving a user a different level is reported."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "**/*.csv"
+  access:
+    write: [alice@example.com]
+- pattern: "data/*.csv"
+  access:
+    read: [alice@example.com]
+"""
+        )
+
+        conflicts = perm_file.validate()
+        self.assertEqual(len(conflicts), 1)
+        conflict = conflicts[0]
+        self.assertEqual((conflict.first_index, conflict.second_index), (0, 1))
+        self.assertEqual(conflict.user, "alice@example.com")
+        self.assertEqual(conflict.first_level, AccessLevel.WRITE)
+        self.assertEqual(conflict.second_level, AccessLevel.READ)
+        self.assertEqual(conflict.overlap, "superset")
+        # The more specific rule is tried first, so it wins where both match
+        self.assertEqual(conflict.applies, 1)
+        self.assertIn("rules 0 and 1", str(conflict))
+
+    def test_identical_patterns(self):
+        """The same pattern listed twice with different levels is reported."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "*.txt"
+  access:
+    read: [bob@example.com]
+- pattern: "*.txt"
+  access:
+    admin: [bob@example.com]
+"""
+        )
+
+        conflicts = perm_file.validate()
+        self.assertEqual([c.overlap for c in conflicts], ["identical"])
+        self.assertEqual(conflicts[0].applies, 0)
+
+    def test_wildcard_entry_conflict(self):
+        """A public grant and an explicit grant on overlapping patterns are compared."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "docs/{a,b}.md"
+  access:
+    write: [carol@example.com]
+- pattern: "docs/*"
+  access:
+    read: ["*"]
+"""
+        )
+
+        conflicts = perm_file.validate()
+        self.assertEqual(
+            [(c.user, c.overlap) for c in conflicts], [("carol@example.com", "subset")]
+        )
+
+    def test_wildcards_compared_not_taken_literally(self):
+        """``?`` names fewer files than ``*``, though ``?`` would match the name ``*``."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "data/*.csv"
+  access:
+    write: [alice@example.com]
+- pattern: "data/?.csv"
+  access:
+    read: [alice@example.com]
+- pattern: "*"
+  access:
+    admin: [bob@example.com]
+- pattern: "**"
+  access:
+    read: [bob@example.com]
+"""
+        )
+
+        conflicts = perm_file.validate()
+        self.assertEqual(
+            [(c.first_index, c.second_index, c.overlap, c.applies) for c in conflicts],
+            [(0, 1, "superset", 1), (2, 3, "subset", 2)],
+        )
+
+    def test_no_false_alarms(self):
+        """Disjoint patterns, equal levels, other users and exclusions are not reported."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "*.csv"
+  access:
+    write: [alice@example.com]
+- pattern: "*.txt"
+  access:
+    read: [alice@example.com]
+- pattern: "data/*.csv"
+  access:
+    write: [alice@example.com]
+    read: [bob@example.com]
+- pattern: "!data/secret.csv"
+"""
+        )
+
+        self.assertEqual(perm_file.validate(), [])
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,98 @@
+"""Tests for per-rule size and extension constraints in the resolver."""
+
+import os
+import shutil
+import stat
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import AccessLevel, Resolver, parse_permission_file  # noqa: E402
+
+RULES = """rules:
+- pattern: "uploads/**"
+  access:
+    write: [alice@example.com]
+  limits:
+    max_file_size: 1000
+    allowed_extensions: [csv, ".TXT"]
+- pattern: "**"
+  access:
+    read: [alice@example.com]
+"""
+
+
+def _fake_stat(sizes):
+    """Build a stat function reporting regular files with the given sizes."""
+
+    def stat_func(path):
+        name = Path(path).name
+        if name not in sizes:
+            raise FileNotFoundError(path)
+        return os.stat_result((stat.S_IFREG | 0o644, 0, 0, 1, 0, 0, sizes[name], 0, 0, 0))
+
+    return stat_func
+
+
+class TestRuleConstraints(unittest.TestCase):
+    """Test that rules are skipped for files outside their size or extension limits."""
+
+    def setUp(self):
+        """Create a temporary datasite with only a permission file on disk."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        (self.test_dir / "syft.pub.yaml").write_text(RULES)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_rule_fields(self):
+        """Limits are exposed as typed fields with normalized extensions."""
+        rule = parse_permission_file(RULES).rules[0]
+        self.assertEqual(rule.max_file_size, 1000)
+        self.assertEqual(rule.allowed_extensions, [".csv", ".txt"])
+        self.assertIsNone(parse_permission_file(RULES).rules[1].allowed_extensions)
+
+    def test_size_limit_with_fake_stat(self):
+        """Files over max_file_size skip the rule and fall through to the next one."""
+        resolver = Resolver(self.test_dir, stat_func=_fake_stat({"small.csv": 10, "big.csv": 5000}))
+        user = "alice@example.com"
+        self.assertEqual(resolver.resolve("uploads/small.csv", user), AccessLevel.WRITE)
+        self.assertEqual(resolver.resolve("uploads/big.csv", user), AccessLevel.READ)
+        # Files that don't exist yet aren't blocked by size
+        self.assertEqual(resolver.resolve("uploads/new.csv", user), AccessLevel.WRITE)
+
+    def test_extension_limit(self):
+        """Only listed extensions match, compared case-insensitively."""
+        resolver = Resolver(self.test_dir, stat_func=_fake_stat({}))
+        user = "alice@example.com"
+        self.assertEqual(resolver.resolve("uploads/notes.TXT", user), AccessLevel.WRITE)
+        self.assertEqual(resolver.resolve("uploads/run.exe", user), AccessLevel.READ)
+        self.assertEqual(resolver.resolve("uploads/README", user), AccessLevel.READ)
+
+    def test_without_stat_size_is_ignored(self):
+        """With no stat function size limits are ignored but extensions still apply."""
+        resolver = Resolver(self.test_dir, stat_func=None)
+        user = "alice@example.com"
+        self.assertEqual(resolver.resolve("uploads/big.csv", user), AccessLevel.WRITE)
+        self.assertEqual(resolver.resolve("uploads/run.exe", user), AccessLevel.READ)
+
+    def test_default_stats_disk(self):
+        """By default sizes come from the filesystem."""
+        (self.test_dir / "uploads").mkdir()
+        (self.test_dir / "uploads" / "big.csv").write_text("x" * 2000)
+        resolver = Resolver(self.test_dir)
+        self.assertEqual(resolver.resolve("uploads/big.csv", "alice@example.com"), AccessLevel.READ)
+
+    def test_invalid_limits_rejected(self):
+        """Malformed limit values fail at load time."""
+        for limits in ["max_file_size: -1", "max_file_size: big", "allowed_extensions: csv"]:
+            with self.assertRaises(ValueError, msg=limits):
+                parse_permission_file(f'rules:\n- pattern: "*"\n  limits:\n    {limits}\n')
+
+
+if __name__ == "__main__":
+    unittest.main()
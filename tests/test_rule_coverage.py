@@ -0,0 +1,109 @@
+"""Tests for counting how many files each rule matches."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import Cancellation, ResolutionCancelled, Resolver  # noqa: E402
+
+ROOT_RULES = """rules:
+- pattern: "data/*.csv"
+  access:
+    write: [bob@example.com]
+- pattern: "**"
+  access:
+    read: ["*"]
+- pattern: "!**/*.key"
+- pattern: "archive/**"
+  access:
+    admin: [carol@example.com]
+"""
+
+NESTED_RULES = """rules:
+- pattern: "*.md"
+  access:
+    read: [alice@example.com]
+"""
+
+FILES = [
+    "readme.md",
+    "data/a.csv",
+    "data/b.csv",
+    "data/c.json",
+    "data/id.key",
+    "docs/guide.md",
+    "docs/todo.txt",
+    ".hidden/x.csv",
+]
+
+
+class TestRuleCoverage(unittest.TestCase):
+    """Test the per-rule match counts and their order."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        write_file(self.test_dir, "syft.pub.yaml", ROOT_RULES)
+        write_file(self.test_dir, "docs/syft.pub.yaml", NESTED_RULES)
+        for rel_path in FILES:
+            write_file(self.test_dir, rel_path, "x")
+        self.resolver = Resolver(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def _counts(self):
+        return [
+            (stat.rule.pattern, stat.total_matches, stat.first_matches)
+            for stat in self.resolver.rule_coverage()
+        ]
+
+    def test_broadest_first(self):
+        """Rules are listed by total matches, then by file and position on ties."""
+        self.assertEqual(
+            self._counts(),
+            [
+                ("**", 7, 3),
+                ("data/*.csv", 2, 2),
+                ("!**/*.key", 1, 1),
+                ("*.md", 1, 1),
+                ("archive/**", 0, 0),
+            ],
+        )
+
+    def test_source(self):
+        """Each count is attributed to the file and line its rule was read from."""
+        stats = {stat.rule.pattern: stat for stat in self.resolver.rule_coverage()}
+        self.assertEqual(stats["*.md"].source, self.test_dir / "docs" / "syft.pub.yaml")
+        self.assertEqual(stats["*.md"].directory, "docs")
+        self.assertEqual(stats["!**/*.key"].rule_index, 2)
+        self.assertEqual(stats["!**/*.key"].source, self.test_dir / "syft.pub.yaml")
+        self.assertEqual(stats["!**/*.key"].rule.position.line, 8)
+
+    def test_terminal(self):
+        """A terminal file decides its files, but ancestors' rules still count them."""
+        write_file(self.test_dir, "docs/syft.pub.yaml", "terminal: true\n" + NESTED_RULES)
+        counts = {pattern: (total, first) for pattern, total, first in self._counts()}
+        self.assertEqual(counts["**"], (7, 2))
+        self.assertEqual(counts["*.md"], (1, 1))
+
+    def test_empty(self):
+        """A datasite without permission files has nothing to report."""
+        self.assertEqual(Resolver(self.test_dir / "data").rule_coverage(), [])
+
+    def test_cancel(self):
+        """The walk can be cancelled."""
+        cancel = Cancellation()
+        cancel.cancel()
+        with self.assertRaises(ResolutionCancelled):
+            self.resolver.rule_coverage(cancel=cancel)
+
+
+if __name__ == "__main__":
+    unittest.main()
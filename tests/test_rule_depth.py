@@ -0,0 +1,86 @@
+"""Tests for limiting rules to a range of path depths."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    PermissionFile,
+    Resolver,
+    Rule,
+    TraceReason,
+    parse_permission_file,
+)
+
+
+class TestRuleDepth(unittest.TestCase):
+    """Test that min_depth and max_depth are enforced on top of the pattern."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_doublestar_with_max_depth(self):
+        """A ** rule limited to depth 2 stops two segments below its directory."""
+        write_file(
+            self.test_dir,
+            "data/syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  max_depth: 2
+  access:
+    read: ["*"]
+""",
+        )
+        resolver = Resolver(self.test_dir)
+        for path, expected in [
+            ("data/a.csv", AccessLevel.READ),
+            ("data/2024/a.csv", AccessLevel.READ),
+            ("data/2024/01/a.csv", AccessLevel.NONE),
+            ("a.csv", AccessLevel.NONE),
+        ]:
+            with self.subTest(path=path):
+                self.assertEqual(resolver.resolve(path, "bob@example.com"), expected)
+
+        _, trace = resolver.resolve_with_trace("data/2024/01/a.csv", "bob@example.com")
+        self.assertEqual(trace[0].reason, TraceReason.OUTSIDE_DEPTH)
+
+    def test_out_of_range_falls_through(self):
+        """A file outside one rule's range can still match the next rule."""
+        perm_file = PermissionFile(
+            rules=[
+                Rule("**", {AccessLevel.WRITE: ["*"]}, min_depth=2, priority=1),
+                Rule("**", {AccessLevel.READ: ["*"]}),
+            ]
+        )
+        resolver = Resolver(".", stat_func=None, permission_files={"": perm_file})
+        self.assertEqual(resolver.resolve("top.txt", "bob@example.com"), AccessLevel.READ)
+        self.assertEqual(resolver.resolve("a/b.txt", "bob@example.com"), AccessLevel.WRITE)
+
+    def test_round_trip(self):
+        """Depth fields survive writing and reading the file again."""
+        rule = Rule("*.md", {AccessLevel.READ: ["*"]}, min_depth=1, max_depth=1)
+        text = PermissionFile(rules=[rule]).to_yaml()
+        self.assertIn("max_depth: 1", text)
+        self.assertEqual(parse_permission_file(text).rules, [rule])
+
+    def test_invalid_depths(self):
+        """Depths must be positive integers with min not above max."""
+        for fields in ["max_depth: 0", "min_depth: yes", "min_depth: 3\n  max_depth: 2"]:
+            with self.subTest(fields=fields):
+                with self.assertRaises(ValueError):
+                    parse_permission_file(f'rules:\n- pattern: "**"\n  {fields}\n')
+
+
+if __name__ == "__main__":
+    unittest.main()
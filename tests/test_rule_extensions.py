@@ -0,0 +1,119 @@
+"""Tests for limiting a rule's pattern to a set of file extensions."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    MatchOptions,
+    PermissionFile,
+    Resolver,
+    Rule,
+    parse_permission_file,
+)
+
+IMAGES = """rules:
+- pattern: "images/**"
+  extensions: [jpg, .png]
+  access:
+    read: ["*"]
+- pattern: "docs/report"
+  extensions: [pdf, tar.gz]
+  access:
+    write: [bob@example.com]
+"""
+
+BOB = "bob@example.com"
+
+
+class TestRuleExtensions(unittest.TestCase):
+    """Test matching a pattern's base with any listed extension."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        (self.test_dir / "syft.pub.yaml").write_text(IMAGES)
+        self.resolver = Resolver(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_composes_with_directory_part(self):
+        """The pattern's directories still apply, at any depth its wildcards allow."""
+        resolve = self.resolver.resolve
+        self.assertEqual(resolve("images/sub/a.jpg", BOB), AccessLevel.READ)
+        self.assertEqual(resolve("images/a.png", BOB), AccessLevel.READ)
+        self.assertEqual(resolve("images/a.gif", BOB), AccessLevel.NONE)
+        self.assertEqual(resolve("other/a.jpg", BOB), AccessLevel.NONE)
+
+    def test_base_name_pattern(self):
+        """A literal pattern names the file without its extension."""
+        resolve = self.resolver.resolve
+        self.assertEqual(resolve("docs/report.pdf", BOB), AccessLevel.WRITE)
+        self.assertEqual(resolve("docs/report.tar.gz", BOB), AccessLevel.WRITE)
+        self.assertEqual(resolve("docs/report", BOB), AccessLevel.NONE)
+        self.assertEqual(resolve("docs/report.doc", BOB), AccessLevel.NONE)
+
+    def test_bare_extension_has_no_base(self):
+        """A file named only after the extension isn't matched."""
+        self.assertEqual(self.resolver.resolve("images/.jpg", BOB), AccessLevel.NONE)
+
+    def test_case(self):
+        """Extensions compare like patterns do: exactly unless matching case-insensitively."""
+        self.assertEqual(self.resolver.resolve("images/A.JPG", BOB), AccessLevel.NONE)
+        resolver = Resolver(self.test_dir, match_options=MatchOptions(case_insensitive=True))
+        self.assertEqual(resolver.resolve("images/A.JPG", BOB), AccessLevel.READ)
+
+    def test_optional(self):
+        """Rules without the field match by pattern alone, and don't write it out."""
+        perm_file = parse_permission_file('rules:\n- pattern: "**"\n')
+        self.assertIsNone(perm_file.rules[0].extensions)
+        self.assertNotIn("extensions", perm_file.to_yaml())
+
+    def test_round_trip(self):
+        """The field is read without dots and written back after the pattern."""
+        perm_file = parse_permission_file(IMAGES)
+        self.assertEqual(perm_file.rules[0].extensions, ["jpg", "png"])
+        yaml_text = perm_file.to_yaml()
+        self.assertIn("- pattern: images/**\n  extensions:\n  - jpg\n  - png\n", yaml_text)
+        self.assertEqual(parse_permission_file(yaml_text), perm_file)
+
+    def test_invalid(self):
+        """Empty lists, non-strings and extensions with separators are rejected."""
+        for value in ("[]", "[1]", "['.']", "['a/b']", "{jpg: 1}"):
+            with self.subTest(value=value):
+                with self.assertRaises(ValueError):
+                    parse_permission_file(f'rules:\n- pattern: "**"\n  extensions: {value}\n')
+
+    def test_distinct_rules(self):
+        """Rules with one pattern but different extensions are kept apart."""
+        perm_file = PermissionFile(rules=[Rule("x/**", extensions=["jpg"])])
+        self.assertIsNone(perm_file.upsert_rule(Rule("x/**", extensions=["pdf"])))
+        self.assertIsNotNone(perm_file.upsert_rule(Rule("x/**", extensions=["pdf"])))
+        self.assertEqual(len(perm_file.rules), 2)
+
+    def test_no_conflict_between_disjoint_sets(self):
+        """Overlapping patterns limited to different extensions don't conflict."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "**"
+  extensions: [jpg]
+  access:
+    read: [bob@example.com]
+- pattern: "**"
+  extensions: [pdf]
+  access:
+    write: [bob@example.com]
+"""
+        )
+        self.assertEqual(perm_file.validate(), [])
+
+
+if __name__ == "__main__":
+    unittest.main()
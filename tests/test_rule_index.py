@@ -0,0 +1,199 @@
+"""
+Tests for skipping rules whose literal prefix a path isn't under.
+
+The speed-up is measured by benchmarks/bench_rule_index.py, outside the test suite.
+"""
+
+import random
+import sys
+import unittest
+from pathlib import Path
+from unittest.mock import patch
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    MatchOptions,
+    Resolver,
+    clear_pattern_cache,
+    parse_permission_file,
+)
+from syft_perm.core import matcher as matcher_module  # noqa: E402
+
+ROOT_RULES = """rules:
+- pattern: "**/*.log"
+  access:
+    read: ["*"]
+- pattern: "/data/Shared/**"
+  access:
+    write: [alice@example.com]
+- pattern: "users/{user}/**"
+  access:
+    admin: ["*"]
+- pattern: "data/*/raw/*.csv"
+  access:
+    read: [bob@example.com]
+- pattern: "Data/{a,b}/x.txt"
+  access:
+    write: [bob@example.com]
+- pattern: "data/plain\\\\*/*.txt"
+  access:
+    read: [carol@example.com]
+- pattern: "cache/"
+  access:
+    read: [carol@example.com]
+- pattern: "images/**"
+  extensions: [jpg]
+  access:
+    read: [carol@example.com]
+"""
+
+DATA_RULES = """rules:
+- pattern: "Shared/reports/*.pdf"
+  access:
+    admin: [carol@example.com]
+- pattern: "/docs/**"
+  access:
+    read: [carol@example.com]
+- pattern: "*"
+  access:
+    read: [dave@example.com]
+"""
+
+PATHS = [
+    "a.log",
+    "data/Shared/x.csv",
+    "data/shared/x.csv",
+    "data/Shared/reports/q1.pdf",
+    "data/shared/REPORTS/q1.PDF",
+    "data/p/raw/a.csv",
+    "data/p/raw/deep/a.csv",
+    "Data/a/x.txt",
+    "data/b/x.txt",
+    "data/plain*/a.txt",
+    "data/plainx/a.txt",
+    "data/notes.txt",
+    "docs/readme.md",
+    "data/docs/readme.md",
+    "users/alice@example.com/f.txt",
+    "users/bob@example.com/deep/f.log",
+    "cache",
+    "images/a.jpg",
+    "images.jpg",
+    "data",
+    "",
+]
+
+USERS = ["alice@example.com", "bob@example.com", "carol@example.com", "dave@example.com"]
+
+
+def _naive_candidates(self, rel_path, relative_path):
+    """Treat every rule as a candidate, like scanning them all."""
+    return set(self.order)
+
+
+class TestRuleIndex(unittest.TestCase):
+    """Test that the index gives the same answers as trying every rule."""
+
+    def setUp(self):
+        """Create in-memory permission files."""
+        clear_pattern_cache()
+        self.permission_files = {
+            "": parse_permission_file(ROOT_RULES),
+            "data": parse_permission_file(DATA_RULES),
+        }
+
+    def _assert_same_as_naive(self, resolver, paths):
+        indexed = [resolver.resolve_with_trace(p, u) for p in paths for u in USERS]
+        with patch.object(matcher_module._RuleIndex, "candidates", _naive_candidates):
+            naive = [resolver.resolve_with_trace(p, u) for p in paths for u in USERS]
+        self.assertEqual(indexed, naive)
+
+    def test_matches_naive_scan(self):
+        """Levels and traces agree for anchored, {user}, brace and escaped patterns."""
+        for options in (None, MatchOptions(case_insensitive=True)):
+            with self.subTest(options=options):
+                resolver = Resolver(
+                    "/nonexistent",
+                    permission_files=self.permission_files,
+                    match_options=options,
+                    stat_func=None,
+                )
+                self._assert_same_as_naive(resolver, PATHS)
+
+    def test_case_folded_prefixes(self):
+        """Case-insensitive matching looks prefixes up case-folded."""
+        resolver = Resolver(
+            "/nonexistent",
+            permission_files=self.permission_files,
+            match_options=MatchOptions(case_insensitive=True),
+            stat_func=None,
+        )
+        self.assertEqual(
+            resolver.resolve("data/SHARED/Reports/q1.PDF", "carol@example.com"), AccessLevel.ADMIN
+        )
+        self.assertEqual(
+            resolver.resolve("data/SHARED/x.csv", "alice@example.com"), AccessLevel.WRITE
+        )
+
+    def test_shared_between_identical_files(self):
+        """Files with the same patterns share one index but keep their own access."""
+        other = parse_permission_file(
+            ROOT_RULES.replace("read: [bob@example.com]", "admin: [bob@example.com]")
+        )
+        first = matcher_module._index_rules(self.permission_files[""])
+        self.assertIs(matcher_module._index_rules(other), first)
+        resolver = Resolver("/nonexistent", permission_files={"": other}, stat_func=None)
+        self.assertEqual(resolver.resolve("data/p/raw/a.csv", "bob@example.com"), AccessLevel.ADMIN)
+
+    def test_random_rulesets(self):
+        """Randomly generated rules and paths resolve as the naive scan does."""
+        rng = random.Random(7)
+        names = ["a", "b", "data", "x.csv", "y.txt"]
+        shapes = ["{}/**", "{}/*.csv", "/{}/*", "**/{}", "{}/*/{}", "{}/{}", "{}/**/{}"]
+        lines = ["rules:"]
+        for i in range(60):
+            pattern = rng.choice(shapes).format(*(rng.choice(names) for _ in range(2)))
+            lines.append(f'- pattern: "{pattern}"\n  access:\n    read: [{rng.choice(USERS)}]')
+        permission_files = {
+            "": parse_permission_file("\n".join(lines) + "\n"),
+            "a": parse_permission_file("\n".join(lines[:30]) + "\n"),
+        }
+        paths = [
+            "/".join(rng.choice(names) for _ in range(rng.randint(1, 4))) for _ in range(150)
+        ]
+        resolver = Resolver("/nonexistent", permission_files=permission_files, stat_func=None)
+        self._assert_same_as_naive(resolver, paths)
+
+    def test_many_rules(self):
+        """With 500 rules under distinct directories the index still agrees with the scan."""
+        rng = random.Random(0)
+        lines = ["rules:"]
+        for i in range(500):
+            directory = f"team{i % 50}/proj{i}"
+            pattern = rng.choice(
+                [
+                    f"{directory}/**",
+                    f"{directory}/*.csv",
+                    f"{directory}/data/**/*.json",
+                    f"{directory}/docs/*.{{md,txt}}",
+                ]
+            )
+            lines.append(f'- pattern: "{pattern}"\n  access:\n    read: [user{i}@example.com]')
+        lines.append('- pattern: "**/*.log"\n  access:\n    read: ["*"]')
+        resolver = Resolver(
+            "/nonexistent",
+            permission_files={"": parse_permission_file("\n".join(lines) + "\n")},
+            stat_func=None,
+        )
+        names = ["a.csv", "data/x/y.json", "docs/r.md", "z.log"]
+        paths = [
+            f"team{rng.randrange(50)}/proj{rng.randrange(500)}/{rng.choice(names)}"
+            for _ in range(20)
+        ]
+        self._assert_same_as_naive(resolver, paths)
+
+
+if __name__ == "__main__":
+    unittest.main()
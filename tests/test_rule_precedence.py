@@ -0,0 +1,173 @@
+"""Tests for deterministic rule precedence and pattern_specificity."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    PermissionFile,
+    Resolver,
+    _sort_rules_by_specificity,
+    parse_permission_file,
+    pattern_specificity,
+)
+
+
+class TestPatternSpecificity(unittest.TestCase):
+    """Test the documented specificity scores."""
+
+    def test_scores(self):
+        """Scores follow 2L + 10D minus wildcard penalties."""
+        self.assertEqual(pattern_specificity("**"), -100)
+        self.assertEqual(pattern_specificity("**/*"), -99)
+        self.assertEqual(pattern_specificity("a.txt"), 10)
+        self.assertEqual(pattern_specificity("docs/a.txt"), 2 * 10 + 10)
+        self.assertEqual(pattern_specificity("*.txt"), 2 * 5 - 20)
+        self.assertEqual(pattern_specificity("docs/*.md"), 2 * 9 + 10 - 10)
+        self.assertEqual(pattern_specificity("file?.csv"), 2 * 9 - 2)
+
+    def test_exclusion_marker_ignored(self):
+        """An exclusion scores like the pattern it excludes."""
+        self.assertEqual(pattern_specificity("!docs/*.md"), pattern_specificity("docs/*.md"))
+
+    def test_more_literal_wins(self):
+        """Fewer wildcards and longer literal parts score higher."""
+        self.assertGreater(
+            pattern_specificity("data/report.csv"), pattern_specificity("data/*.csv")
+        )
+        self.assertGreater(pattern_specificity("data/*.csv"), pattern_specificity("**/*.csv"))
+
+
+class TestRulePrecedence(unittest.TestCase):
+    """Test tie-breaking between equally specific rules."""
+
+    MIXED = """rules:
+- pattern: "a/*.txt"
+  access:
+    read: [alice@example.com]
+- pattern: "*/b.txt"
+  access:
+    write: [alice@example.com]
+- pattern: "!a/?.txt"
+- pattern: "a/b.txt"
+  access:
+    admin: [alice@example.com]
+"""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_specificity_order(self):
+        """Rules are tried from the highest specificity score down."""
+        perm_file = parse_permission_file(self.MIXED)
+        order = [index for index, _ in perm_file.ordered_rules()]
+        self.assertEqual(order, [3, 2, 0, 1])
+
+    def test_ties_keep_declaration_order(self):
+        """Equally specific rules are tried in the order they were declared."""
+        tied = parse_permission_file(
+            'rules:\n- pattern: "x/*.md"\n- pattern: "y/*.md"\n- pattern: "z/*.md"\n'
+        )
+        self.assertEqual([index for index, _ in tied.ordered_rules()], [0, 1, 2])
+        raw = [{"pattern": "x/*.md", "n": 0}, {"pattern": "y/*.md", "n": 1}]
+        self.assertEqual([rule["n"] for rule in _sort_rules_by_specificity(raw)], [0, 1])
+
+    def test_exclusion_wins_equal_tie(self):
+        """An exclusion beats an equally specific include regardless of order."""
+        perm_file = parse_permission_file(
+            'rules:\n- pattern: "a/*"\n  access:\n    read: ["*"]\n- pattern: "!a/*"\n'
+        )
+        self.assertEqual([index for index, _ in perm_file.ordered_rules()], [1, 0])
+
+    def test_repeated_resolution_is_stable(self):
+        """Resolving the same path many times gives the same level and trace."""
+        (self.test_dir / "a").mkdir()
+        (self.test_dir / "a" / "syft.pub.yaml").write_text(
+            'rules:\n- pattern: "*.txt"\n  access:\n    read: [alice@example.com]\n'
+            '- pattern: "n?tes.txt"\n  access:\n    write: [alice@example.com]\n'
+            '- pattern: "no*.txt"\n  access:\n    admin: [alice@example.com]\n'
+        )
+        resolver = Resolver(self.test_dir)
+        first = resolver.resolve_with_trace("a/notes.txt", "alice@example.com")
+        for _ in range(50):
+            self.assertEqual(resolver.resolve_with_trace("a/notes.txt", "alice@example.com"), first)
+        self.assertEqual(first[0], AccessLevel.WRITE)
+
+
+class TestRulePriority(unittest.TestCase):
+    """Test that priority overrides specificity within a file."""
+
+    PRIORITIZED = """rules:
+- pattern: "secret.txt"
+  access:
+    admin: [alice@example.com]
+- pattern: "**"
+  priority: 10
+  access:
+    read: [alice@example.com]
+- pattern: "*.txt"
+  priority: -1
+  access:
+    write: [alice@example.com]
+- pattern: "notes.txt"
+  access:
+    write: [alice@example.com]
+"""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        (self.test_dir / "syft.pub.yaml").write_text(self.PRIORITIZED)
+        self.resolver = Resolver(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_priority_before_specificity(self):
+        """Priority orders rules first; specificity only breaks ties within a priority."""
+        perm_file = parse_permission_file(self.PRIORITIZED)
+        self.assertEqual([index for index, _ in perm_file.ordered_rules()], [1, 0, 3, 2])
+
+    def test_high_priority_catch_all_wins(self):
+        """A broad high-priority rule beats an exact default-priority one."""
+        self.assertEqual(self.resolver.resolve("secret.txt", "alice@example.com"), AccessLevel.READ)
+        _, trace = self.resolver.resolve_with_trace("secret.txt", "alice@example.com")
+        self.assertEqual([m.pattern for m in trace if m.applied], ["**"])
+
+    def test_legacy_sort_honors_priority(self):
+        """Raw rule mappings sort the same way, treating invalid priorities as 0."""
+        raw = [
+            {"pattern": "a/b.txt", "n": 0},
+            {"pattern": "**", "priority": 5, "n": 1},
+            {"pattern": "a/*.txt", "priority": "high", "n": 2},
+        ]
+        self.assertEqual([rule["n"] for rule in _sort_rules_by_specificity(raw)], [1, 0, 2])
+
+    def test_priority_serialized_when_set(self):
+        """Non-zero priorities survive yaml and json round trips."""
+        perm_file = parse_permission_file(self.PRIORITIZED)
+        self.assertEqual(parse_permission_file(perm_file.to_yaml()), perm_file)
+        self.assertEqual(PermissionFile.from_json(perm_file.to_json()), perm_file)
+        self.assertIn("  priority: 10\n", perm_file.to_yaml())
+        self.assertNotIn("priority", perm_file.rules[0].to_dict())
+
+    def test_priority_must_be_integer(self):
+        """Non-integer priorities are rejected when loading."""
+        for value in ["high", "1.5", "true"]:
+            with self.assertRaisesRegex(ValueError, "priority must be an integer"):
+                parse_permission_file(f'rules:\n- pattern: "x"\n  priority: {value}\n')
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,189 @@
+"""Tests for rules that only apply within a not_before/not_after window."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from datetime import datetime, timedelta, timezone
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    PermissionFile,
+    PermissionFileBuilder,
+    PermissionStore,
+    Resolver,
+    TraceReason,
+    parse_permission_file,
+)
+
+START = datetime(2024, 6, 1, tzinfo=timezone.utc)
+END = datetime(2024, 7, 1, tzinfo=timezone.utc)
+
+TEMPORARY = """rules:
+- pattern: "**"
+  not_before: 2024-06-01T00:00:00Z
+  not_after: 2024-07-01T00:00:00Z
+  access:
+    write: [bob@example.com]
+"""
+
+
+class FakeClock:
+    """A clock that stays where a test sets it."""
+
+    def __init__(self, now: datetime):
+        self.now = now
+
+    def __call__(self) -> datetime:
+        return self.now
+
+
+class TestRuleValidity(unittest.TestCase):
+    """Test that rules apply inside their window and are inert outside it."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        self.clock = FakeClock(START)
+        self.resolver = Resolver(self.test_dir, clock=self.clock)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def _level_at(self, now, path="a.txt"):
+        self.clock.now = now
+        return self.resolver.resolve(path, "bob@example.com")
+
+    def test_active_inside_window(self):
+        """Between not_before and not_after the rule grants its access."""
+        write_file(self.test_dir, "syft.pub.yaml", TEMPORARY)
+        self.assertEqual(self._level_at(START), AccessLevel.WRITE)
+        self.assertEqual(self._level_at(START + timedelta(days=10)), AccessLevel.WRITE)
+
+    def test_inert_outside_window(self):
+        """Before not_before and from not_after on the rule doesn't apply."""
+        write_file(self.test_dir, "syft.pub.yaml", TEMPORARY)
+        self.assertEqual(self._level_at(START - timedelta(seconds=1)), AccessLevel.NONE)
+        self.assertEqual(self._level_at(END), AccessLevel.NONE)
+        self.assertEqual(self._level_at(END + timedelta(days=1)), AccessLevel.NONE)
+
+    def test_falls_back_to_inherited_rules(self):
+        """Once a nearer rule expires, the parent's rules decide again."""
+        write_file(
+            self.test_dir, "syft.pub.yaml", 'rules:\n- pattern: "**"\n  access:\n    read: ["*"]\n'
+        )
+        write_file(self.test_dir, "shared/syft.pub.yaml", TEMPORARY.replace('"**"', '"*.txt"'))
+        self.assertEqual(self._level_at(START, "shared/a.txt"), AccessLevel.WRITE)
+        self.assertEqual(self._level_at(END, "shared/a.txt"), AccessLevel.READ)
+
+    def test_expired_terminal_rule_stops_blocking(self):
+        """A terminal rule outside its window no longer cuts off other files."""
+        write_file(
+            self.test_dir, "syft.pub.yaml", 'rules:\n- pattern: "**"\n  access:\n    read: ["*"]\n'
+        )
+        write_file(
+            self.test_dir,
+            "shared/syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  terminal: true
+  not_after: 2024-07-01T00:00:00Z
+  access:
+    admin: [carol@example.com]
+""",
+        )
+        self.assertEqual(self._level_at(START, "shared/a.txt"), AccessLevel.NONE)
+        self.assertEqual(self._level_at(END, "shared/a/b.txt"), AccessLevel.READ)
+
+    def test_trace(self):
+        """Skipped rules are traced as outside their window."""
+        write_file(self.test_dir, "syft.pub.yaml", TEMPORARY)
+        self.clock.now = END
+        _, trace = self.resolver.resolve_with_trace("a.txt", "bob@example.com")
+        self.assertEqual([match.reason for match in trace], [TraceReason.INACTIVE])
+
+    def test_clock_read_once_per_path(self):
+        """Every user of one resolve_for_users call is checked at the same instant."""
+        write_file(self.test_dir, "syft.pub.yaml", TEMPORARY)
+        calls = []
+
+        def clock():
+            calls.append(None)
+            return START
+
+        resolver = Resolver(self.test_dir, clock=clock)
+        resolver.resolve_for_users("a.txt", ["bob@example.com", "carol@example.com"])
+        self.assertEqual(len(calls), 1)
+
+    def test_store_cache_expires_with_window(self):
+        """Levels cached by a store are dropped when a window closes."""
+        write_file(self.test_dir, "bob@example.com/syft.pub.yaml", TEMPORARY)
+        store = PermissionStore(self.test_dir, clock=self.clock)
+        store.reload_all()
+        self.assertEqual(
+            store.resolve_cached("bob@example.com", "a.txt", "bob@example.com"), AccessLevel.WRITE
+        )
+        self.clock.now = END
+        self.assertEqual(
+            store.resolve_cached("bob@example.com", "a.txt", "bob@example.com"), AccessLevel.NONE
+        )
+
+
+class TestRuleValidityParsing(unittest.TestCase):
+    """Test reading and writing validity windows."""
+
+    def _rule(self, extra):
+        return parse_permission_file(f'rules:\n- pattern: "*"\n{extra}').rules[0]
+
+    def test_yaml_and_string_timestamps(self):
+        """Bare yaml timestamps and quoted RFC 3339 strings both parse."""
+        rule = self._rule(
+            '  not_before: 2024-06-01T00:00:00Z\n  not_after: "2024-07-01T02:00:00+02:00"\n'
+        )
+        self.assertEqual(rule.not_before, START)
+        self.assertEqual(rule.not_after, END)
+
+    def test_bad_timestamps_rejected(self):
+        """Dates, times without an offset and other values are errors."""
+        for value in ("2024-06-01", "2024-06-01T00:00:00", '"tomorrow"', "5"):
+            with self.subTest(value=value):
+                with self.assertRaisesRegex(ValueError, "not_after must be an RFC 3339 timestamp"):
+                    self._rule(f"  not_after: {value}\n")
+
+    def test_empty_window_rejected(self):
+        """not_before must come before not_after."""
+        with self.assertRaisesRegex(ValueError, "not_before is not earlier than not_after"):
+            self._rule("  not_before: 2024-07-01T00:00:00Z\n  not_after: 2024-06-01T00:00:00Z\n")
+
+    def test_round_trip(self):
+        """to_dict writes the window as ISO 8601 strings that parse back to the rule."""
+        perm_file = parse_permission_file(TEMPORARY)
+        data = perm_file.to_dict()
+        self.assertEqual(data["rules"][0]["not_before"], "2024-06-01T00:00:00+00:00")
+        self.assertEqual(PermissionFile.from_dict(data), perm_file)
+        plain = parse_permission_file('rules:\n- pattern: "*"\n').rules[0]
+        self.assertNotIn("not_after", plain.to_dict())
+
+    def test_builder(self):
+        """The builder sets the current rule's window."""
+        perm_file = (
+            PermissionFileBuilder()
+            .add_rule("**")
+            .grant("read", "*")
+            .active(not_before=START, not_after=END)
+            .build()
+        )
+        self.assertEqual(
+            (perm_file.rules[0].not_before, perm_file.rules[0].not_after), (START, END)
+        )
+        self.assertTrue(perm_file.rules[0].active_at(START))
+        self.assertFalse(perm_file.rules[0].active_at(END))
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,89 @@
+"""Tests that single-segment wildcards never cross directory boundaries."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import AccessLevel, PatternMatcher, Resolver, is_recursive, match  # noqa: E402
+
+
+class TestSegmentWildcards(unittest.TestCase):
+    """Test the documented difference between * and **."""
+
+    def test_star_is_direct_children_only(self):
+        """data/* matches direct children but not deeper descendants."""
+        self.assertTrue(match("data/*", "data/file.txt"))
+        self.assertFalse(match("data/*", "data/sub/file.txt"))
+        self.assertFalse(match("data/*", "data"))
+        self.assertFalse(match("*.txt", "a/b.txt"))
+        self.assertFalse(match("a*b", "axx/yb"))
+        self.assertFalse(match("*/*", "a/b/c"))
+        self.assertFalse(PatternMatcher("data/*").match_path("data/sub/file.txt"))
+
+    def test_doublestar_is_all_descendants(self):
+        """data/** matches descendants at any depth but not the directory itself."""
+        self.assertTrue(match("data/**", "data/file.txt"))
+        self.assertTrue(match("data/**", "data/sub/file.txt"))
+        self.assertTrue(match("data/**", "data/a/b/c/d.txt"))
+        self.assertFalse(match("data/**", "data"))
+
+    def test_wildcard_before_doublestar(self):
+        """Wildcards in the segments before ** match exactly those segments."""
+        self.assertTrue(match("data*/**", "data1/x"))
+        self.assertTrue(match("d*/**", "dx/y/z"))
+        self.assertFalse(match("d*/**", "dx"))
+        self.assertFalse(match("d*/**", "e/x"))
+        self.assertTrue(match("a/*/**/c", "a/b/c"))
+        self.assertTrue(match("a/*/**/c", "a/b/x/y/c"))
+        self.assertFalse(match("a/*/**/c", "a/c"))
+
+    def test_is_recursive(self):
+        """is_recursive tells fixed-depth patterns from any-depth ones."""
+        self.assertFalse(is_recursive("data/*"))
+        self.assertFalse(is_recursive("*.txt"))
+        self.assertTrue(is_recursive("data/**"))
+        self.assertTrue(is_recursive("!**/secret"))
+        self.assertTrue(is_recursive("{docs/*,src/**}"))
+
+
+class TestSegmentWildcardsResolver(unittest.TestCase):
+    """Test * versus ** through the resolver."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        (self.test_dir / "syft.pub.yaml").write_text(
+            """rules:
+- pattern: "data/*"
+  access:
+    read: [alice@example.com]
+- pattern: "docs/**"
+  access:
+    read: [alice@example.com]
+"""
+        )
+        self.resolver = Resolver(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_direct_children_only(self):
+        """A data/* grant does not reach into subdirectories."""
+        user = "alice@example.com"
+        self.assertEqual(self.resolver.resolve("data/file.txt", user), AccessLevel.READ)
+        self.assertEqual(self.resolver.resolve("data/sub/file.txt", user), AccessLevel.NONE)
+
+    def test_all_descendants(self):
+        """A docs/** grant reaches every depth."""
+        user = "alice@example.com"
+        self.assertEqual(self.resolver.resolve("docs/file.txt", user), AccessLevel.READ)
+        self.assertEqual(self.resolver.resolve("docs/sub/file.txt", user), AccessLevel.READ)
+
+
+if __name__ == "__main__":
+    unittest.main()
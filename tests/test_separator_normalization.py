@@ -0,0 +1,81 @@
+"""Tests for normalizing backslash separators in paths before matching."""
+
+import os
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import AccessLevel, MatchOptions, PatternMatcher, Resolver, match  # noqa: E402
+
+NORMALIZE = MatchOptions(normalize_separators=True)
+LITERAL = MatchOptions(normalize_separators=False)
+
+
+class TestSeparatorNormalization(unittest.TestCase):
+    """Test that Windows-style paths match forward-slash patterns when enabled."""
+
+    def test_default_follows_os(self):
+        """Normalization is on by default only where backslash is the OS separator."""
+        self.assertEqual(MatchOptions().normalize_separators, os.sep == "\\")
+
+    def test_backslash_and_mixed_paths(self):
+        """Backslash and mixed-separator paths match once normalized."""
+        for path in ["data\\reports\\q1.csv", "data\\reports/q1.csv", "data/reports\\q1.csv"]:
+            self.assertTrue(match("data/**", path, NORMALIZE), path)
+            self.assertTrue(match("data/*/q1.csv", path, NORMALIZE), path)
+            self.assertTrue(PatternMatcher("data/**/*.csv", NORMALIZE).match_path(path), path)
+            self.assertFalse(match("data/*/q1.csv", path, LITERAL), path)
+
+    def test_literal_backslashes_kept_when_disabled(self):
+        """Without normalization a backslash stays part of the file name."""
+        self.assertTrue(match("notes\\\\draft.txt", "notes\\draft.txt", LITERAL))
+        self.assertTrue(match("*.txt", "notes\\draft.txt", LITERAL))
+        self.assertFalse(match("notes/*", "notes\\draft.txt", LITERAL))
+
+    def test_patterns_not_converted(self):
+        """Backslashes in patterns remain escapes even when paths are normalized."""
+        self.assertTrue(match("data/\\*.csv", "data\\*.csv", NORMALIZE))
+        self.assertFalse(match("data/\\*.csv", "data\\q1.csv", NORMALIZE))
+
+
+class TestResolverSeparators(unittest.TestCase):
+    """Test separator normalization through the resolver."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        (self.test_dir / "data" / "reports").mkdir(parents=True)
+        (self.test_dir / "syft.pub.yaml").write_text(
+            'rules:\n- pattern: "data/**"\n  access:\n    read: ["*"]\n'
+        )
+        (self.test_dir / "data" / "reports" / "syft.pub.yaml").write_text(
+            'rules:\n- pattern: "*.csv"\n  access:\n    write: [alice@example.com]\n'
+        )
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_backslash_path_uses_nested_rules(self):
+        """A backslash path walks the same permission files as its slash form."""
+        resolver = Resolver(self.test_dir, NORMALIZE)
+        for path in ["data\\reports\\q1.csv", "data\\reports/q1.csv"]:
+            self.assertEqual(resolver.resolve(path, "alice@example.com"), AccessLevel.WRITE)
+            self.assertEqual(resolver.resolve(path, "bob@example.com"), AccessLevel.NONE)
+        absolute = str(self.test_dir) + "\\data\\reports\\q1.csv"
+        self.assertEqual(resolver.resolve(absolute, "alice@example.com"), AccessLevel.WRITE)
+
+    def test_disabled_is_lexical(self):
+        """Without normalization the backslash path is a single top-level name."""
+        resolver = Resolver(self.test_dir, LITERAL)
+        self.assertEqual(
+            resolver.resolve("data\\reports\\q1.csv", "alice@example.com"), AccessLevel.NONE
+        )
+
+
+if __name__ == "__main__":
+    unittest.main()
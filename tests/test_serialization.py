@@ -0,0 +1,88 @@
+"""Tests for json and canonical yaml serialization of permission files."""
+
+import json
+import sys
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    PatternSyntaxError,
+    PermissionFile,
+    Rule,
+    parse_permission_file,
+)
+
+SAMPLE = """terminal: true
+rules:
+- pattern: "**/*.csv"
+  access:
+    read: [public]
+    admin: [root@example.com]
+    write: ["*@example.com"]
+  limits:
+    max_file_size: 1024
+    allow_dirs: false
+- pattern: "private/**"
+  terminal: true
+  access:
+    read: [alice@example.com]
+- pattern: "!private/keys/*"
+"""
+
+
+class TestSerialization(unittest.TestCase):
+    """Test round-tripping the permission model through json and yaml."""
+
+    def test_json_shape(self):
+        """The json shape is stable and uses level names."""
+        data = json.loads(parse_permission_file(SAMPLE).to_json())
+        self.assertEqual(list(data), ["terminal", "rules"])
+        self.assertTrue(data["terminal"])
+        first = data["rules"][0]
+        self.assertEqual(list(first), ["pattern", "terminal", "access", "limits"])
+        self.assertEqual(list(first["access"]), ["admin", "write", "read"])
+        self.assertEqual(first["access"]["read"], ["*"])
+        self.assertEqual(
+            data["rules"][2],
+            {"pattern": "!private/keys/*", "terminal": False, "access": {}, "limits": {}},
+        )
+
+    def test_round_trip_is_byte_stable(self):
+        """yaml -> model -> json -> model -> yaml gives identical output every time."""
+        first = parse_permission_file(SAMPLE)
+        yaml_once = first.to_yaml()
+        restored = PermissionFile.from_json(first.to_json())
+        self.assertEqual(restored, first)
+        self.assertEqual(restored.to_yaml(), yaml_once)
+        self.assertEqual(parse_permission_file(yaml_once).to_yaml(), yaml_once)
+        self.assertEqual(restored.to_json(), first.to_json())
+
+    def test_canonical_yaml(self):
+        """Canonical yaml omits unset fields and orders access from admin down."""
+        text = parse_permission_file(SAMPLE).to_yaml()
+        self.assertTrue(text.startswith("terminal: true\nrules:\n"))
+        self.assertLess(text.index("admin:"), text.index("write:"))
+        self.assertLess(text.index("write:"), text.index("read:"))
+        self.assertIn("- pattern: '!private/keys/*'\n", text)
+        self.assertFalse(text.rstrip().endswith("{}"))
+
+        plain = PermissionFile(rules=[Rule("*.txt", {AccessLevel.READ: ["*"]})]).to_yaml()
+        self.assertEqual(plain, "rules:\n- pattern: '*.txt'\n  access:\n    read:\n    - '*'\n")
+
+    def test_from_dict_validates(self):
+        """Models built from json are validated like yaml."""
+        with self.assertRaises(ValueError):
+            PermissionFile.from_dict({"rules": [{"pattern": "x", "access": {"owner": ["a"]}}]})
+        with self.assertRaises(PatternSyntaxError):
+            PermissionFile.from_json('{"rules": [{"pattern": "[bad"}]}')
+        with self.assertRaises(ValueError):
+            PermissionFile.from_json("{not json")
+        rule = Rule.from_dict({"pattern": "*.md", "access": {"write": ["a@b.c"]}})
+        self.assertEqual(rule.access, {AccessLevel.WRITE: ["a@b.c"]})
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,85 @@
+"""Tests for recording where each rule is defined in its permission file."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    PatternSyntaxError,
+    PermissionFile,
+    Rule,
+    SourcePosition,
+    load_permission_file,
+    parse_permission_file,
+)
+
+CONTENT = """terminal: false
+rules:
+- pattern: "*.csv"
+  access:
+    read: ["*"]
+
+# comment between rules
+- pattern: "docs/**"
+  access:
+    write: [alice@example.com]
+-   pattern: "indented/*"
+"""
+
+
+class TestSourcePositions(unittest.TestCase):
+    """Test SourcePosition values recorded by the loader."""
+
+    def setUp(self):
+        """Create a temporary directory."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_lines_and_columns(self):
+        """Each rule records the 1-based line and column where it starts."""
+        yaml_path = self.test_dir / "syft.pub.yaml"
+        yaml_path.write_text(CONTENT)
+
+        perm_file = load_permission_file(yaml_path)
+        positions = [rule.position for rule in perm_file.rules]
+        self.assertEqual(
+            positions,
+            [
+                SourcePosition(yaml_path, 3, 3),
+                SourcePosition(yaml_path, 8, 3),
+                SourcePosition(yaml_path, 11, 5),
+            ],
+        )
+        self.assertEqual(str(positions[1]), f"{yaml_path}:8:3")
+
+    def test_programmatic_rules_have_no_position(self):
+        """Rules built in code or from json have no position and still compare equal."""
+        built = Rule("*.csv", {AccessLevel.READ: ["*"]})
+        self.assertIsNone(built.position)
+        loaded = parse_permission_file(CONTENT).rules[0]
+        self.assertEqual(loaded, built)
+        from_json = PermissionFile.from_json(parse_permission_file(CONTENT).to_json())
+        self.assertIsNone(from_json.rules[0].position)
+
+    def test_errors_mention_line(self):
+        """Load errors point at the line of the offending rule."""
+        with self.assertRaises(ValueError) as ctx:
+            parse_permission_file('rules:\n- pattern: "a"\n- pattern: "b"\n  access: {own: [x]}\n')
+        self.assertIn("syft.pub.yaml:3: rule 1", str(ctx.exception))
+
+        with self.assertRaises(PatternSyntaxError) as ctx:
+            parse_permission_file('rules:\n- pattern: "ok"\n\n- pattern: "[bad"\n')
+        self.assertEqual(ctx.exception.lines, {0: 2, 1: 4})
+        self.assertIn("rule 1 ('[bad') at line 4", str(ctx.exception))
+
+
+if __name__ == "__main__":
+    unittest.main()
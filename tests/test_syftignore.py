@@ -0,0 +1,133 @@
+"""Tests for taking paths out of the datasite with .syftignore files."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+from unittest.mock import patch
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    PatternSyntaxError,
+    Resolver,
+    TraceReason,
+    parse_ignore_file,
+)
+
+PUBLIC_RULES = """rules:
+- pattern: "**"
+  access:
+    read: ["*"]
+"""
+
+FILES = [
+    "notes.txt",
+    "build.log",
+    "keep.log",
+    "cache/blob.bin",
+    "data/a.csv",
+    "data/tmp/b.csv",
+    "data/tmp/keep.csv",
+]
+
+BOB = "bob@example.com"
+
+
+class TestSyftIgnore(unittest.TestCase):
+    """Test that ignored paths have no access before any rule is consulted."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        write_file(self.test_dir, "syft.pub.yaml", PUBLIC_RULES)
+        write_file(self.test_dir, ".syftignore", "# build output\n*.log\n!keep.log\n\ncache/\n")
+        write_file(self.test_dir, "data/.syftignore", "tmp/**\n")
+        for rel_path in FILES:
+            write_file(self.test_dir, rel_path, "x")
+        self.resolver = Resolver(self.test_dir, ignore_files=True)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_ignored_despite_grant(self):
+        """An ignored file gets no access even though a ``**`` rule grants it."""
+        resolve = self.resolver.resolve
+        self.assertEqual(resolve("build.log", BOB), AccessLevel.NONE)
+        self.assertEqual(resolve("notes.txt", BOB), AccessLevel.READ)
+
+    def test_negation(self):
+        """A later ``!`` pattern takes a path back out of the ignored ones."""
+        self.assertEqual(self.resolver.resolve("keep.log", BOB), AccessLevel.READ)
+
+    def test_directory_pattern(self):
+        """Everything beneath an ignored directory is ignored too."""
+        self.assertEqual(self.resolver.resolve("cache/blob.bin", BOB), AccessLevel.NONE)
+        self.assertEqual(self.resolver.resolve("cache", BOB, is_dir=True), AccessLevel.NONE)
+
+    def test_relative_to_ignore_file(self):
+        """Patterns are relative to the directory of the file they're in."""
+        resolve = self.resolver.resolve
+        self.assertEqual(resolve("data/tmp/b.csv", BOB), AccessLevel.NONE)
+        self.assertEqual(resolve("data/a.csv", BOB), AccessLevel.READ)
+        self.assertEqual(resolve("tmp/b.csv", BOB), AccessLevel.READ)
+
+    def test_nested_file_overrides(self):
+        """A nested ignore file can take back what one above it ignores."""
+        write_file(self.test_dir, "data/.syftignore", "tmp/**\n!tmp/keep.csv\n")
+        resolver = Resolver(self.test_dir, ignore_files=True)
+        self.assertEqual(resolver.resolve("data/tmp/keep.csv", BOB), AccessLevel.READ)
+        write_file(self.test_dir, "data/tmp/build.log", "x")
+        self.assertEqual(resolver.resolve("data/tmp/build.log", BOB), AccessLevel.NONE)
+
+    def test_ignores_default_access(self):
+        """An ignored path has no access even where the default would grant some."""
+        resolver = Resolver(self.test_dir, ignore_files=True, default_access=AccessLevel.READ)
+        self.assertEqual(resolver.resolve("build.log", BOB), AccessLevel.NONE)
+
+    def test_trace(self):
+        """The trace shows every rule skipped because the path is ignored."""
+        level, trace = self.resolver.resolve_with_trace("build.log", BOB)
+        self.assertEqual(level, AccessLevel.NONE)
+        self.assertEqual([m.reason for m in trace], [TraceReason.IGNORED])
+        self.assertFalse(trace[0].applied)
+
+    def test_walk_skips_ignored(self):
+        """Walking leaves ignored files and directories out."""
+        walked = dict(self.resolver.walk(BOB))
+        self.assertEqual(sorted(walked), ["data/a.csv", "keep.log", "notes.txt"])
+
+    def test_batch_reads_ignore_files_once(self):
+        """A batch reads each ignore file once and agrees with resolving each path."""
+        real_load = self.resolver._load_ignore
+        with patch.object(self.resolver, "_load_ignore", side_effect=real_load) as load_mock:
+            results = self.resolver.resolve_batch(FILES, BOB)
+        self.assertEqual(load_mock.call_count, 3)
+        self.assertEqual(results, {path: self.resolver.resolve(path, BOB) for path in FILES})
+
+    def test_off_by_default(self):
+        """Without the option ignore files are ordinary hidden files."""
+        resolver = Resolver(self.test_dir)
+        self.assertEqual(resolver.resolve("build.log", BOB), AccessLevel.READ)
+        self.assertEqual(len(dict(resolver.walk(BOB))), len(FILES))
+
+    def test_parse(self):
+        """Comments and blank lines are skipped, and each pattern keeps its line."""
+        rules = parse_ignore_file("# comment\n\n*.log  \n!keep.log\n")
+        self.assertEqual([rule.pattern for rule in rules], ["*.log", "!keep.log"])
+        self.assertEqual([rule.position.line for rule in rules], [3, 4])
+        self.assertTrue(rules[1].is_exclusion)
+
+    def test_invalid_pattern(self):
+        """Malformed patterns are reported like those of permission files."""
+        with self.assertRaises(PatternSyntaxError) as raised:
+            parse_ignore_file("ok\na/[b\n")
+        self.assertIn(".syftignore", str(raised.exception))
+
+
+if __name__ == "__main__":
+    unittest.main()
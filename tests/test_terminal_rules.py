@@ -0,0 +1,176 @@
+"""Tests for rule-level terminal flags in the core resolver."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import AccessLevel, Resolver, TraceReason, parse_permission_file  # noqa: E402
+
+
+class TestTerminalRules(unittest.TestCase):
+    """Test that terminal rules and files stop inheritance for the paths they match."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        self.resolver = Resolver(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def _write_root_grant(self):
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  access:
+    read: ["*"]
+""",
+        )
+
+    def test_rule_terminal_flag_parsed(self):
+        """terminal: true on a rule is loaded into the model."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "private/**"
+  terminal: true
+  access:
+    read: [alice@example.com]
+- pattern: "**"
+"""
+        )
+        self.assertTrue(perm_file.rules[0].terminal)
+        self.assertFalse(perm_file.rules[1].terminal)
+        self.assertFalse(perm_file.terminal)
+
+    def test_rule_terminal_must_be_boolean(self):
+        """A rule terminal flag that isn't a yaml boolean is rejected when loading."""
+        for value in ['"false"', "0", "yes please"]:
+            with self.assertRaisesRegex(ValueError, "terminal must be a boolean"):
+                parse_permission_file(f'rules:\n- pattern: "x"\n  terminal: {value}\n')
+
+    def test_file_terminal_must_be_boolean(self):
+        """A file terminal flag that isn't a yaml boolean is rejected when loading."""
+        with self.assertRaisesRegex(ValueError, "terminal must be a boolean"):
+            parse_permission_file('terminal: "false"\nrules: []\n')
+
+    def test_intermediate_terminal_rule_shadows_root_grant(self):
+        """A terminal rule at an intermediate directory ignores the broader root grant."""
+        self._write_root_grant()
+        write_file(
+            self.test_dir,
+            "projects/syft.pub.yaml",
+            """rules:
+- pattern: "private/**"
+  terminal: true
+  access:
+    write: [alice@example.com]
+""",
+        )
+        write_file(
+            self.test_dir,
+            "projects/private/notes/syft.pub.yaml",
+            """rules:
+- pattern: "*.txt"
+  access:
+    admin: [bob@example.com]
+""",
+        )
+        write_file(self.test_dir, "projects/private/notes/todo.txt", "x")
+        write_file(self.test_dir, "projects/public/readme.md", "x")
+
+        path = "projects/private/notes/todo.txt"
+        self.assertEqual(self.resolver.resolve(path, "alice@example.com"), AccessLevel.WRITE)
+        # Neither the root grant nor the nested admin grant applies below the terminal rule
+        self.assertEqual(self.resolver.resolve(path, "bob@example.com"), AccessLevel.NONE)
+        self.assertEqual(self.resolver.resolve(path, "carol@example.com"), AccessLevel.NONE)
+
+        _, trace = self.resolver.resolve_with_trace(path, "bob@example.com")
+        by_dir = {m.directory: m for m in trace}
+        overridden = TraceReason.OVERRIDDEN_BY_TERMINAL
+        self.assertEqual(by_dir["projects/private/notes"].reason, overridden)
+        self.assertEqual(by_dir[""].reason, overridden)
+
+    def test_terminal_rule_only_affects_matching_paths(self):
+        """Paths the terminal rule doesn't match keep normal inheritance."""
+        self._write_root_grant()
+        write_file(
+            self.test_dir,
+            "projects/syft.pub.yaml",
+            """rules:
+- pattern: "private/**"
+  terminal: true
+  access:
+    write: [alice@example.com]
+""",
+        )
+        write_file(self.test_dir, "projects/public/readme.md", "x")
+
+        self.assertEqual(
+            self.resolver.resolve("projects/public/readme.md", "carol@example.com"),
+            AccessLevel.READ,
+        )
+
+    def test_terminal_file_shadows_root_grant(self):
+        """A file-level terminal at an intermediate directory is self-contained."""
+        self._write_root_grant()
+        write_file(
+            self.test_dir,
+            "vault/syft.pub.yaml",
+            """terminal: true
+rules:
+- pattern: "keys/*"
+  access:
+    read: [alice@example.com]
+""",
+        )
+        write_file(self.test_dir, "vault/keys/a.pem", "x")
+        write_file(self.test_dir, "vault/other.txt", "x")
+
+        self.assertEqual(
+            self.resolver.resolve("vault/keys/a.pem", "alice@example.com"), AccessLevel.READ
+        )
+        self.assertEqual(
+            self.resolver.resolve("vault/keys/a.pem", "bob@example.com"), AccessLevel.NONE
+        )
+        # No rule in the terminal file matches, and inheritance is still blocked
+        self.assertEqual(
+            self.resolver.resolve("vault/other.txt", "bob@example.com"), AccessLevel.NONE
+        )
+
+    def test_terminal_nearest_root_wins(self):
+        """When several terminals apply, the one closest to the root decides."""
+        write_file(
+            self.test_dir,
+            "a/syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  terminal: true
+  access:
+    read: [alice@example.com]
+""",
+        )
+        write_file(
+            self.test_dir,
+            "a/b/syft.pub.yaml",
+            """terminal: true
+rules:
+- pattern: "**"
+  access:
+    admin: [alice@example.com]
+""",
+        )
+        write_file(self.test_dir, "a/b/c.txt", "x")
+
+        self.assertEqual(self.resolver.resolve("a/b/c.txt", "alice@example.com"), AccessLevel.READ)
+
+
+if __name__ == "__main__":
+    unittest.main()
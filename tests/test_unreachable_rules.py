@@ -0,0 +1,201 @@
+"""Tests for PermissionFile.validate reporting rules shadowed by terminal rules."""
+
+import sys
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    PermissionFile,
+    Resolver,
+    RuleConflict,
+    UnreachableRule,
+    parse_permission_file,
+)
+
+TERMINAL_ALL = """rules:
+- pattern: "**"
+  terminal: true
+  access:
+    read: ["*"]
+"""
+
+
+def _unreachable(findings):
+    return [finding for finding in findings if isinstance(finding, UnreachableRule)]
+
+
+class TestUnreachableRules(unittest.TestCase):
+    """Test which rules validate reports as dead configuration."""
+
+    def test_ancestor_terminal_doublestar(self):
+        """A terminal ``**`` in a parent file shadows every rule of a child file."""
+        parent = parse_permission_file(TERMINAL_ALL, Path("syft.pub.yaml"))
+        child = parse_permission_file(
+            """rules:
+- pattern: "reports/*.csv"
+  access:
+    write: [bob@example.com]
+""",
+            Path("data/syft.pub.yaml"),
+        )
+
+        findings = _unreachable(child.validate("data", [("", parent)]))
+        self.assertEqual(len(findings), 1)
+        finding = findings[0]
+        self.assertEqual((finding.index, finding.shadowed_by), (0, 0))
+        self.assertEqual(finding.ancestor_directory, "")
+        self.assertEqual(finding.terminal_rule.pattern, "**")
+        message = str(finding)
+        self.assertIn("rule 0 ('reports/*.csv') at data/syft.pub.yaml:2:3", message)
+        self.assertIn("terminal rule 0 ('**') at syft.pub.yaml:2:3", message)
+
+    def test_same_file_terminal_with_priority(self):
+        """A terminal ``**`` tried first in its own file shadows a later specific rule."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "**"
+  terminal: true
+  priority: 10
+  access:
+    read: ["*"]
+- pattern: "data/*.csv"
+  access:
+    write: [bob@example.com]
+"""
+        )
+        findings = _unreachable(perm_file.validate())
+        self.assertEqual([(f.index, f.shadowed_by) for f in findings], [(1, 0)])
+        self.assertIsNone(findings[0].ancestor_directory)
+        self.assertIn("earlier in this file", str(findings[0]))
+
+    def test_specific_rule_tried_first_not_reported(self):
+        """Without a priority the specific rule is tried before ``**`` and stays live."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "**"
+  terminal: true
+  access:
+    read: ["*"]
+- pattern: "data/*.csv"
+  access:
+    write: [bob@example.com]
+"""
+        )
+        self.assertEqual(_unreachable(perm_file.validate()), [])
+
+    def test_partial_overlap_not_reported(self):
+        """A terminal rule covering only part of a rule's paths doesn't make it dead."""
+        parent = parse_permission_file(
+            """rules:
+- pattern: "data/*.csv"
+  terminal: true
+  access:
+    read: ["*"]
+"""
+        )
+        child = parse_permission_file(
+            """rules:
+- pattern: "*"
+  access:
+    write: [bob@example.com]
+- pattern: "x.csv"
+  access:
+    write: [bob@example.com]
+"""
+        )
+        findings = _unreachable(child.validate("data", [("", parent)]))
+        self.assertEqual([f.index for f in findings], [1])
+
+    def test_narrower_wildcards_not_reported(self):
+        """A terminal ``*`` or ``?`` doesn't shadow the wider ``**`` or ``*`` after it."""
+        cases = [("*", "**", "a/b.txt"), ("data/?.csv", "data/*.csv", "data/ab.csv")]
+        for terminal, rule, path in cases:
+            perm_file = parse_permission_file(
+                f"""rules:
+- pattern: "{terminal}"
+  terminal: true
+  priority: 10
+  access:
+    read: ["*"]
+- pattern: "{rule}"
+  access:
+    write: [bob@example.com]
+"""
+            )
+            with self.subTest(terminal=terminal, rule=rule):
+                self.assertEqual(_unreachable(perm_file.validate()), [])
+                resolver = Resolver("/nonexistent", permission_files={"": perm_file})
+                self.assertEqual(resolver.resolve(path, "bob@example.com"), AccessLevel.WRITE)
+
+    def test_depth_limited_terminal_ignored(self):
+        """Terminal rules with a depth range aren't trusted to shadow anything."""
+        parent = parse_permission_file(
+            """rules:
+- pattern: "**"
+  terminal: true
+  max_depth: 5
+  access:
+    read: ["*"]
+"""
+        )
+        child = parse_permission_file('rules:\n- pattern: "*.csv"\n  access:\n    read: ["*"]\n')
+        self.assertEqual(_unreachable(child.validate("data", [("", parent)])), [])
+
+    def test_terminal_ancestor_file(self):
+        """A terminal ancestor file makes every rule below it unreachable."""
+        parent = PermissionFile(rules=[], terminal=True)
+        child = parse_permission_file('rules:\n- pattern: "*.csv"\n  access:\n    read: ["*"]\n')
+        findings = _unreachable(child.validate("team/data", [("team", parent)]))
+        self.assertEqual(len(findings), 1)
+        self.assertIsNone(findings[0].shadowed_by)
+        self.assertIn("permission file of 'team' is terminal", str(findings[0]))
+
+    def test_root_anchored_patterns(self):
+        """Anchored patterns are compared by the paths they name from the root."""
+        parent = parse_permission_file(
+            """rules:
+- pattern: "/projects/*/archive/**"
+  terminal: true
+  access:
+    read: ["*"]
+"""
+        )
+        child = parse_permission_file(
+            """rules:
+- pattern: "archive/*.csv"
+  access:
+    write: [bob@example.com]
+- pattern: "/projects/alpha/archive/old/**"
+  access:
+    write: [bob@example.com]
+- pattern: "live/*.csv"
+  access:
+    write: [bob@example.com]
+"""
+        )
+        findings = _unreachable(child.validate("projects/alpha", [("projects", parent)]))
+        self.assertEqual(sorted(f.index for f in findings), [0, 1])
+
+    def test_conflicts_still_reported(self):
+        """Conflicts come first and unreachable rules are appended after them."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "*.txt"
+  terminal: true
+  priority: 1
+  access:
+    read: [bob@example.com]
+- pattern: "*.txt"
+  access:
+    admin: [bob@example.com]
+"""
+        )
+        findings = perm_file.validate()
+        self.assertEqual([type(f) for f in findings], [RuleConflict, UnreachableRule])
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,106 @@
+"""Tests for adding rules idempotently with PermissionFile.upsert_rule."""
+
+import sys
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    PermissionFile,
+    PermissionFileBuilder,
+    Rule,
+    parse_permission_file,
+)
+
+GENERATED = """rules:
+- pattern: '**'
+  access:
+    read:
+    - '*'
+# Managed by the generator
+- pattern: data/*.csv  # analysts
+  access:
+    write:
+    - alice@example.com
+- pattern: docs/**
+  access:
+    admin:
+    - carol@example.com
+"""
+
+
+def _write_rule(pattern, *users):
+    return Rule(pattern, {AccessLevel.WRITE: list(users)})
+
+
+class TestUpsertRule(unittest.TestCase):
+    """Test replacing rules in place instead of appending duplicates."""
+
+    def test_second_upsert_updates_in_place(self):
+        """Upserting a pattern twice leaves one rule with the latest users."""
+        perm_file = PermissionFile()
+        self.assertIsNone(perm_file.upsert_rule(_write_rule("data/*.csv", "alice@example.com")))
+        perm_file.upsert_rule(_write_rule("docs/**", "carol@example.com"))
+        replaced = perm_file.upsert_rule(_write_rule("data/*.csv", "bob@example.com"))
+
+        self.assertEqual(replaced.users_for(AccessLevel.WRITE), ["alice@example.com"])
+        self.assertEqual([rule.pattern for rule in perm_file.rules], ["data/*.csv", "docs/**"])
+        self.assertEqual(perm_file.rules[0].users_for(AccessLevel.WRITE), ["bob@example.com"])
+
+    def test_keeps_position_and_comments(self):
+        """A loaded file is updated where the rule stands, keeping its comments."""
+        perm_file = parse_permission_file(GENERATED)
+        perm_file.upsert_rule(_write_rule("data/*.csv", "alice@example.com", "bob@example.com"))
+        both = "    - alice@example.com\n    - bob@example.com\n"
+        self.assertEqual(perm_file.to_yaml(), GENERATED.replace("    - alice@example.com\n", both))
+
+    def test_own_comments_win(self):
+        """A new rule with comments of its own keeps them."""
+        perm_file = parse_permission_file(GENERATED)
+        rule = _write_rule("data/*.csv", "bob@example.com")
+        rule.line_comment = "handed over"
+        perm_file.upsert_rule(rule)
+        self.assertIsNone(perm_file.rules[1].head_comment)
+        self.assertEqual(perm_file.rules[1].line_comment, "handed over")
+
+    def test_normalized_patterns(self):
+        """Spellings of the same pattern are one rule; an exclusion is another."""
+        perm_file = PermissionFile(rules=[_write_rule("./data//x.csv", "alice@example.com")])
+        perm_file.upsert_rule(_write_rule("data/x.csv", "bob@example.com"))
+        self.assertEqual(len(perm_file.rules), 1)
+        self.assertEqual(perm_file.rules[0].pattern, "data/x.csv")
+        perm_file.upsert_rule(Rule("!data/x.csv"))
+        perm_file.upsert_rule(_write_rule("data/x.csv/", "bob@example.com"))
+        self.assertEqual(
+            [rule.pattern for rule in perm_file.rules], ["data/x.csv", "!data/x.csv", "data/x.csv/"]
+        )
+
+    def test_drops_later_duplicates(self):
+        """Copies left by earlier appends are folded into the first one."""
+        perm_file = (
+            PermissionFileBuilder()
+            .add_rule("a/**")
+            .grant("read", "alice@example.com")
+            .add_rule("b/**")
+            .grant("read", "alice@example.com")
+            .add_rule("a/**")
+            .grant("write", "alice@example.com")
+            .build()
+        )
+        self.assertEqual(len(perm_file.rules), 3)
+        perm_file.upsert_rule(_write_rule("a/**", "bob@example.com"))
+        self.assertEqual([rule.pattern for rule in perm_file.rules], ["a/**", "b/**"])
+        self.assertEqual(perm_file.rules[0].level_for("bob@example.com"), AccessLevel.WRITE)
+
+    def test_append_unchanged(self):
+        """Appending to the rule list still allows duplicates."""
+        perm_file = PermissionFile()
+        perm_file.rules.append(_write_rule("a/**", "alice@example.com"))
+        perm_file.rules.append(_write_rule("a/**", "bob@example.com"))
+        self.assertEqual(len(perm_file.rules), 2)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,106 @@
+"""Tests for reusing user lists through yaml anchors and named groups."""
+
+import sys
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import AccessLevel, parse_permission_file  # noqa: E402
+
+ADMINS = ["alice@example.com", "bob@example.com"]
+
+
+class TestYamlAnchors(unittest.TestCase):
+    """Test that anchors and aliases resolve into the rule model."""
+
+    def test_alias_as_whole_list(self):
+        """An aliased list is used as the users of every level that refers to it."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "*.csv"
+  access:
+    admin: &admins [alice@example.com, bob@example.com]
+- pattern: "**"
+  access:
+    write: *admins
+"""
+        )
+        self.assertEqual(perm_file.rules[0].users_for(AccessLevel.ADMIN), ADMINS)
+        self.assertEqual(perm_file.rules[1].users_for(AccessLevel.WRITE), ADMINS)
+        self.assertEqual(perm_file.rules[1].level_for("bob@example.com"), AccessLevel.WRITE)
+
+    def test_alias_inside_list(self):
+        """An alias among other entries is spliced into the list."""
+        perm_file = parse_permission_file(
+            """admins: &admins [alice@example.com, bob@example.com]
+rules:
+- pattern: "**"
+  access:
+    read: [*admins, carol@example.com]
+"""
+        )
+        self.assertEqual(
+            perm_file.rules[0].users_for(AccessLevel.READ), ADMINS + ["carol@example.com"]
+        )
+
+    def test_merge_key(self):
+        """Whole rules can be based on an anchored mapping."""
+        perm_file = parse_permission_file(
+            """rules:
+- &base
+  pattern: "*.csv"
+  access:
+    read: ["*"]
+- <<: *base
+  pattern: "*.json"
+"""
+        )
+        self.assertEqual([rule.pattern for rule in perm_file.rules], ["*.csv", "*.json"])
+        self.assertEqual(perm_file.rules[1].users_for(AccessLevel.READ), ["*"])
+
+
+class TestGroups(unittest.TestCase):
+    """Test the top-level groups mapping."""
+
+    def test_group_references(self):
+        """A group can stand for a whole level or be one entry of its user list."""
+        perm_file = parse_permission_file(
+            """groups:
+  admins: [alice@example.com, Bob@Example.com]
+  owner_only: "{owner}"
+rules:
+- pattern: "**"
+  access:
+    admin: {group: admins}
+    read: [{group: owner_only}, carol@example.com, {group: admins}]
+"""
+        )
+        rule = perm_file.rules[0]
+        self.assertEqual(rule.users_for(AccessLevel.ADMIN), ADMINS)
+        self.assertEqual(
+            rule.users_for(AccessLevel.READ), ["{owner}", "carol@example.com"] + ADMINS
+        )
+        self.assertNotIn("groups", perm_file.to_yaml())
+
+    def test_undefined_group(self):
+        """Referring to a group that isn't defined fails to load."""
+        with self.assertRaisesRegex(ValueError, "unknown group 'admins'"):
+            parse_permission_file(
+                """rules:
+- pattern: "**"
+  access:
+    read: [{group: admins}]
+"""
+            )
+
+    def test_malformed_groups(self):
+        """Groups must map names to lists of users."""
+        for groups in ["[a, b]", "{admins: [1, 2]}", "{admins: {group: x}}"]:
+            with self.subTest(groups=groups):
+                with self.assertRaises(ValueError):
+                    parse_permission_file(f"groups: {groups}\nrules: []\n")
+
+
+if __name__ == "__main__":
+    unittest.main()
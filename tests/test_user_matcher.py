@@ -0,0 +1,134 @@
+"""Tests for plugging a custom user matcher into resolution."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    EmailUserMatcher,
+    PermissionStore,
+    Resolver,
+    UserMatcher,
+)
+
+TEAM_RULES = """rules:
+- pattern: "reports/**"
+  access:
+    read: ["team:analysts"]
+    write: ["u-1001"]
+  revoke:
+    write: ["team:contractors"]
+- pattern: "owner/**"
+  access:
+    admin: ["{owner}"]
+"""
+
+
+class TeamMatcher(UserMatcher):
+    """Opaque user IDs, with ``team:<name>`` entries covering every member."""
+
+    def __init__(self, teams):
+        self.teams = teams
+        self.calls = []
+
+    def matches(self, entry, user):
+        self.calls.append((entry, user))
+        if entry.startswith("team:"):
+            return user in self.teams.get(entry[len("team:") :], ())
+        return entry == user
+
+
+TEAMS = {"analysts": {"u-1001", "u-1002", "u-1003"}, "contractors": {"u-1003", "u-1004"}}
+
+
+class TestUserMatcher(unittest.TestCase):
+    """Test that every allow- and revoke-list comparison goes through the matcher."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        (self.test_dir / "syft.pub.yaml").write_text(TEAM_RULES)
+        self.matcher = TeamMatcher(TEAMS)
+        self.resolver = Resolver(self.test_dir, owner="u-0001", user_matcher=self.matcher)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_group_semantics(self):
+        """Team entries grant to members, and team revokes cap members."""
+        resolve = self.resolver.resolve
+        self.assertEqual(resolve("reports/q1.csv", "u-1001"), AccessLevel.WRITE)
+        self.assertEqual(resolve("reports/q1.csv", "u-1002"), AccessLevel.READ)
+        self.assertEqual(resolve("reports/q1.csv", "u-1003"), AccessLevel.READ)
+        self.assertEqual(resolve("reports/q1.csv", "u-9999"), AccessLevel.NONE)
+
+    def test_users_with_access(self):
+        """Listed users a team revoke covers are left out."""
+        (self.test_dir / "syft.pub.yaml").write_text(
+            TEAM_RULES.replace('write: ["u-1001"]', 'write: ["u-1001", "u-1004"]')
+        )
+        self.assertEqual(self.resolver.resolve("reports/q1.csv", "u-1004"), AccessLevel.CREATE)
+        listed = self.resolver.users_with_access
+        self.assertEqual(listed("reports/q1.csv", AccessLevel.WRITE), (["u-1001"], False))
+        self.assertEqual(
+            listed("reports/q1.csv", AccessLevel.READ),
+            (["team:analysts", "u-1001", "u-1004"], False),
+        )
+
+    def test_every_comparison_asked(self):
+        """The matcher sees each entry compared with the user, revokes included."""
+        self.resolver.resolve("reports/q1.csv", "u-1004")
+        self.assertEqual(
+            self.matcher.calls,
+            [("team:contractors", "u-1004"), ("u-1001", "u-1004"), ("team:analysts", "u-1004")],
+        )
+
+    def test_owner_replaced_first(self):
+        """``{owner}`` reaches the matcher as the owner's ID; without one it's skipped."""
+        self.assertEqual(self.resolver.resolve("owner/a.txt", "u-0001"), AccessLevel.ADMIN)
+        self.assertIn(("u-0001", "u-0001"), self.matcher.calls)
+        self.matcher.calls.clear()
+        resolver = Resolver(self.test_dir, user_matcher=self.matcher)
+        self.assertEqual(resolver.resolve("owner/a.txt", "u-0001"), AccessLevel.NONE)
+        self.assertEqual(self.matcher.calls, [])
+
+    def test_no_email_semantics(self):
+        """A custom matcher replaces wildcards and case folding entirely."""
+        (self.test_dir / "syft.pub.yaml").write_text(
+            'rules:\n- pattern: "**"\n  access:\n    read: ["*"]\n'
+        )
+        self.assertEqual(self.resolver.resolve("a.txt", "u-1001"), AccessLevel.NONE)
+        self.assertEqual(self.resolver.resolve("a.txt", "*"), AccessLevel.READ)
+
+    def test_default_matcher(self):
+        """Without one, resolvers compare emails and wildcards as before."""
+        matcher = Resolver(self.test_dir).user_matcher
+        self.assertIsInstance(matcher, EmailUserMatcher)
+        self.assertTrue(matcher.matches("*@example.com", "Bob@Example.com"))
+        self.assertTrue(matcher.matches("bob@example.com", "Bob@Example.com"))
+        self.assertFalse(
+            EmailUserMatcher(strict=True).matches("bob@example.com", "Bob@Example.com")
+        )
+        self.assertTrue(Resolver(self.test_dir, strict_users=True).user_matcher.strict)
+
+    def test_store(self):
+        """A store's snapshots resolve through its matcher, caching by exact ID."""
+        datasites = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        self.addCleanup(shutil.rmtree, datasites, ignore_errors=True)
+        (datasites / "u-0001").mkdir()
+        (datasites / "u-0001" / "syft.pub.yaml").write_text(TEAM_RULES)
+        store = PermissionStore(datasites, user_matcher=TeamMatcher({"analysts": {"U@x"}}))
+        store.reload_all()
+        self.assertEqual(store.resolve_cached("u-0001", "owner/a.txt", "u-0001"), AccessLevel.ADMIN)
+        self.assertEqual(store.resolve_cached("u-0001", "reports/a.csv", "U@x"), AccessLevel.READ)
+        self.assertEqual(store.resolve_cached("u-0001", "reports/a.csv", "u@x"), AccessLevel.NONE)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,150 @@
+"""Tests for the {user} pattern token filled in with the requesting user."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    Resolver,
+    UnreachableRule,
+    parse_permission_file,
+    user_path_segment,
+)
+
+OWN_FOLDER = """rules:
+- pattern: "inbox/{user}/**"
+  access:
+    write: ["*"]
+- pattern: "inbox/**"
+  access:
+    read: ["*@example.com"]
+"""
+
+ALICE = "alice@example.com"
+BOB = "bob@example.com"
+
+
+class TestUserPathSegment(unittest.TestCase):
+    """Test how a user ID becomes a directory name."""
+
+    def test_email_is_its_own_folder(self):
+        """Emails keep their spelling, lowercased unless strict."""
+        self.assertEqual(user_path_segment("Alice@Example.com"), ALICE)
+        self.assertEqual(user_path_segment("a.b+tag@x-y.org"), "a.b+tag@x-y.org")
+        self.assertEqual(user_path_segment("Alice@Example.com", strict=True), "Alice@Example.com")
+
+    def test_unsafe_characters_encoded(self):
+        """Separators, glob characters and % can't leave a single literal segment."""
+        self.assertEqual(user_path_segment("a/b"), "a%2Fb")
+        self.assertEqual(user_path_segment("a%2Fb"), "a%252Fb")
+        self.assertEqual(user_path_segment("*@x.org"), "%2A@x.org")
+        self.assertEqual(user_path_segment("[ab]{c,d}?"), "%5Bab%5D%7Bc%2Cd%7D%3F")
+
+    def test_no_segment(self):
+        """Everyone and IDs that would be empty or dot segments get no folder."""
+        for user in ("*", "", ".", ".."):
+            with self.subTest(user=user):
+                self.assertIsNone(user_path_segment(user))
+
+
+class TestUserPlaceholder(unittest.TestCase):
+    """Test one rule giving every user their own directory."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        write_file(self.test_dir, "syft.pub.yaml", OWN_FOLDER)
+        self.resolver = Resolver(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_own_directory(self):
+        """Each user matches their own directory and not anyone else's."""
+        resolve = self.resolver.resolve
+        self.assertEqual(resolve(f"inbox/{ALICE}/a.txt", ALICE), AccessLevel.WRITE)
+        self.assertEqual(resolve(f"inbox/{BOB}/a.txt", BOB), AccessLevel.WRITE)
+        self.assertEqual(resolve(f"inbox/{BOB}/a.txt", ALICE), AccessLevel.READ)
+        self.assertEqual(resolve(f"inbox/{ALICE}/a.txt", BOB), AccessLevel.READ)
+        self.assertEqual(resolve("inbox/user/a.txt", ALICE), AccessLevel.READ)
+
+    def test_case_of_requester(self):
+        """An email differing only in case still finds its folder, unless strict."""
+        self.assertEqual(
+            self.resolver.resolve(f"inbox/{ALICE}/a.txt", "Alice@Example.com"), AccessLevel.WRITE
+        )
+        strict = Resolver(self.test_dir, strict_users=True)
+        self.assertEqual(
+            strict.resolve(f"inbox/{ALICE}/a.txt", "Alice@Example.com"), AccessLevel.READ
+        )
+
+    def test_crafted_ids_stay_out(self):
+        """IDs with separators or wildcards can't reach other users' directories."""
+        resolve = self.resolver.resolve
+        self.assertEqual(resolve(f"inbox/{BOB}/a.txt", "*"), AccessLevel.NONE)
+        self.assertEqual(resolve(f"inbox/{BOB}/a.txt", "*@example.com"), AccessLevel.READ)
+        self.assertEqual(resolve(f"inbox/{BOB}/a.txt", f"{BOB}/.."), AccessLevel.NONE)
+        self.assertEqual(resolve(f"inbox/{BOB}/a.txt", "**"), AccessLevel.NONE)
+        self.assertEqual(resolve("inbox/mallory/x/a.txt", "mallory/x"), AccessLevel.NONE)
+
+    def test_shared_memo(self):
+        """Resolving one path for several users keeps each user's match apart."""
+        levels = self.resolver.resolve_for_users(f"inbox/{ALICE}/a.txt", [ALICE, BOB, ALICE])
+        self.assertEqual(levels, {ALICE: AccessLevel.WRITE, BOB: AccessLevel.READ})
+
+    def test_terminal_user_rule(self):
+        """A terminal {user} rule only cuts off nested files for the user it matches."""
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            'rules:\n- pattern: "home/{user}/**"\n  terminal: true\n  access:\n    admin: ["*"]\n',
+        )
+        write_file(self.test_dir, f"home/{ALICE}/syft.pub.yaml", _grant_read(BOB))
+        path = f"home/{ALICE}/a.txt"
+        levels = self.resolver.resolve_for_users(path, [ALICE, BOB])
+        self.assertEqual(levels, {ALICE: AccessLevel.ADMIN, BOB: AccessLevel.READ})
+
+    def test_walk(self):
+        """Listing what a user can see shows their own directory."""
+        write_file(self.test_dir, f"inbox/{ALICE}/a.txt", "a")
+        write_file(self.test_dir, f"inbox/{BOB}/b.txt", "b")
+        listed = dict(self.resolver.walk(ALICE))
+        self.assertEqual(listed[f"inbox/{ALICE}/a.txt"], AccessLevel.WRITE)
+        self.assertEqual(listed[f"inbox/{BOB}/b.txt"], AccessLevel.READ)
+
+    def test_not_expanded_at_load(self):
+        """The token is kept in the loaded rule and in what it writes back."""
+        perm_file = parse_permission_file(OWN_FOLDER)
+        self.assertTrue(perm_file.rules[0].has_user_placeholder)
+        self.assertFalse(perm_file.rules[1].has_user_placeholder)
+        self.assertIn("inbox/{user}/**", perm_file.to_yaml())
+
+    def test_not_reported_unreachable(self):
+        """A terminal {user} rule isn't taken to shadow rules it only covers for some."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "{user}/**"
+  terminal: true
+  access:
+    write: ["*"]
+- pattern: "user/**"
+  access:
+    read: ["*"]
+"""
+        )
+        self.assertEqual([f for f in perm_file.validate() if isinstance(f, UnreachableRule)], [])
+
+
+def _grant_read(user):
+    return f'rules:\n- pattern: "**"\n  access:\n    read: ["{user}"]\n'
+
+
+if __name__ == "__main__":
+    unittest.main()
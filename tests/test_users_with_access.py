@@ -0,0 +1,115 @@
+"""Tests for Resolver.users_with_access."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import AccessLevel, Resolver  # noqa: E402
+
+
+class TestUsersWithAccess(unittest.TestCase):
+    """Test listing who can access a path under nearest-node resolution."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        self.resolver = Resolver(self.test_dir)
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "**"
+  access:
+    read: ["*"]
+    write: [alice@example.com]
+- pattern: "*.csv"
+  access:
+    read: [bob@example.com, "*@partner.org"]
+    write: [alice@example.com, bob@example.com]
+    admin: [alice@example.com]
+- pattern: "!secret.csv"
+""",
+        )
+        write_file(
+            self.test_dir,
+            "vault/syft.pub.yaml",
+            """terminal: true
+rules:
+- pattern: "*.pem"
+  access:
+    read: [carol@example.com]
+""",
+        )
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_wildcard_grant(self):
+        """A * grant is flagged and only named users are listed."""
+        self.assertEqual(
+            self.resolver.users_with_access("notes.txt"), (["alice@example.com"], True)
+        )
+        self.assertEqual(
+            self.resolver.users_with_access("notes.txt", AccessLevel.WRITE),
+            (["alice@example.com"], False),
+        )
+
+    def test_users_deduped_across_levels(self):
+        """Users listed at several levels appear once; domain wildcards are kept."""
+        self.assertEqual(
+            self.resolver.users_with_access("data.csv"),
+            (["*@partner.org", "alice@example.com", "bob@example.com"], False),
+        )
+        self.assertEqual(
+            self.resolver.users_with_access("data.csv", AccessLevel.ADMIN),
+            (["alice@example.com"], False),
+        )
+
+    def test_exclusions_and_terminals(self):
+        """Excluded paths have no users and terminal files hide the root grant."""
+        self.assertEqual(self.resolver.users_with_access("secret.csv"), ([], False))
+        self.assertEqual(
+            self.resolver.users_with_access("vault/a.pem"), (["carol@example.com"], False)
+        )
+        self.assertEqual(self.resolver.users_with_access("vault/a.txt"), ([], False))
+
+    def test_agrees_with_resolve(self):
+        """Listed users resolve to at least the level, others don't unless flagged."""
+        users = ["alice@example.com", "bob@example.com", "carol@example.com", "x@partner.org"]
+        for path in ["notes.txt", "data.csv", "secret.csv", "vault/a.pem", "vault/a.txt"]:
+            for minimum in (AccessLevel.READ, AccessLevel.WRITE, AccessLevel.ADMIN):
+                listed, everyone = self.resolver.users_with_access(path, minimum)
+                for user in users:
+                    has_access = self.resolver.check_access(path, user, minimum)
+                    domain = "*@" + user.split("@")[1]
+                    expected = everyone or user in listed or domain in listed
+                    self.assertEqual(has_access, expected, (path, minimum, user))
+
+    def test_default_access_and_owner(self):
+        """Unmatched paths fall back to default_access and {owner} expands."""
+        write_file(
+            self.test_dir,
+            "home/syft.pub.yaml",
+            """terminal: true
+rules:
+- pattern: "*.md"
+  access:
+    write: ["{owner}"]
+""",
+        )
+        resolver = Resolver(
+            self.test_dir, default_access=AccessLevel.READ, owner="dana@example.com"
+        )
+        self.assertEqual(resolver.users_with_access("home/a.md"), (["dana@example.com"], False))
+        self.assertEqual(resolver.users_with_access("home/a.txt"), ([], True))
+        self.assertEqual(self.resolver.users_with_access("home/a.md"), ([], False))
+
+
+if __name__ == "__main__":
+    unittest.main()
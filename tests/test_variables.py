@@ -0,0 +1,154 @@
+"""Tests for ${name} variables in patterns and user lists expanded at load time."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    PermissionStore,
+    Resolver,
+    load_datasite,
+    load_permission_file,
+    parse_permission_file,
+)
+
+TEMPLATE = """groups:
+  team: ["${lead}", carol@example.com]
+rules:
+- pattern: "${datasite}/public/**"
+  access:
+    read: ["*"]
+- pattern: "${datasite}/shared/**"
+  access:
+    write: [{group: team}]
+    admin: "${lead}"
+"""
+
+VARIABLES = {"datasite": "alice@example.com", "lead": "Bob@Example.com"}
+
+
+class TestVariables(unittest.TestCase):
+    """Test expanding, escaping and rejecting variable references."""
+
+    def test_defined(self):
+        """Variables are expanded in patterns, user lists and groups."""
+        perm_file = parse_permission_file(TEMPLATE, variables=VARIABLES)
+        public, shared = perm_file.rules
+        self.assertEqual(public.pattern, "alice@example.com/public/**")
+        self.assertEqual(shared.pattern, "alice@example.com/shared/**")
+        self.assertEqual(shared.access[AccessLevel.WRITE], ["bob@example.com", "carol@example.com"])
+        self.assertEqual(shared.access[AccessLevel.ADMIN], ["bob@example.com"])
+
+    def test_several_in_one_string(self):
+        """A string can reference several variables, and the same one twice."""
+        perm_file = parse_permission_file(
+            'rules:\n- pattern: "${a}/${b}/${a}.txt"\n', variables={"a": "x", "b": "y"}
+        )
+        self.assertEqual(perm_file.rules[0].pattern, "x/y/x.txt")
+
+    def test_undefined(self):
+        """A reference to a variable that isn't given fails with its name and rule."""
+        with self.assertRaises(ValueError) as raised:
+            parse_permission_file(TEMPLATE, variables={"datasite": "alice@example.com"})
+        self.assertIn("'lead'", str(raised.exception))
+        with self.assertRaises(ValueError) as raised:
+            parse_permission_file('rules:\n- pattern: "${nope}/**"\n', variables={})
+        self.assertIn("rule 0", str(raised.exception))
+        self.assertIn("'nope'", str(raised.exception))
+
+    def test_unterminated(self):
+        """A ``${`` without its closing brace is rejected."""
+        with self.assertRaises(ValueError) as raised:
+            parse_permission_file('rules:\n- pattern: "${datasite/**"\n', variables=VARIABLES)
+        self.assertIn("unterminated", str(raised.exception))
+
+    def test_escaped(self):
+        """``$$`` is a literal ``$``, and so is a ``$`` not starting a reference."""
+        perm_file = parse_permission_file(
+            """rules:
+- pattern: "$${datasite}/**"
+- pattern: "costs/$$5.txt"
+- pattern: "$RECYCLE/${datasite}"
+""",
+            variables=VARIABLES,
+        )
+        self.assertEqual(
+            [rule.pattern for rule in perm_file.rules],
+            ["${datasite}/**", "costs/$5.txt", "$RECYCLE/alice@example.com"],
+        )
+
+    def test_values_not_rescanned(self):
+        """A value containing ``${...}`` or ``$$`` is inserted as it is."""
+        perm_file = parse_permission_file(
+            'rules:\n- pattern: "${a}/x"\n', variables={"a": "${b}$$"}
+        )
+        self.assertEqual(perm_file.rules[0].pattern, "${b}$$/x")
+
+    def test_without_variables(self):
+        """Without a variable map nothing is expanded or rejected."""
+        perm_file = parse_permission_file(TEMPLATE)
+        self.assertEqual(perm_file.rules[0].pattern, "${datasite}/public/**")
+
+    def test_user_lists_not_keys(self):
+        """Access level names are never expanded."""
+        with self.assertRaises(ValueError):
+            parse_permission_file(
+                'rules:\n- pattern: "**"\n  access:\n    "${level}": ["*"]\n',
+                variables={"level": "read"},
+            )
+
+
+class TestVariablesLoading(unittest.TestCase):
+    """Test that every loader passes the variable map on."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        self.datasite = self.test_dir / "alice@example.com"
+        self.datasite.mkdir()
+        (self.datasite / "syft.pub.yaml").write_text(
+            'rules:\n- pattern: "${folder}/**"\n  access:\n    read: ["${reader}"]\n'
+        )
+        self.variables = {"folder": "public", "reader": "bob@example.com"}
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_load_permission_file(self):
+        """Files read from disk are expanded."""
+        perm_file = load_permission_file(self.datasite / "syft.pub.yaml", variables=self.variables)
+        self.assertEqual(perm_file.rules[0].pattern, "public/**")
+
+    def test_resolver_and_datasite(self):
+        """Resolvers and datasites loaded with variables grant the expanded rules."""
+        resolvers = [
+            Resolver(self.datasite, variables=self.variables),
+            load_datasite(self.datasite, variables=self.variables).resolver(),
+        ]
+        for resolver in resolvers:
+            self.assertEqual(resolver.resolve("public/a.txt", "bob@example.com"), AccessLevel.READ)
+            self.assertEqual(resolver.resolve("other/a.txt", "bob@example.com"), AccessLevel.NONE)
+
+    def test_store(self):
+        """A store expands the variables in every datasite it loads."""
+        store = PermissionStore(self.test_dir, variables=self.variables)
+        store.reload_all()
+        snapshot = store.get("alice@example.com")
+        self.assertEqual(snapshot.resolve("public/a.txt", "bob@example.com"), AccessLevel.READ)
+
+    def test_undefined_when_loading(self):
+        """An undefined variable names the file it appears in."""
+        with self.assertRaises(ValueError) as raised:
+            load_datasite(self.datasite, variables={"folder": "public"})
+        self.assertIn(str(self.datasite / "syft.pub.yaml"), str(raised.exception))
+        self.assertIn("'reader'", str(raised.exception))
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,174 @@
+"""Tests for granting individual verbs beyond the access level ladder."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from syft_perm.core import (  # noqa: E402
+    AccessLevel,
+    PermissionFileBuilder,
+    PermissionFileBuildError,
+    ResolutionStrategy,
+    Resolver,
+    UnknownAccessLevelError,
+    Verb,
+    level_for_verbs,
+    parse_permission_file,
+    verbs_for_level,
+)
+
+DROPBOX_RULES = """rules:
+- pattern: "inbox/**"
+  access:
+    read: [bob@example.com]
+  verbs:
+    create: [bob@example.com]
+- pattern: "log/**"
+  verbs:
+    read: [carol@example.com]
+    create: [carol@example.com]
+    update: [carol@example.com]
+- pattern: "**"
+  access:
+    write: [alice@example.com]
+"""
+
+BOB = "bob@example.com"
+CAROL = "carol@example.com"
+
+
+class TestVerbBundles(unittest.TestCase):
+    """Test how access levels map to verb sets and back."""
+
+    def test_bundles(self):
+        """Each level's bundle includes every bundle below it."""
+        self.assertEqual(verbs_for_level(AccessLevel.NONE), Verb(0))
+        self.assertEqual(verbs_for_level(AccessLevel.CREATE), Verb.READ | Verb.CREATE)
+        write = Verb.READ | Verb.CREATE | Verb.UPDATE | Verb.DELETE
+        self.assertEqual(verbs_for_level(AccessLevel.WRITE), write)
+        self.assertEqual(verbs_for_level(AccessLevel.ADMIN), write | Verb.ADMIN)
+
+    def test_round_trip(self):
+        """Levels survive the trip to verbs and back; partial sets round down."""
+        for level in AccessLevel:
+            self.assertEqual(level_for_verbs(verbs_for_level(level)), level)
+        self.assertEqual(level_for_verbs(Verb.CREATE), AccessLevel.NONE)
+        self.assertEqual(level_for_verbs(Verb.READ | Verb.CREATE | Verb.UPDATE), AccessLevel.CREATE)
+
+    def test_str(self):
+        """Verb sets are written with the yaml names, in ladder order."""
+        self.assertEqual(str(Verb.DELETE | Verb.READ), "read+delete")
+
+
+class TestVerbs(unittest.TestCase):
+    """Test rules granting verbs alongside access levels."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        (self.test_dir / "syft.pub.yaml").write_text(DROPBOX_RULES)
+        self.resolver = Resolver(self.test_dir)
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_create_and_read_but_not_delete(self):
+        """A rule granting read and create leaves out update and delete."""
+        self.assertEqual(self.resolver.resolve_verbs("inbox/a.csv", BOB), Verb.READ | Verb.CREATE)
+        self.assertTrue(self.resolver.can_read("inbox/a.csv", BOB))
+        self.assertTrue(self.resolver.can_create("inbox/a.csv", BOB))
+        self.assertFalse(self.resolver.can_write("inbox/a.csv", BOB))
+        self.assertFalse(self.resolver.can_delete("inbox/a.csv", BOB))
+        self.assertEqual(self.resolver.resolve("inbox/a.csv", BOB), AccessLevel.CREATE)
+
+    def test_append_only(self):
+        """Verbs alone can grant writing without deleting, short of any level above create."""
+        verbs = self.resolver.resolve_verbs("log/today.txt", CAROL)
+        self.assertEqual(verbs, Verb.READ | Verb.CREATE | Verb.UPDATE)
+        self.assertTrue(self.resolver.can_write("log/today.txt", CAROL))
+        self.assertFalse(self.resolver.can_delete("log/today.txt", CAROL))
+        self.assertEqual(self.resolver.resolve("log/today.txt", CAROL), AccessLevel.CREATE)
+
+    def test_levels_unchanged(self):
+        """Rules without verbs grant their level's whole bundle."""
+        verbs = self.resolver.resolve_verbs("notes.txt", "alice@example.com")
+        self.assertEqual(verbs, verbs_for_level(AccessLevel.WRITE))
+        self.assertTrue(self.resolver.can_delete("notes.txt", "alice@example.com"))
+        self.assertFalse(self.resolver.can_admin("notes.txt", "alice@example.com"))
+
+    def test_revoke_caps_verbs(self):
+        """Revoking a level leaves at most the bundle of the level below it."""
+        (self.test_dir / "syft.pub.yaml").write_text(
+            DROPBOX_RULES + "- pattern: 'log/**'\n  revoke:\n    create: [carol@example.com]\n"
+        )
+        self.assertEqual(self.resolver.resolve_verbs("log/today.txt", CAROL), Verb.READ)
+
+    def test_most_permissive_unites_verbs(self):
+        """Combining rules adds their verbs, so the level can be more than either grants."""
+        (self.test_dir / "syft.pub.yaml").write_text(
+            "rules:\n- pattern: 'a/**'\n  access:\n    read: ['*']\n"
+            "- pattern: 'a/*.txt'\n  verbs:\n    create: [bob@example.com]\n"
+        )
+        resolver = Resolver(self.test_dir, strategy=ResolutionStrategy.MOST_PERMISSIVE)
+        self.assertEqual(resolver.resolve("a/x.txt", BOB), AccessLevel.CREATE)
+        self.assertEqual(self.resolver.resolve("a/x.txt", BOB), AccessLevel.NONE)
+
+    def test_users_with_access(self):
+        """Verb lists count toward the levels they complete."""
+        self.assertEqual(
+            self.resolver.users_with_access("inbox/a.csv", AccessLevel.CREATE), ([BOB], False)
+        )
+        self.assertEqual(
+            self.resolver.users_with_access("log/a.txt", AccessLevel.CREATE), ([CAROL], False)
+        )
+        self.assertEqual(
+            self.resolver.users_with_access("log/a.txt", AccessLevel.WRITE), ([], False)
+        )
+
+
+class TestVerbsInFiles(unittest.TestCase):
+    """Test reading, writing and building the ``verbs`` key."""
+
+    def test_round_trip(self):
+        """Verbs are written back after access, from read up to admin."""
+        perm_file = parse_permission_file(
+            "rules:\n- pattern: x/**\n  verbs:\n    delete: [bob@example.com]\n"
+            "    Read: [Bob@Example.com]\n"
+        )
+        rule = perm_file.rules[0]
+        self.assertEqual(rule.verbs, {Verb.DELETE: [BOB], Verb.READ: [BOB]})
+        self.assertFalse(rule.is_revoke_only)
+        yaml_text = perm_file.to_yaml()
+        self.assertIn("  verbs:\n    read:\n    - bob@example.com\n    delete:\n", yaml_text)
+        self.assertEqual(parse_permission_file(yaml_text), perm_file)
+
+    def test_unknown_verb(self):
+        """A misspelled verb names the rule and the known verbs."""
+        with self.assertRaises(UnknownAccessLevelError) as raised:
+            parse_permission_file("rules:\n- pattern: x/**\n  verbs:\n    append: [bob]\n")
+        self.assertIn("rule 0", str(raised.exception))
+        self.assertIn("read, create, update, delete, admin", str(raised.exception))
+
+    def test_builder(self):
+        """The builder can allow single verbs or a combination of them."""
+        perm_file = (
+            PermissionFileBuilder()
+            .add_rule("inbox/**")
+            .allow("create", BOB)
+            .allow(Verb.READ | Verb.UPDATE, CAROL)
+            .build()
+        )
+        rule = perm_file.rules[0]
+        self.assertEqual(rule.verbs_for(BOB), Verb.CREATE)
+        self.assertEqual(rule.verbs_for(CAROL), Verb.READ | Verb.UPDATE)
+        with self.assertRaises(PermissionFileBuildError):
+            PermissionFileBuilder().add_rule("x").allow("append", BOB).build()
+
+
+if __name__ == "__main__":
+    unittest.main()
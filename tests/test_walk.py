@@ -0,0 +1,174 @@
+"""Tests for walking a datasite with each file's effective access."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+from unittest.mock import patch
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+from helpers import write_file  # noqa: E402
+from syft_perm.core import AccessLevel, Resolver  # noqa: E402
+from syft_perm.core import resolver as resolver_module  # noqa: E402
+
+
+class TestWalk(unittest.TestCase):
+    """Test Resolver.walk results, laziness and pruning."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "public/**"
+  access:
+    read: ["*"]
+- pattern: "shared/*.csv"
+  access:
+    write: [alice@example.com]
+""",
+        )
+        for rel_path in [
+            "readme.md",
+            "public/a.txt",
+            "public/deep/b.txt",
+            "shared/data.csv",
+            "shared/notes.txt",
+            "private/keys/id.pem",
+            "private/diary.txt",
+            ".hidden/secret.txt",
+        ]:
+            write_file(self.test_dir, rel_path, "x")
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+
+    def test_levels_match_resolve(self):
+        """Every visible file is yielded once with the level resolve() gives."""
+        resolver = Resolver(self.test_dir)
+        results = dict(resolver.walk("alice@example.com"))
+        self.assertEqual(
+            set(results),
+            {
+                "readme.md",
+                "public/a.txt",
+                "public/deep/b.txt",
+                "shared/data.csv",
+                "shared/notes.txt",
+                "private/keys/id.pem",
+                "private/diary.txt",
+            },
+        )
+        for rel_path, level in results.items():
+            self.assertEqual(level, resolver.resolve(rel_path, "alice@example.com"), rel_path)
+        self.assertEqual(results["shared/data.csv"], AccessLevel.WRITE)
+        self.assertEqual(results["private/diary.txt"], AccessLevel.NONE)
+
+    def test_stops_early_and_loads_lazily(self):
+        """Breaking out of the loop ends the walk; permission files are loaded once."""
+        resolver = Resolver(self.test_dir)
+        real_load = resolver_module.load_permission_file
+        with patch.object(
+            resolver_module, "load_permission_file", side_effect=real_load
+        ) as load_mock:
+            walker = resolver.walk("alice@example.com")
+            first = next(walker)
+            walker.close()
+        self.assertEqual(first, ("readme.md", AccessLevel.NONE))
+        self.assertEqual(load_mock.call_count, 1)
+
+    def test_prune_no_access(self):
+        """Directories no rule can open up for the user are skipped."""
+        resolver = Resolver(self.test_dir)
+        pruned = dict(resolver.walk("bob@example.com", prune_no_access=True))
+        self.assertNotIn("private/diary.txt", pruned)
+        self.assertNotIn("private/keys/id.pem", pruned)
+        # shared/*.csv lists only alice, so bob has nothing to see there either
+        self.assertNotIn("shared/data.csv", pruned)
+        self.assertEqual(pruned["public/deep/b.txt"], AccessLevel.READ)
+        # Files directly in the root are always listed
+        self.assertIn("readme.md", pruned)
+
+    def test_prune_keeps_nested_grants(self):
+        """A directory is entered when a nested permission file might grant access."""
+        write_file(
+            self.test_dir,
+            "private/keys/syft.pub.yaml",
+            'rules:\n- pattern: "*.pem"\n  access:\n    read: [bob@example.com]\n',
+        )
+        resolver = Resolver(self.test_dir)
+        pruned = dict(resolver.walk("bob@example.com", prune_no_access=True))
+        self.assertEqual(pruned["private/keys/id.pem"], AccessLevel.READ)
+
+    def test_prune_respects_terminal(self):
+        """Below a terminal file, nested permission files can't grant and are ignored."""
+        write_file(self.test_dir, "private/syft.pub.yaml", "terminal: true\nrules: []\n")
+        write_file(
+            self.test_dir,
+            "private/keys/syft.pub.yaml",
+            'rules:\n- pattern: "*.pem"\n  access:\n    read: [bob@example.com]\n',
+        )
+        resolver = Resolver(self.test_dir)
+        pruned = dict(resolver.walk("bob@example.com", prune_no_access=True))
+        self.assertNotIn("private/keys/id.pem", pruned)
+        unpruned = dict(resolver.walk("bob@example.com"))
+        self.assertEqual(unpruned["private/keys/id.pem"], AccessLevel.NONE)
+
+
+    def test_empty_and_comment_only_files(self):
+        """Permission files with no content are valid files without rules."""
+        write_file(self.test_dir, "public/syft.pub.yaml", "")
+        write_file(self.test_dir, "shared/syft.pub.yaml", "# rules go here later\n\n")
+        results = dict(Resolver(self.test_dir).walk("alice@example.com"))
+        self.assertEqual(results["public/a.txt"], AccessLevel.READ)
+        self.assertEqual(results["shared/data.csv"], AccessLevel.WRITE)
+
+    def test_malformed_file_aborts_by_default(self):
+        """Broken yaml raises out of the walk."""
+        write_file(self.test_dir, "private/syft.pub.yaml", "rules: [")
+        with self.assertRaises(ValueError):
+            list(Resolver(self.test_dir).walk("alice@example.com"))
+
+    def test_skip_invalid_files(self):
+        """Skipped files are logged and deny everything beneath them."""
+        write_file(self.test_dir, "public/deep/syft.pub.yaml", "rules: [")
+        write_file(self.test_dir, "shared/syft.pub.yaml", 'rules:\n- pattern: "[oops"\n')
+        resolver = Resolver(self.test_dir)
+        with self.assertLogs(resolver_module.logger, "WARNING") as logs:
+            results = dict(resolver.walk("alice@example.com", skip_invalid_files=True))
+        self.assertEqual(len(logs.records), 2)
+        self.assertIn("public/deep/syft.pub.yaml", logs.output[0])
+        self.assertEqual(results["public/a.txt"], AccessLevel.READ)
+        self.assertEqual(results["public/deep/b.txt"], AccessLevel.NONE)
+        self.assertEqual(results["shared/data.csv"], AccessLevel.NONE)
+        self.assertEqual(results["readme.md"], AccessLevel.NONE)
+
+        with self.assertLogs(resolver_module.logger, "WARNING"):
+            pruned = dict(resolver.walk("alice@example.com", True, skip_invalid_files=True))
+        self.assertEqual(set(pruned), {"readme.md", "public/a.txt"})
+
+    def test_max_depth(self):
+        """Directories below the limit are reported instead of entered."""
+        resolver = Resolver(self.test_dir)
+        skipped = []
+        results = dict(
+            resolver.walk("alice@example.com", max_depth=1, on_depth_limit=skipped.append)
+        )
+        self.assertNotIn("public/deep/b.txt", results)
+        self.assertNotIn("private/keys/id.pem", results)
+        self.assertIn("public/a.txt", results)
+        self.assertEqual(skipped, ["private/keys", "public/deep"])
+
+        root_only = [path for path, _ in resolver.walk("alice@example.com", max_depth=0)]
+        self.assertEqual(root_only, ["readme.md"])
+        unlimited = dict(resolver.walk("alice@example.com", max_depth=None))
+        self.assertIn("public/deep/b.txt", unlimited)
+
+
+if __name__ == "__main__":
+    unittest.main()
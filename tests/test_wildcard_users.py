@@ -0,0 +1,92 @@
+"""Tests for wildcard and domain entries in allow lists."""
+
+import shutil
+import sys
+import tempfile
+import unittest
+from pathlib import Path
+
+sys.path.insert(0, str(Path(__file__).parent.parent / "src"))
+
+import syft_perm  # noqa: E402
+from helpers import write_file  # noqa: E402
+from syft_perm._impl import clear_permission_cache  # noqa: E402
+from syft_perm.core import AccessLevel, Resolver, _user_matches  # noqa: E402
+
+
+class TestWildcardUsers(unittest.TestCase):
+    """Test that "*" and "*@domain" entries grant access to the users they cover."""
+
+    def setUp(self):
+        """Create a temporary datasite."""
+        self.test_dir = Path(tempfile.mkdtemp(prefix="syft_perm_test_"))
+        self.resolver = Resolver(self.test_dir)
+        clear_permission_cache()
+
+    def tearDown(self):
+        """Clean up test directory."""
+        shutil.rmtree(self.test_dir, ignore_errors=True)
+        clear_permission_cache()
+
+    def test_entry_matching(self):
+        """Domain entries match by email suffix only."""
+        self.assertTrue(_user_matches("*", "anyone@example.com"))
+        self.assertTrue(_user_matches("*@openmined.org", "alice@openmined.org"))
+        self.assertTrue(_user_matches("*@openmined.org", "Alice@OpenMined.org"))
+        self.assertFalse(_user_matches("*@openmined.org", "alice@sub.openmined.org"))
+        self.assertFalse(_user_matches("*@openmined.org", "alice@evilopenmined.org"))
+        self.assertFalse(_user_matches("*@openmined.org", "openmined.org"))
+        self.assertFalse(_user_matches("alice@openmined.org", "bob@openmined.org"))
+
+    def test_explicit_write_beats_public_read(self):
+        """A user listed explicitly for write keeps write despite a "*" read grant."""
+        write_file(self.test_dir, "notes.txt", "x")
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "*.txt"
+  access:
+    read: ["*"]
+    write: [alice@example.com]
+""",
+        )
+
+        self.assertEqual(self.resolver.resolve("notes.txt", "alice@example.com"), AccessLevel.WRITE)
+        self.assertEqual(self.resolver.resolve("notes.txt", "bob@example.com"), AccessLevel.READ)
+
+        syft_file = syft_perm.open(self.test_dir / "notes.txt")
+        self.assertTrue(syft_file.has_write_access("alice@example.com"))
+        self.assertFalse(syft_file.has_write_access("bob@example.com"))
+        self.assertTrue(syft_file.has_read_access("bob@example.com"))
+
+    def test_domain_grant(self):
+        """A *@domain entry grants its level to every user at that domain."""
+        write_file(self.test_dir, "report.csv", "a,b")
+        write_file(
+            self.test_dir,
+            "syft.pub.yaml",
+            """rules:
+- pattern: "*.csv"
+  access:
+    read: ["*@example.com"]
+    write: ["*@openmined.org"]
+    admin: [root@openmined.org]
+""",
+        )
+
+        cases = {
+            "alice@openmined.org": AccessLevel.WRITE,
+            "root@openmined.org": AccessLevel.ADMIN,
+            "bob@example.com": AccessLevel.READ,
+            "eve@elsewhere.net": AccessLevel.NONE,
+        }
+        syft_file = syft_perm.open(self.test_dir / "report.csv")
+        for user, expected in cases.items():
+            self.assertEqual(self.resolver.resolve("report.csv", user), expected, user)
+            self.assertEqual(syft_file.has_write_access(user), expected >= AccessLevel.WRITE, user)
+            self.assertEqual(syft_file.has_read_access(user), expected >= AccessLevel.READ, user)
+
+
+if __name__ == "__main__":
+    unittest.main()